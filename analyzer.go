@@ -1,42 +1,66 @@
 package pirsch
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"sort"
+	"strings"
 	"time"
 )
 
-// PathVisitors represents visitor statistics per day for a path.
+// ErrNotArchived is returned by the Analyzer if statistics counted from the hits, like those filtered by visitor attributes,
+// path patterns, or content groups, are requested for days whose hits have not been archived (see AnalyzerConfig.ArchiveRetention).
+var ErrNotArchived = errors.New("hits for the time frame have not been archived")
+
+// PathVisitors represents visitor statistics per day (or interval) for a path.
 type PathVisitors struct {
 	Path  string  `json:"path"`
 	Stats []Stats `json:"stats"`
 }
 
-// TimeOfDayVisitors represents the visitor count per day and hour for a path.
+// TimeOfDayVisitors represents the visitor count per day (or interval) and hour for a path.
 type TimeOfDayVisitors struct {
 	Day   time.Time          `json:"day"`
 	Stats []VisitorTimeStats `json:"stats"`
 }
 
-// Growth represents the visitors, sessions, and bounces growth between two time periods.
+// Growth represents the visitors, page views, sessions, bounces, average session duration, and average time on page growth between two time periods.
 type Growth struct {
-	Current        *Stats  `json:"current"`
-	Previous       *Stats  `json:"previous"`
-	VisitorsGrowth float64 `json:"visitors_growth"`
-	SessionsGrowth float64 `json:"sessions_growth"`
-	BouncesGrowth  float64 `json:"bounces_growth"`
+	Current               *Stats  `json:"current"`
+	Previous              *Stats  `json:"previous"`
+	VisitorsGrowth        float64 `json:"visitors_growth"`
+	PageViewsGrowth       float64 `json:"page_views_growth"`
+	SessionsGrowth        float64 `json:"sessions_growth"`
+	BouncesGrowth         float64 `json:"bounces_growth"`
+	SessionDurationGrowth float64 `json:"session_duration_growth"`
+	TimeOnPageGrowth      float64 `json:"time_on_page_growth"`
 }
 
 // Analyzer provides an interface to analyze processed data and hits.
 type Analyzer struct {
-	store    Store
-	timezone *time.Location
+	store            Store
+	timezone         *time.Location
+	dimensions       map[string]Dimension
+	archiveRetention time.Duration
 }
 
 // AnalyzerConfig is the (optional) configuration for the Analyzer.
 type AnalyzerConfig struct {
 	// Timezone sets the time zone for the result set.
-	// If not set, UTC will be used.
+	// If not set, UTC will be used. Weeks, months, and years (see Filter.Interval) are bucketed in the database,
+	// so this must be a location known to Postgres, like one loaded using time.LoadLocation("Europe/Berlin").
 	Timezone *time.Location
+
+	// Dimensions are the custom dimensions the visitors can be broken down by in addition to the built-in dimensions.
+	// They must have been passed to the Processor as well.
+	Dimensions []Dimension
+
+	// ArchiveRetention must be set to the ProcessorConfig.ArchiveRetention.
+	// Statistics filtered by visitor attributes, path patterns, or content groups and time series grouped by week, month, or year are counted from the hits,
+	// so ErrNotArchived is returned if the time frame includes days before today that are not kept in the archive.
+	// The archive is disabled by default (0), which limits these statistics to today.
+	ArchiveRetention time.Duration
 }
 
 func (config *AnalyzerConfig) validate() {
@@ -46,15 +70,24 @@ func (config *AnalyzerConfig) validate() {
 }
 
 // NewAnalyzer returns a new Analyzer for given Store.
+// NewAnalyzer panics if one of the dimensions is invalid (see Dimension.Validate).
 func NewAnalyzer(store Store, config *AnalyzerConfig) *Analyzer {
 	if config == nil {
 		config = new(AnalyzerConfig)
 	}
 
 	config.validate()
+	dimensions, err := newDimensions(config.Dimensions)
+
+	if err != nil {
+		panic(fmt.Sprintf("pirsch: invalid dimension: %s", err))
+	}
+
 	return &Analyzer{
-		store:    store,
-		timezone: config.Timezone,
+		store:            store,
+		timezone:         config.Timezone,
+		dimensions:       dimensions,
+		archiveRetention: config.ArchiveRetention,
 	}
 }
 
@@ -63,19 +96,30 @@ func NewAnalyzer(store Store, config *AnalyzerConfig) *Analyzer {
 // The correct date/time is not included.
 func (analyzer *Analyzer) ActiveVisitors(filter *Filter, duration time.Duration) ([]Stats, int, error) {
 	filter = analyzer.getFilter(filter)
+
+	if err := analyzer.resolvePaths(filter); err != nil {
+		return nil, 0, err
+	}
+
 	from := time.Now().UTC().Add(-duration)
-	stats, err := analyzer.store.ActivePageVisitors(filter.TenantID, from)
+	stats, err := analyzer.store.ActivePageVisitorsFilter(filter.withoutPath(), from)
 
 	if err != nil {
 		return nil, 0, err
 	}
 
-	return stats, analyzer.store.ActiveVisitors(filter.TenantID, from), nil
+	return stats, analyzer.store.ActiveVisitorsFilter(filter.withoutPath(), from), nil
 }
 
-// Visitors returns the visitor count, session count, and bounce rate per day.
+// Visitors returns the visitor count, page views, session count, views per visit, bounce rate, average session duration, and average time on page per day.
+// The statistics are grouped by week, month, or year instead, if the Filter.Interval is set.
 func (analyzer *Analyzer) Visitors(filter *Filter) ([]Stats, error) {
 	filter = analyzer.getFilter(filter)
+
+	if filter.needsFilterStats() || filter.groupsByInterval() {
+		return analyzer.filteredVisitors(filter.withoutPath())
+	}
+
 	today := today()
 	addToday := today.Equal(filter.To)
 	stats, err := analyzer.store.Visitors(filter.TenantID, filter.From, filter.To)
@@ -86,36 +130,48 @@ func (analyzer *Analyzer) Visitors(filter *Filter) ([]Stats, error) {
 
 	if addToday {
 		visitorsToday := analyzer.store.CountVisitors(nil, filter.TenantID, today)
-		bouncesToday := analyzer.store.CountVisitorsByPathAndMaxOneHit(nil, filter.TenantID, today, "")
+		sessionStatsToday, err := analyzer.sessionStatsToday(filter.TenantID)
 
-		if len(stats) > 0 {
-			if visitorsToday != nil {
-				stats[len(stats)-1].Visitors += visitorsToday.Visitors
-				stats[len(stats)-1].Sessions += visitorsToday.Sessions
-				stats[len(stats)-1].Bounces += bouncesToday
-			}
-		} else {
-			stats = append(stats, Stats{
-				Visitors: visitorsToday.Visitors,
-				Sessions: visitorsToday.Sessions,
-				Bounces:  visitorsToday.Bounces,
-			})
+		if err != nil {
+			return nil, err
+		}
+
+		sessionStats := sumSessionStats(sessionStatsToday, "")
+
+		if len(stats) == 0 {
+			stats = append(stats, Stats{Day: today})
+		}
+
+		if visitorsToday != nil {
+			stats[len(stats)-1].Visitors += visitorsToday.Visitors
+			stats[len(stats)-1].PageViews += visitorsToday.PageViews
+			stats[len(stats)-1].Sessions += visitorsToday.Sessions
 		}
+
+		stats[len(stats)-1].addSessionStats(&sessionStats)
 	}
 
 	for i := range stats {
-		if stats[i].Visitors > 0 {
-			stats[i].BounceRate = float64(stats[i].Bounces) / float64(stats[i].Visitors)
-		}
+		stats[i].calculateBounceRate()
+		stats[i].calculateViewsPerVisit()
+		stats[i].calculateAverageDurations()
 	}
 
 	return stats, nil
 }
 
 // VisitorHours returns the visitor and session count grouped by hour of day for given time frame.
+// The time frame is made of the days in the Analyzer time zone, if the Filter.Interval is set to a week, month, or year.
 func (analyzer *Analyzer) VisitorHours(filter *Filter) ([]VisitorTimeStats, error) {
 	filter = analyzer.getFilter(filter)
-	stats, err := analyzer.store.VisitorHours(filter.TenantID, filter.From, filter.To)
+	var stats []VisitorTimeStats
+	var err error
+
+	if filter.needsFilterStats() || filter.groupsByInterval() {
+		stats, err = analyzer.filteredVisitorHours(filter.withoutPath())
+	} else {
+		stats, err = analyzer.store.VisitorHours(filter.TenantID, filter.From, filter.To)
+	}
 
 	if err != nil {
 		return nil, err
@@ -132,217 +188,194 @@ func (analyzer *Analyzer) VisitorHours(filter *Filter) ([]VisitorTimeStats, erro
 	return stats, nil
 }
 
-// Languages returns the visitor count per language.
-func (analyzer *Analyzer) Languages(filter *Filter) ([]LanguageStats, error) {
-	filter = analyzer.getFilter(filter)
-	today := today()
-	addToday := today.Equal(filter.To)
-	stats, err := analyzer.store.VisitorLanguages(filter.TenantID, filter.From, filter.To)
-
-	if err != nil {
-		return nil, err
-	}
+// Breakdown returns the visitor count per value of given dimension.
+// The dimension must either be built-in (like DimensionLanguage), DimensionContentGroup, or configured in the AnalyzerConfig.
+// ErrUnknownDimension is returned otherwise.
+func (analyzer *Analyzer) Breakdown(filter *Filter, dimension string) ([]DimensionStats, error) {
+	var stats []DimensionStats
+	var err error
 
-	if addToday {
-		visitorsToday, err := analyzer.store.CountVisitorsByLanguage(nil, filter.TenantID, today)
+	if dimension == DimensionContentGroup {
+		stats, err = analyzer.contentGroups(analyzer.getFilter(filter).withoutPath())
+	} else {
+		d, found := analyzer.dimensions[dimension]
 
-		if err != nil {
-			return nil, err
+		if !found {
+			return nil, ErrUnknownDimension
 		}
 
-		for _, v := range visitorsToday {
-			found := false
-
-			for i, s := range stats {
-				if s.Language.String == v.Language.String {
-					stats[i].Visitors += v.Visitors
-					found = true
-					break
-				}
-			}
+		filter = analyzer.getFilter(filter)
 
-			if !found {
-				stats = append(stats, v)
-			}
+		if filter.needsFilterStats() {
+			stats, err = analyzer.filteredBreakdown(filter.withoutPath(), d)
+		} else {
+			stats, err = analyzer.breakdown(filter, d)
 		}
 	}
 
-	sort.Slice(stats, func(i, j int) bool {
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
 		return stats[i].Visitors > stats[j].Visitors
 	})
 
-	var sum float64
-
-	for i := range stats {
-		sum += float64(stats[i].Visitors)
-	}
-
-	for i := range stats {
-		stats[i].RelativeVisitors = float64(stats[i].Visitors) / sum
-	}
+	calculateRelativeVisitors(len(stats), func(i int) *Stats {
+		return &stats[i].Stats
+	})
 
 	return stats, nil
 }
 
-// Referrer returns the visitor count per referrer.
-func (analyzer *Analyzer) Referrer(filter *Filter) ([]ReferrerStats, error) {
-	filter = analyzer.getFilter(filter)
-	today := today()
-	addToday := today.Equal(filter.To)
-	stats, err := analyzer.store.VisitorReferrer(filter.TenantID, filter.From, filter.To)
+// Languages returns the visitor count per language.
+func (analyzer *Analyzer) Languages(filter *Filter) ([]LanguageStats, error) {
+	stats, err := analyzer.Breakdown(filter, DimensionLanguage)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if addToday {
-		visitorsToday, err := analyzer.store.CountVisitorsByReferrer(nil, filter.TenantID, today)
-
-		if err != nil {
-			return nil, err
-		}
-
-		for _, v := range visitorsToday {
-			found := false
+	return languageStats(stats), nil
+}
 
-			for i, s := range stats {
-				if s.Referrer.String == v.Referrer.String {
-					stats[i].Visitors += v.Visitors
-					found = true
-					break
-				}
-			}
+// Referrer returns the visitor count per referrer.
+func (analyzer *Analyzer) Referrer(filter *Filter) ([]ReferrerStats, error) {
+	stats, err := analyzer.Breakdown(filter, DimensionReferrer)
 
-			if !found {
-				stats = append(stats, v)
-			}
-		}
+	if err != nil {
+		return nil, err
 	}
 
-	sort.Slice(stats, func(i, j int) bool {
-		return stats[i].Visitors > stats[j].Visitors
-	})
-
-	var sum float64
+	return referrerStats(stats), nil
+}
 
-	for i := range stats {
-		sum += float64(stats[i].Visitors)
-	}
+// ReferrerSource returns the visitor count per referrer source (like Google or Twitter) and channel.
+// Referrers not known as a source are grouped by hostname. Direct visitors have no source name.
+func (analyzer *Analyzer) ReferrerSource(filter *Filter) ([]ReferrerSourceStats, error) {
+	filter = analyzer.getFilter(filter)
 
-	for i := range stats {
-		stats[i].RelativeVisitors = float64(stats[i].Visitors) / sum
+	if filter.needsFilterStats() {
+		return analyzer.filteredReferrerSource(filter.withoutPath(), "referrer_name", "channel")
 	}
 
-	return stats, nil
-}
-
-// OS returns the visitor count per operating system.
-func (analyzer *Analyzer) OS(filter *Filter) ([]OSStats, error) {
-	filter = analyzer.getFilter(filter)
 	today := today()
 	addToday := today.Equal(filter.To)
-	stats, err := analyzer.store.VisitorOS(filter.TenantID, filter.From, filter.To)
+	stats, err := analyzer.store.VisitorReferrerSource(filter.TenantID, filter.From, filter.To)
 
 	if err != nil {
 		return nil, err
 	}
 
 	if addToday {
-		visitorsToday, err := analyzer.store.CountVisitorsByOS(nil, filter.TenantID, today)
+		visitorsToday, err := analyzer.store.CountVisitorsByReferrerSource(nil, filter.TenantID, today)
 
 		if err != nil {
 			return nil, err
 		}
 
-		for _, v := range visitorsToday {
-			found := false
+		type source struct {
+			referrerName sql.NullString
+			channel      sql.NullString
+		}
+		index := make(map[source]int, len(stats))
 
-			for i, s := range stats {
-				if s.OS.String == v.OS.String {
-					stats[i].Visitors += v.Visitors
-					found = true
-					break
-				}
-			}
+		for i := range stats {
+			index[source{stats[i].ReferrerName, stats[i].Channel}] = i
+		}
+
+		for _, v := range visitorsToday {
+			key := source{v.ReferrerName, v.Channel}
 
-			if !found {
+			if i, found := index[key]; found {
+				stats[i].Visitors += v.Visitors
+				stats[i].PageViews += v.PageViews
+			} else {
+				index[key] = len(stats)
 				stats = append(stats, v)
 			}
 		}
 	}
 
-	sort.Slice(stats, func(i, j int) bool {
-		return stats[i].Visitors > stats[j].Visitors
-	})
-
-	var sum float64
-
-	for i := range stats {
-		sum += float64(stats[i].Visitors)
-	}
-
-	for i := range stats {
-		stats[i].RelativeVisitors = float64(stats[i].Visitors) / sum
-	}
-
+	analyzer.calculateReferrerSourceRelativeVisitors(stats)
 	return stats, nil
 }
 
-// Browser returns the visitor count per browser.
-func (analyzer *Analyzer) Browser(filter *Filter) ([]BrowserStats, error) {
+// Channel returns the visitor count per channel (direct, search, social, email, and referral).
+func (analyzer *Analyzer) Channel(filter *Filter) ([]ReferrerSourceStats, error) {
 	filter = analyzer.getFilter(filter)
+
+	if filter.needsFilterStats() {
+		return analyzer.filteredReferrerSource(filter.withoutPath(), "channel")
+	}
+
 	today := today()
 	addToday := today.Equal(filter.To)
-	stats, err := analyzer.store.VisitorBrowser(filter.TenantID, filter.From, filter.To)
+	stats, err := analyzer.store.VisitorChannel(filter.TenantID, filter.From, filter.To)
 
 	if err != nil {
 		return nil, err
 	}
 
 	if addToday {
-		visitorsToday, err := analyzer.store.CountVisitorsByBrowser(nil, filter.TenantID, today)
+		visitorsToday, err := analyzer.store.CountVisitorsByReferrerSource(nil, filter.TenantID, today)
 
 		if err != nil {
 			return nil, err
 		}
 
-		for _, v := range visitorsToday {
-			found := false
+		index := make(map[sql.NullString]int, len(stats))
 
-			for i, s := range stats {
-				if s.Browser.String == v.Browser.String {
-					stats[i].Visitors += v.Visitors
-					found = true
-					break
-				}
-			}
+		for i := range stats {
+			index[stats[i].Channel] = i
+		}
 
-			if !found {
+		for _, v := range visitorsToday {
+			if i, found := index[v.Channel]; found {
+				stats[i].Visitors += v.Visitors
+				stats[i].PageViews += v.PageViews
+			} else {
+				v.ReferrerName = sql.NullString{}
+				index[v.Channel] = len(stats)
 				stats = append(stats, v)
 			}
 		}
 	}
 
-	sort.Slice(stats, func(i, j int) bool {
-		return stats[i].Visitors > stats[j].Visitors
-	})
+	analyzer.calculateReferrerSourceRelativeVisitors(stats)
+	return stats, nil
+}
 
-	var sum float64
+// OS returns the visitor count per operating system.
+func (analyzer *Analyzer) OS(filter *Filter) ([]OSStats, error) {
+	stats, err := analyzer.Breakdown(filter, DimensionOS)
 
-	for i := range stats {
-		sum += float64(stats[i].Visitors)
+	if err != nil {
+		return nil, err
 	}
 
-	for i := range stats {
-		stats[i].RelativeVisitors = float64(stats[i].Visitors) / sum
+	return osStats(stats), nil
+}
+
+// Browser returns the visitor count per browser.
+func (analyzer *Analyzer) Browser(filter *Filter) ([]BrowserStats, error) {
+	stats, err := analyzer.Breakdown(filter, DimensionBrowser)
+
+	if err != nil {
+		return nil, err
 	}
 
-	return stats, nil
+	return browserStats(stats), nil
 }
 
 // Platform returns the visitor count per browser.
 func (analyzer *Analyzer) Platform(filter *Filter) *VisitorStats {
 	filter = analyzer.getFilter(filter)
+
+	if filter.needsFilterStats() {
+		return analyzer.filteredPlatform(filter.withoutPath())
+	}
+
 	today := today()
 	addToday := today.Equal(filter.To)
 	stats := analyzer.store.VisitorPlatform(filter.TenantID, filter.From, filter.To)
@@ -361,129 +394,70 @@ func (analyzer *Analyzer) Platform(filter *Filter) *VisitorStats {
 		}
 	}
 
-	sum := float64(stats.PlatformDesktop + stats.PlatformMobile + stats.PlatformUnknown)
-
-	if sum != 0 {
-		stats.RelativePlatformDesktop = float64(stats.PlatformDesktop) / sum
-		stats.RelativePlatformMobile = float64(stats.PlatformMobile) / sum
-		stats.RelativePlatformUnknown = float64(stats.PlatformUnknown) / sum
-	}
-
+	analyzer.calculatePlatformRelativeVisitors(stats)
 	return stats
 }
 
 // Screen returns the visitor count per screen size (width and height).
 func (analyzer *Analyzer) Screen(filter *Filter) ([]ScreenStats, error) {
-	filter = analyzer.getFilter(filter)
-	today := today()
-	addToday := today.Equal(filter.To)
-	stats, err := analyzer.store.VisitorScreenSize(filter.TenantID, filter.From, filter.To)
+	stats, err := analyzer.Breakdown(filter, DimensionScreen)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if addToday {
-		visitorsToday, err := analyzer.store.CountVisitorsByScreenSize(nil, filter.TenantID, today)
-
-		if err != nil {
-			return nil, err
-		}
-
-		for _, v := range visitorsToday {
-			found := false
-
-			for i, s := range stats {
-				if s.Width == v.Width && s.Height == v.Height {
-					stats[i].Visitors += v.Visitors
-					found = true
-					break
-				}
-			}
-
-			if !found {
-				stats = append(stats, v)
-			}
-		}
-	}
-
-	sort.Slice(stats, func(i, j int) bool {
-		return stats[i].Visitors > stats[j].Visitors
-	})
-
-	var sum float64
-
-	for i := range stats {
-		sum += float64(stats[i].Visitors)
-	}
-
-	for i := range stats {
-		stats[i].RelativeVisitors = float64(stats[i].Visitors) / sum
-	}
-
-	return stats, nil
+	return screenStats(stats)
 }
 
 // Country returns the visitor count per country.
 func (analyzer *Analyzer) Country(filter *Filter) ([]CountryStats, error) {
-	filter = analyzer.getFilter(filter)
-	today := today()
-	addToday := today.Equal(filter.To)
-	stats, err := analyzer.store.VisitorCountry(filter.TenantID, filter.From, filter.To)
+	stats, err := analyzer.Breakdown(filter, DimensionCountry)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if addToday {
-		visitorsToday, err := analyzer.store.CountVisitorsByCountryCode(nil, filter.TenantID, today)
-
-		if err != nil {
-			return nil, err
-		}
-
-		for _, v := range visitorsToday {
-			found := false
-
-			for i, s := range stats {
-				if s.CountryCode == v.CountryCode {
-					stats[i].Visitors += v.Visitors
-					found = true
-					break
-				}
-			}
-
-			if !found {
-				stats = append(stats, v)
-			}
-		}
-	}
+	return countryStats(stats), nil
+}
 
-	sort.Slice(stats, func(i, j int) bool {
-		return stats[i].Visitors > stats[j].Visitors
+// UTMSource returns the visitor count per utm_source. Visitors without source are not included.
+func (analyzer *Analyzer) UTMSource(filter *Filter) ([]UTMStats, error) {
+	return analyzer.utm(filter, "utm_source", analyzer.store.VisitorUTMSource, func(stats *UTMStats) *sql.NullString {
+		return &stats.UTMSource
 	})
+}
 
-	var sum float64
-
-	for i := range stats {
-		sum += float64(stats[i].Visitors)
-	}
-
-	for i := range stats {
-		stats[i].RelativeVisitors = float64(stats[i].Visitors) / sum
-	}
+// UTMMedium returns the visitor count per utm_medium. Visitors without medium are not included.
+func (analyzer *Analyzer) UTMMedium(filter *Filter) ([]UTMStats, error) {
+	return analyzer.utm(filter, "utm_medium", analyzer.store.VisitorUTMMedium, func(stats *UTMStats) *sql.NullString {
+		return &stats.UTMMedium
+	})
+}
 
-	return stats, nil
+// UTMCampaign returns the visitor count per utm_campaign. Visitors without campaign are not included.
+func (analyzer *Analyzer) UTMCampaign(filter *Filter) ([]UTMStats, error) {
+	return analyzer.utm(filter, "utm_campaign", analyzer.store.VisitorUTMCampaign, func(stats *UTMStats) *sql.NullString {
+		return &stats.UTMCampaign
+	})
 }
 
 // TimeOfDay returns the visitor count per day and hour for given time frame.
+// The hours are summed up per week, month, or year instead, if the Filter.Interval is set.
 func (analyzer *Analyzer) TimeOfDay(filter *Filter) ([]TimeOfDayVisitors, error) {
 	filter = analyzer.getFilter(filter)
 	from := filter.From
 	stats := make([]TimeOfDayVisitors, 0)
 
 	for !from.After(filter.To) {
-		s, err := analyzer.VisitorHours(&Filter{TenantID: filter.TenantID, From: from, To: from})
+		to := filter.intervalEnd(from)
+
+		if to.After(filter.To) {
+			to = filter.To
+		}
+
+		intervalFilter := *filter
+		intervalFilter.From, intervalFilter.To = from, to
+		s, err := analyzer.VisitorHours(&intervalFilter)
 
 		if err != nil {
 			return nil, err
@@ -491,22 +465,39 @@ func (analyzer *Analyzer) TimeOfDay(filter *Filter) ([]TimeOfDayVisitors, error)
 
 		// no need to set timezone for hours and sort stats, as this is done by VisitorHours
 		stats = append(stats, TimeOfDayVisitors{
-			Day:   from,
+			Day:   filter.intervalStart(from),
 			Stats: s,
 		})
-		from = from.Add(time.Hour * 24)
+		from = to.AddDate(0, 0, 1)
 	}
 
 	return stats, nil
 }
 
-// PageVisitors returns the visitor count, session count, and bounce rate per day for the given time frame grouped by path.
+// PageVisitors returns the visitor count, page views, session count, views per visit, bounce rate, average session duration, and average time on page per day
+// for the given time frame grouped by path. The bounces and session duration of a path are those of the sessions started on it.
+// The statistics are grouped by week, month, or year instead, if the Filter.Interval is set.
 func (analyzer *Analyzer) PageVisitors(filter *Filter) ([]PathVisitors, error) {
 	filter = analyzer.getFilter(filter)
+
+	if filter.needsFilterStats() || filter.groupsByInterval() {
+		return analyzer.filteredPageVisitors(filter)
+	}
+
 	paths := analyzer.getPaths(filter)
 	today := today()
 	addToday := today.Equal(filter.To)
 	stats := make([]PathVisitors, 0, len(paths))
+	var sessionStatsToday map[pathKey]Stats
+
+	if addToday {
+		var err error
+		sessionStatsToday, err = analyzer.sessionStatsToday(filter.TenantID)
+
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	for _, path := range paths {
 		visitors, err := analyzer.store.PageVisitors(filter.TenantID, path, filter.From, filter.To)
@@ -522,27 +513,35 @@ func (analyzer *Analyzer) PageVisitors(filter *Filter) ([]PathVisitors, error) {
 				return nil, err
 			}
 
-			bouncesToday := analyzer.store.CountVisitorsByPathAndMaxOneHit(nil, filter.TenantID, today, path)
+			sessionStats := sumSessionStats(sessionStatsToday, path)
 
 			if len(visitorsToday) > 0 {
 				if len(visitors) > 0 {
 					visitors[len(visitors)-1].Visitors += visitorsToday[0].Visitors
+					visitors[len(visitors)-1].PageViews += visitorsToday[0].PageViews
 					visitors[len(visitors)-1].Sessions += visitorsToday[0].Sessions
-					visitors[len(visitors)-1].Bounces += bouncesToday
+					visitors[len(visitors)-1].addSessionStats(&sessionStats)
 				} else {
 					visitors = append(visitors, Stats{
-						Visitors: visitorsToday[0].Visitors,
-						Sessions: visitorsToday[0].Sessions,
-						Bounces:  bouncesToday,
+						Day:                  today,
+						Visitors:             visitorsToday[0].Visitors,
+						PageViews:            visitorsToday[0].PageViews,
+						Sessions:             visitorsToday[0].Sessions,
+						Bounces:              sessionStats.Bounces,
+						SessionsStarted:      sessionStats.SessionsStarted,
+						SessionDuration:      sessionStats.SessionDuration,
+						SessionDurationCount: sessionStats.SessionDurationCount,
+						TimeOnPage:           sessionStats.TimeOnPage,
+						TimeOnPageCount:      sessionStats.TimeOnPageCount,
 					})
 				}
 			}
 		}
 
 		for i := range visitors {
-			if visitors[i].Visitors > 0 {
-				visitors[i].BounceRate = float64(visitors[i].Bounces) / float64(visitors[i].Visitors)
-			}
+			visitors[i].calculateBounceRate()
+			visitors[i].calculateViewsPerVisit()
+			visitors[i].calculateAverageDurations()
 		}
 
 		stats = append(stats, PathVisitors{
@@ -563,21 +562,25 @@ func (analyzer *Analyzer) PageLanguages(filter *Filter) ([]LanguageStats, error)
 		return []LanguageStats{}, nil
 	}
 
-	stats, err := analyzer.store.PageLanguages(filter.TenantID, filter.Path, filter.From, filter.To)
+	var stats []LanguageStats
+	var err error
 
-	if err != nil {
-		return nil, err
+	if filter.needsFilterStats() {
+		dimension := builtinDimensions[DimensionLanguage]
+		var dimensionStats []DimensionStats
+		dimensionStats, err = analyzer.filteredBreakdown(filter, dimension)
+		stats = languageStats(dimensionStats)
+	} else {
+		stats, err = analyzer.store.PageLanguages(filter.TenantID, filter.Path, filter.From, filter.To)
 	}
 
-	var sum float64
-
-	for i := range stats {
-		sum += float64(stats[i].Visitors)
+	if err != nil {
+		return nil, err
 	}
 
-	for i := range stats {
-		stats[i].RelativeVisitors = float64(stats[i].Visitors) / sum
-	}
+	calculateRelativeVisitors(len(stats), func(i int) *Stats {
+		return &stats[i].Stats
+	})
 
 	return stats, nil
 }
@@ -591,21 +594,25 @@ func (analyzer *Analyzer) PageReferrer(filter *Filter) ([]ReferrerStats, error)
 		return []ReferrerStats{}, nil
 	}
 
-	stats, err := analyzer.store.PageReferrer(filter.TenantID, filter.Path, filter.From, filter.To)
+	var stats []ReferrerStats
+	var err error
+
+	if filter.needsFilterStats() {
+		dimension := builtinDimensions[DimensionReferrer]
+		var dimensionStats []DimensionStats
+		dimensionStats, err = analyzer.filteredBreakdown(filter, dimension)
+		stats = referrerStats(dimensionStats)
+	} else {
+		stats, err = analyzer.store.PageReferrer(filter.TenantID, filter.Path, filter.From, filter.To)
+	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	var sum float64
-
-	for i := range stats {
-		sum += float64(stats[i].Visitors)
-	}
-
-	for i := range stats {
-		stats[i].RelativeVisitors = float64(stats[i].Visitors) / sum
-	}
+	calculateRelativeVisitors(len(stats), func(i int) *Stats {
+		return &stats[i].Stats
+	})
 
 	return stats, nil
 }
@@ -619,21 +626,25 @@ func (analyzer *Analyzer) PageOS(filter *Filter) ([]OSStats, error) {
 		return []OSStats{}, nil
 	}
 
-	stats, err := analyzer.store.PageOS(filter.TenantID, filter.Path, filter.From, filter.To)
+	var stats []OSStats
+	var err error
 
-	if err != nil {
-		return nil, err
+	if filter.needsFilterStats() {
+		dimension := builtinDimensions[DimensionOS]
+		var dimensionStats []DimensionStats
+		dimensionStats, err = analyzer.filteredBreakdown(filter, dimension)
+		stats = osStats(dimensionStats)
+	} else {
+		stats, err = analyzer.store.PageOS(filter.TenantID, filter.Path, filter.From, filter.To)
 	}
 
-	var sum float64
-
-	for i := range stats {
-		sum += float64(stats[i].Visitors)
+	if err != nil {
+		return nil, err
 	}
 
-	for i := range stats {
-		stats[i].RelativeVisitors = float64(stats[i].Visitors) / sum
-	}
+	calculateRelativeVisitors(len(stats), func(i int) *Stats {
+		return &stats[i].Stats
+	})
 
 	return stats, nil
 }
@@ -647,21 +658,25 @@ func (analyzer *Analyzer) PageBrowser(filter *Filter) ([]BrowserStats, error) {
 		return []BrowserStats{}, nil
 	}
 
-	stats, err := analyzer.store.PageBrowser(filter.TenantID, filter.Path, filter.From, filter.To)
+	var stats []BrowserStats
+	var err error
 
-	if err != nil {
-		return nil, err
+	if filter.needsFilterStats() {
+		dimension := builtinDimensions[DimensionBrowser]
+		var dimensionStats []DimensionStats
+		dimensionStats, err = analyzer.filteredBreakdown(filter, dimension)
+		stats = browserStats(dimensionStats)
+	} else {
+		stats, err = analyzer.store.PageBrowser(filter.TenantID, filter.Path, filter.From, filter.To)
 	}
 
-	var sum float64
-
-	for i := range stats {
-		sum += float64(stats[i].Visitors)
+	if err != nil {
+		return nil, err
 	}
 
-	for i := range stats {
-		stats[i].RelativeVisitors = float64(stats[i].Visitors) / sum
-	}
+	calculateRelativeVisitors(len(stats), func(i int) *Stats {
+		return &stats[i].Stats
+	})
 
 	return stats, nil
 }
@@ -675,29 +690,399 @@ func (analyzer *Analyzer) PagePlatform(filter *Filter) *VisitorStats {
 		return &VisitorStats{}
 	}
 
+	if filter.needsFilterStats() {
+		return analyzer.filteredPlatform(filter)
+	}
+
 	stats := analyzer.store.PagePlatform(filter.TenantID, filter.Path, filter.From, filter.To)
 
 	if stats == nil {
 		return &VisitorStats{}
 	}
 
-	sum := float64(stats.PlatformDesktop + stats.PlatformMobile + stats.PlatformUnknown)
+	analyzer.calculatePlatformRelativeVisitors(stats)
+	return stats
+}
 
-	if sum != 0 {
-		stats.RelativePlatformDesktop = float64(stats.PlatformDesktop) / sum
-		stats.RelativePlatformMobile = float64(stats.PlatformMobile) / sum
-		stats.RelativePlatformUnknown = float64(stats.PlatformUnknown) / sum
+// EntryPages returns the visitor and entry count for the given time frame grouped by the path sessions started on.
+// The path is optional.
+func (analyzer *Analyzer) EntryPages(filter *Filter) ([]EntryStats, error) {
+	filter = analyzer.getFilter(filter)
+
+	var stats []EntryStats
+	var err error
+
+	if filter.needsFilterStats() {
+		stats, err = analyzer.filteredEntryPages(filter)
+	} else {
+		stats, err = analyzer.entryPages(filter)
 	}
 
-	return stats
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Entries > stats[j].Entries
+	})
+
+	calculateRelativeVisitors(len(stats), func(i int) *Stats {
+		return &stats[i].Stats
+	})
+
+	return stats, nil
+}
+
+// ExitPages returns the visitor and exit count and the exit rate for the given time frame grouped by the path sessions ended on.
+// The exit rate is the share of sessions visiting the path that ended on it. The path is optional.
+func (analyzer *Analyzer) ExitPages(filter *Filter) ([]ExitStats, error) {
+	filter = analyzer.getFilter(filter)
+
+	var stats []ExitStats
+	var err error
+
+	if filter.needsFilterStats() {
+		stats, err = analyzer.filteredExitPages(filter)
+	} else {
+		stats, err = analyzer.exitPages(filter)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// pages visited without ending a session are only stored to calculate the exit rate
+	exits := make([]ExitStats, 0, len(stats))
+
+	for _, s := range stats {
+		if s.Exits > 0 {
+			exits = append(exits, s)
+		}
+	}
+
+	sort.Slice(exits, func(i, j int) bool {
+		return exits[i].Exits > exits[j].Exits
+	})
+	calculateRelativeVisitors(len(exits), func(i int) *Stats {
+		return &exits[i].Stats
+	})
+
+	for i := range exits {
+		if exits[i].Sessions > 0 {
+			exits[i].ExitRate = float64(exits[i].Exits) / float64(exits[i].Sessions)
+		}
+	}
+
+	return exits, nil
+}
+
+// NextPages returns the visitor and transition count for the given time frame grouped by the path visitors navigated to next
+// from the filter path within a session. The path is mandatory.
+func (analyzer *Analyzer) NextPages(filter *Filter) ([]TransitionStats, error) {
+	return analyzer.transitions(filter, analyzer.store.NextPages, analyzer.store.FilterNextPages, func(stats *TransitionStats) (string, string) {
+		return stats.Path, stats.NextPath
+	})
+}
+
+// PreviousPages returns the visitor and transition count for the given time frame grouped by the path visitors navigated from
+// to the filter path within a session. The path is mandatory.
+func (analyzer *Analyzer) PreviousPages(filter *Filter) ([]TransitionStats, error) {
+	return analyzer.transitions(filter, analyzer.store.PreviousPages, analyzer.store.FilterPreviousPages, func(stats *TransitionStats) (string, string) {
+		return stats.NextPath, stats.Path
+	})
+}
+
+// Events returns the visitor and event count and the sum and average of the values per event for the given time frame.
+// The path is optional and limits the events to those triggered on that page.
+func (analyzer *Analyzer) Events(filter *Filter) ([]EventStats, error) {
+	filter = analyzer.getFilter(filter)
+	today := today()
+	addToday := !filter.needsFilterStats() && today.Equal(filter.To)
+	var stats []EventStats
+	var err error
+
+	if filter.needsFilterStats() {
+		var events []Event
+		events, err = analyzer.filteredEvents(filter)
+		stats = countEvents(filter, events)
+	} else {
+		stats, err = analyzer.store.Events(filter.TenantID, filter.From, filter.To, filter.Path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if addToday {
+		eventsToday, err := analyzer.store.CountEventsByName(nil, filter.TenantID, today, filter.Path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		index := make(map[string]int, len(stats))
+
+		for i := range stats {
+			index[stats[i].Name] = i
+		}
+
+		for _, e := range eventsToday {
+			if i, found := index[e.Name]; found {
+				stats[i].Visitors += e.Visitors
+				stats[i].Events += e.Events
+				stats[i].Value += e.Value
+			} else {
+				index[e.Name] = len(stats)
+				stats = append(stats, e)
+			}
+		}
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Visitors > stats[j].Visitors
+	})
+
+	calculateRelativeVisitors(len(stats), func(i int) *Stats {
+		return &stats[i].Stats
+	})
+
+	for i := range stats {
+		if stats[i].Events > 0 {
+			stats[i].AverageValue = stats[i].Value / float64(stats[i].Events)
+		}
+	}
+
+	return stats, nil
+}
+
+// EventMetadata returns the visitor and event count per metadata key and value for given event and time frame.
+// The path is optional and limits the events to those triggered on that page.
+func (analyzer *Analyzer) EventMetadata(filter *Filter, name string) ([]EventMetadataStats, error) {
+	filter = analyzer.getFilter(filter)
+	today := today()
+	addToday := !filter.needsFilterStats() && today.Equal(filter.To)
+	var stats []EventMetadataStats
+	var err error
+
+	if filter.needsFilterStats() {
+		var events []Event
+		events, err = analyzer.filteredEvents(filter)
+		stats = countEventMetadata(filter, events, name)
+	} else {
+		stats, err = analyzer.store.EventMetadata(filter.TenantID, name, filter.From, filter.To, filter.Path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if addToday {
+		metadataToday, err := analyzer.store.CountEventMetadataByName(nil, filter.TenantID, today, name, filter.Path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		type metadata struct {
+			key   string
+			value string
+		}
+		index := make(map[metadata]int, len(stats))
+
+		for i := range stats {
+			index[metadata{stats[i].MetaKey, stats[i].MetaValue}] = i
+		}
+
+		for _, m := range metadataToday {
+			key := metadata{m.MetaKey, m.MetaValue}
+
+			if i, found := index[key]; found {
+				stats[i].Visitors += m.Visitors
+				stats[i].Events += m.Events
+			} else {
+				index[key] = len(stats)
+				stats = append(stats, m)
+			}
+		}
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Visitors > stats[j].Visitors
+	})
+
+	calculateRelativeVisitors(len(stats), func(i int) *Stats {
+		return &stats[i].Stats
+	})
+
+	return stats, nil
+}
+
+// Goals returns the converted visitor count and conversion rate for each goal in given time frame.
+// The conversion rate is relative to the total number of visitors. Goals without conversions are included.
+// The path of the filter is ignored.
+func (analyzer *Analyzer) Goals(filter *Filter) ([]GoalStats, error) {
+	filter = analyzer.getFilter(filter)
+	today := today()
+	addToday := !filter.needsFilterStats() && today.Equal(filter.To)
+	goals, err := analyzer.store.Goals(filter.TenantID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var conversions []GoalStats
+
+	if filter.needsFilterStats() {
+		conversions, err = analyzer.filteredConversions(filter.withoutPath(), goals)
+	} else {
+		conversions, err = analyzer.store.GoalConversions(filter.TenantID, filter.From, filter.To)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]GoalStats, 0, len(goals))
+
+	for i, goal := range goals {
+		s := GoalStats{GoalID: goal.ID, Name: goal.Name}
+		s.TenantID = goal.TenantID
+
+		for _, c := range conversions {
+			if c.GoalID == goal.ID {
+				s.Visitors += c.Visitors
+			}
+		}
+
+		if addToday {
+			conversionsToday, err := analyzer.store.CountGoalConversions(nil, filter.TenantID, today, &goals[i])
+
+			if err != nil {
+				return nil, err
+			}
+
+			for _, c := range conversionsToday {
+				s.Visitors += c.Visitors
+			}
+		}
+
+		stats = append(stats, s)
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Visitors > stats[j].Visitors
+	})
+
+	if err := analyzer.calculateConversionRate(filter, stats); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// GoalReferrer returns the converted visitor count and conversion rate for given goal grouped by referrer.
+// The referrer is taken from the first page visit of the visitor on the day of the conversion.
+func (analyzer *Analyzer) GoalReferrer(filter *Filter, goalID int64) ([]GoalStats, error) {
+	return analyzer.goalBreakdown(filter, goalID, analyzer.store.GoalReferrer, func(stats *GoalStats) sql.NullString {
+		return stats.Referrer
+	})
+}
+
+// GoalCountry returns the converted visitor count and conversion rate for given goal grouped by country code.
+func (analyzer *Analyzer) GoalCountry(filter *Filter, goalID int64) ([]GoalStats, error) {
+	return analyzer.goalBreakdown(filter, goalID, analyzer.store.GoalCountry, func(stats *GoalStats) sql.NullString {
+		return stats.CountryCode
+	})
+}
+
+// GoalBrowser returns the converted visitor count and conversion rate for given goal grouped by browser.
+func (analyzer *Analyzer) GoalBrowser(filter *Filter, goalID int64) ([]GoalStats, error) {
+	return analyzer.goalBreakdown(filter, goalID, analyzer.store.GoalBrowser, func(stats *GoalStats) sql.NullString {
+		return stats.Browser
+	})
+}
+
+// Funnel returns the visitor count for each step of given funnel and time frame.
+// The relative visitors are relative to the first step and the drop off is the share of visitors lost since the previous step.
+// The path of the filter is ignored.
+func (analyzer *Analyzer) Funnel(filter *Filter, funnelID int64) ([]FunnelStats, error) {
+	filter = analyzer.getFilter(filter)
+	funnel, err := analyzer.getFunnel(filter.TenantID, funnelID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if funnel == nil {
+		return []FunnelStats{}, nil
+	}
+
+	stats := make([]FunnelStats, len(funnel.Steps))
+
+	for i, pattern := range funnel.Steps {
+		stats[i].TenantID = funnel.TenantID
+		stats[i].FunnelID = funnel.ID
+		stats[i].Step = i
+		stats[i].Pattern = pattern
+	}
+
+	var steps []FunnelStats
+
+	if filter.needsFilterStats() {
+		steps, err = analyzer.filteredFunnelSteps(filter.withoutPath(), funnel)
+	} else {
+		steps, err = analyzer.store.FunnelSteps(filter.TenantID, funnelID, filter.From, filter.To)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range steps {
+		if s.Step >= 0 && s.Step < len(stats) {
+			stats[s.Step].Visitors += s.Visitors
+		}
+	}
+
+	today := today()
+
+	if !filter.needsFilterStats() && today.Equal(filter.To) {
+		hits, err := analyzer.store.SessionHits(nil, filter.TenantID, today)
+
+		if err != nil {
+			return nil, err
+		}
+
+		stepsToday, err := funnel.countSteps(groupSessions(hits))
+
+		if err != nil {
+			return nil, err
+		}
+
+		for i, visitors := range stepsToday {
+			stats[i].Visitors += visitors
+		}
+	}
+
+	for i := range stats {
+		if stats[0].Visitors > 0 {
+			stats[i].RelativeVisitors = float64(stats[i].Visitors) / float64(stats[0].Visitors)
+		}
+
+		if i > 0 && stats[i-1].Visitors > 0 {
+			stats[i].DropOff = 1 - float64(stats[i].Visitors)/float64(stats[i-1].Visitors)
+		}
+	}
+
+	return stats, nil
 }
 
-// Growth returns the total number of visitors, sessions, and bounces for given time frame and path
+// Growth returns the total number of visitors, page views, sessions, and bounces, the bounce rate, and the average views per visit, session duration, and time on page for given time frame and path
 // and calculates the growth of each metric relative to the previous time frame. The path is optional.
 // It does not include today, as that won't be accurate (the day needs to be over to be comparable).
 func (analyzer *Analyzer) Growth(filter *Filter) (*Growth, error) {
 	filter = analyzer.getFilter(filter)
-	current, err := analyzer.store.VisitorsSum(filter.TenantID, filter.From, filter.To, filter.Path)
+	current, err := analyzer.visitorsSum(filter)
 
 	if err != nil {
 		return nil, err
@@ -706,28 +1091,38 @@ func (analyzer *Analyzer) Growth(filter *Filter) (*Growth, error) {
 	days := filter.To.Sub(filter.From)
 	filter.To = filter.From.Add(-time.Hour * 24)
 	filter.From = filter.To.Add(-days)
-	previous, err := analyzer.store.VisitorsSum(filter.TenantID, filter.From, filter.To, filter.Path)
+	previous, err := analyzer.visitorsSum(filter)
 
 	if err != nil {
 		return nil, err
 	}
 
+	current.calculateBounceRate()
+	previous.calculateBounceRate()
+	current.calculateViewsPerVisit()
+	previous.calculateViewsPerVisit()
+	current.calculateAverageDurations()
+	previous.calculateAverageDurations()
 	return &Growth{
-		Current:        current,
-		Previous:       previous,
-		VisitorsGrowth: analyzer.calculateGrowth(current.Visitors, previous.Visitors),
-		SessionsGrowth: analyzer.calculateGrowth(current.Sessions, previous.Sessions),
-		BouncesGrowth:  analyzer.calculateGrowth(current.Bounces, previous.Bounces),
+		Current:               current,
+		Previous:              previous,
+		VisitorsGrowth:        analyzer.calculateGrowth(current.Visitors, previous.Visitors),
+		PageViewsGrowth:       analyzer.calculateGrowth(current.PageViews, previous.PageViews),
+		SessionsGrowth:        analyzer.calculateGrowth(current.Sessions, previous.Sessions),
+		BouncesGrowth:         analyzer.calculateGrowth(current.Bounces, previous.Bounces),
+		SessionDurationGrowth: analyzer.calculateGrowthFloat(current.AverageSessionDuration, previous.AverageSessionDuration),
+		TimeOnPageGrowth:      analyzer.calculateGrowthFloat(current.AverageTimeOnPage, previous.AverageTimeOnPage),
 	}, nil
 }
 
 // getFilter validates and returns the given filter or a default filter if it is nil.
 func (analyzer *Analyzer) getFilter(filter *Filter) *Filter {
 	if filter == nil {
-		return NewFilter(NullTenant)
+		filter = NewFilter(NullTenant)
 	}
 
 	filter.validate()
+	filter.timezone = analyzer.timezone
 	return filter
 }
 
@@ -747,14 +1142,891 @@ func (analyzer *Analyzer) getPaths(filter *Filter) []string {
 	return paths
 }
 
-func (analyzer *Analyzer) calculateGrowth(current, previous int) float64 {
-	if current == 0 && previous == 0 {
-		return 0
-	} else if previous == 0 {
-		return 1
+// utm returns the visitors grouped by one campaign parameter, including today.
+// The column is the name of the parameter and the key function returns the parameter the statistics are grouped by.
+func (analyzer *Analyzer) utm(filter *Filter, column string, fetch func(sql.NullInt64, time.Time, time.Time) ([]UTMStats, error), key func(*UTMStats) *sql.NullString) ([]UTMStats, error) {
+	filter = analyzer.getFilter(filter)
+
+	if filter.needsFilterStats() {
+		return analyzer.filteredUTM(filter.withoutPath(), column, key)
+	}
+
+	today := today()
+	addToday := today.Equal(filter.To)
+	stats, err := fetch(filter.TenantID, filter.From, filter.To)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if addToday {
+		visitorsToday, err := analyzer.store.CountVisitorsByUTM(nil, filter.TenantID, today)
+
+		if err != nil {
+			return nil, err
+		}
+
+		index := make(map[sql.NullString]int, len(stats))
+
+		for i := range stats {
+			index[*key(&stats[i])] = i
+		}
+
+		for _, v := range visitorsToday {
+			if !key(&v).Valid {
+				continue
+			}
+
+			if i, found := index[*key(&v)]; found {
+				stats[i].Visitors += v.Visitors
+				stats[i].PageViews += v.PageViews
+			} else {
+				s := UTMStats{Stats: Stats{Visitors: v.Visitors, PageViews: v.PageViews}}
+				*key(&s) = *key(&v)
+				index[*key(&v)] = len(stats)
+				stats = append(stats, s)
+			}
+		}
+	}
+
+	analyzer.calculateUTMRelativeVisitors(stats)
+	return stats, nil
+}
+
+// transitions returns the transitions from or to the filter path, including today.
+// The transitions are fetched from the processed statistics, or counted from the hits using filterFetch if the filter contains visitor attributes.
+// The key function returns the path filtered by and the path the statistics are grouped by.
+func (analyzer *Analyzer) transitions(filter *Filter, fetch func(sql.NullInt64, time.Time, time.Time, string) ([]TransitionStats, error), filterFetch func(*Filter) ([]TransitionStats, error), key func(*TransitionStats) (string, string)) ([]TransitionStats, error) {
+	filter = analyzer.getFilter(filter)
+
+	if filter.Path == "" {
+		return []TransitionStats{}, nil
+	}
+
+	var stats []TransitionStats
+	var err error
+
+	if filter.needsFilterStats() {
+		stats, err = analyzer.filteredTransitions(filter, filterFetch)
+	} else {
+		stats, err = fetch(filter.TenantID, filter.From, filter.To, filter.Path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	today := today()
+
+	if !filter.needsFilterStats() && today.Equal(filter.To) {
+		hits, err := analyzer.store.SessionHits(nil, filter.TenantID, today)
+
+		if err != nil {
+			return nil, err
+		}
+
+		stats = addTransitions(stats, countTransitions(groupSessions(hits)), key, func(path, groupPath string) bool {
+			return strings.EqualFold(filter.Path, path)
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Transitions > stats[j].Transitions
+	})
+
+	calculateRelativeVisitors(len(stats), func(i int) *Stats {
+		return &stats[i].Stats
+	})
+
+	return stats, nil
+}
+
+// entryPages returns the entry pages for the time frame and path of the filter, including today.
+func (analyzer *Analyzer) entryPages(filter *Filter) ([]EntryStats, error) {
+	stats, err := analyzer.store.EntryPages(filter.TenantID, filter.From, filter.To, filter.Path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	today := today()
+
+	if today.Equal(filter.To) {
+		hits, err := analyzer.store.SessionHits(nil, filter.TenantID, today)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entriesToday, _ := countEntryExitPages(groupSessions(hits))
+		stats = addEntryPages(stats, entriesToday, func(path string) bool {
+			return filter.Path == "" || strings.EqualFold(filter.Path, path)
+		})
+	}
+
+	return stats, nil
+}
+
+// exitPages returns the exit pages for the time frame and path of the filter, including today.
+func (analyzer *Analyzer) exitPages(filter *Filter) ([]ExitStats, error) {
+	stats, err := analyzer.store.ExitPages(filter.TenantID, filter.From, filter.To, filter.Path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	today := today()
+
+	if today.Equal(filter.To) {
+		hits, err := analyzer.store.SessionHits(nil, filter.TenantID, today)
+
+		if err != nil {
+			return nil, err
+		}
+
+		_, exitsToday := countEntryExitPages(groupSessions(hits))
+		stats = addExitPages(stats, exitsToday, func(path string) bool {
+			return filter.Path == "" || strings.EqualFold(filter.Path, path)
+		})
+	}
+
+	return stats, nil
+}
+
+// filteredEntryPages returns the entry pages matching the path and visitor attributes of the filter.
+func (analyzer *Analyzer) filteredEntryPages(filter *Filter) ([]EntryStats, error) {
+	if err := analyzer.resolveHitFilter(filter); err != nil {
+		return nil, err
+	}
+
+	return analyzer.store.FilterEntryPages(filter)
+}
+
+// filteredExitPages returns the exit pages matching the path and visitor attributes of the filter.
+func (analyzer *Analyzer) filteredExitPages(filter *Filter) ([]ExitStats, error) {
+	if err := analyzer.resolveHitFilter(filter); err != nil {
+		return nil, err
+	}
+
+	return analyzer.store.FilterExitPages(filter)
+}
+
+// addEntryPages adds the entry pages per tenant matching the path to the entry pages per path.
+// The visitors and entries of all tenants are summed up for each path, compared case-insensitively.
+func addEntryPages(stats, entries []EntryStats, matchesPath func(string) bool) []EntryStats {
+	index := make(map[string]int, len(stats))
+
+	for i := range stats {
+		index[strings.ToLower(stats[i].Path)] = i
+	}
+
+	for _, e := range entries {
+		if !matchesPath(e.Path) {
+			continue
+		}
+
+		key := strings.ToLower(e.Path)
+
+		if i, found := index[key]; found {
+			stats[i].Visitors += e.Visitors
+			stats[i].Entries += e.Entries
+		} else {
+			e.TenantID = sql.NullInt64{}
+			index[key] = len(stats)
+			stats = append(stats, e)
+		}
+	}
+
+	return stats
+}
+
+// addExitPages adds the exit pages per tenant matching the path to the exit pages per path.
+// The visitors, sessions, and exits of all tenants are summed up for each path, compared case-insensitively.
+func addExitPages(stats, exits []ExitStats, matchesPath func(string) bool) []ExitStats {
+	index := make(map[string]int, len(stats))
+
+	for i := range stats {
+		index[strings.ToLower(stats[i].Path)] = i
+	}
+
+	for _, e := range exits {
+		if !matchesPath(e.Path) {
+			continue
+		}
+
+		key := strings.ToLower(e.Path)
+
+		if i, found := index[key]; found {
+			stats[i].Visitors += e.Visitors
+			stats[i].Sessions += e.Sessions
+			stats[i].Exits += e.Exits
+		} else {
+			e.TenantID = sql.NullInt64{}
+			index[key] = len(stats)
+			stats = append(stats, e)
+		}
+	}
+
+	return stats
+}
+
+// filteredTransitions returns the transitions from or to the filter path matching the visitor attributes of the filter using given fetch function.
+// The path grouped by must match the path pattern of the filter.
+func (analyzer *Analyzer) filteredTransitions(filter *Filter, fetch func(*Filter) ([]TransitionStats, error)) ([]TransitionStats, error) {
+	if err := analyzer.resolveHitFilter(filter); err != nil {
+		return nil, err
+	}
+
+	return fetch(filter)
+}
+
+// addTransitions adds the transitions per tenant accepted by the match function to the transitions per path grouped by.
+// The key function returns the path filtered by and the path grouped by, which is compared case-insensitively.
+// The visitors and transitions of all tenants are summed up for each path grouped by.
+func addTransitions(stats, transitions []TransitionStats, key func(*TransitionStats) (string, string), match func(string, string) bool) []TransitionStats {
+	index := make(map[string]int, len(stats))
+
+	for i := range stats {
+		_, groupPath := key(&stats[i])
+		index[strings.ToLower(groupPath)] = i
+	}
+
+	for _, t := range transitions {
+		path, groupPath := key(&t)
+
+		if !match(path, groupPath) {
+			continue
+		}
+
+		groupPath = strings.ToLower(groupPath)
+
+		if i, found := index[groupPath]; found {
+			stats[i].Visitors += t.Visitors
+			stats[i].Transitions += t.Transitions
+		} else {
+			t.TenantID = sql.NullInt64{}
+			index[groupPath] = len(stats)
+			stats = append(stats, t)
+		}
+	}
+
+	return stats
+}
+
+// filteredEvents returns the events of the sessions matching the visitor attributes of the filter, including today.
+func (analyzer *Analyzer) filteredEvents(filter *Filter) ([]Event, error) {
+	if err := analyzer.resolveHitFilter(filter); err != nil {
+		return nil, err
+	}
+
+	return analyzer.store.FilterEvents(filter)
+}
+
+// filteredConversions returns the conversions for given goals matching the path pattern and visitor attributes of the filter, including today.
+func (analyzer *Analyzer) filteredConversions(filter *Filter, goals []Goal) ([]GoalStats, error) {
+	if err := analyzer.resolveHitFilter(filter); err != nil {
+		return nil, err
+	}
+
+	conversions := make([]GoalStats, 0)
+
+	for i := range goals {
+		stats, err := analyzer.store.FilterGoalConversions(filter, &goals[i])
+
+		if err != nil {
+			return nil, err
+		}
+
+		conversions = append(conversions, stats...)
+	}
+
+	return conversions, nil
+}
+
+// filteredFunnelSteps returns the number of visitors reaching each step of given funnel matching the path pattern and visitor attributes of the filter.
+// Page views not matching the path pattern are left out of the sessions.
+func (analyzer *Analyzer) filteredFunnelSteps(filter *Filter, funnel *Funnel) ([]FunnelStats, error) {
+	if err := analyzer.resolveHitFilter(filter); err != nil {
+		return nil, err
+	}
+
+	return analyzer.store.FilterFunnelSteps(filter, funnel)
+}
+
+// calculateUTMRelativeVisitors sorts given statistics by visitors and calculates the relative visitor count.
+func (analyzer *Analyzer) calculateUTMRelativeVisitors(stats []UTMStats) {
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Visitors > stats[j].Visitors
+	})
+
+	calculateRelativeVisitors(len(stats), func(i int) *Stats {
+		return &stats[i].Stats
+	})
+}
+
+// calculatePlatformRelativeVisitors calculates the relative visitor count for each platform.
+func (analyzer *Analyzer) calculatePlatformRelativeVisitors(stats *VisitorStats) {
+	sum := float64(stats.PlatformDesktop + stats.PlatformMobile + stats.PlatformUnknown)
+
+	if sum != 0 {
+		stats.RelativePlatformDesktop = float64(stats.PlatformDesktop) / sum
+		stats.RelativePlatformMobile = float64(stats.PlatformMobile) / sum
+		stats.RelativePlatformUnknown = float64(stats.PlatformUnknown) / sum
+	}
+}
+
+// calculateRelativeVisitors sets the relative visitors of the n statistics returned by the stats function
+// to their share of the total visitors. The relative visitors are left at 0 if there are no visitors at all.
+func calculateRelativeVisitors(n int, stats func(int) *Stats) {
+	var sum float64
+
+	for i := 0; i < n; i++ {
+		sum += float64(stats(i).Visitors)
+	}
+
+	if sum == 0 {
+		return
+	}
+
+	for i := 0; i < n; i++ {
+		s := stats(i)
+		s.RelativeVisitors = float64(s.Visitors) / sum
+	}
+}
+
+// calculateReferrerSourceRelativeVisitors sorts given statistics by visitors and calculates the relative visitor count.
+func (analyzer *Analyzer) calculateReferrerSourceRelativeVisitors(stats []ReferrerSourceStats) {
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Visitors > stats[j].Visitors
+	})
+
+	calculateRelativeVisitors(len(stats), func(i int) *Stats {
+		return &stats[i].Stats
+	})
+}
+
+// goalBreakdown returns the conversions for given goal grouped by one dimension, including today.
+// The key function returns the dimension the conversions are grouped by.
+func (analyzer *Analyzer) goalBreakdown(filter *Filter, goalID int64, fetch func(sql.NullInt64, int64, time.Time, time.Time) ([]GoalStats, error), key func(*GoalStats) sql.NullString) ([]GoalStats, error) {
+	filter = analyzer.getFilter(filter)
+	goal, err := analyzer.getGoal(filter.TenantID, goalID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if goal == nil {
+		return []GoalStats{}, nil
+	}
+
+	today := today()
+	var stats, conversions []GoalStats
+
+	if filter.needsFilterStats() {
+		conversions, err = analyzer.filteredConversions(filter.withoutPath(), []Goal{*goal})
+	} else {
+		stats, err = fetch(filter.TenantID, goalID, filter.From, filter.To)
+
+		if err == nil && today.Equal(filter.To) {
+			conversions, err = analyzer.store.CountGoalConversions(nil, filter.TenantID, today, goal)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// conversions from today or filtered by visitor attributes are grouped by all dimensions and must be merged
+	index := make(map[sql.NullString]int, len(stats))
+
+	for i := range stats {
+		index[key(&stats[i])] = i
+	}
+
+	for _, c := range conversions {
+		if i, found := index[key(&c)]; found {
+			stats[i].Visitors += c.Visitors
+		} else {
+			c.Day = time.Time{}
+			index[key(&c)] = len(stats)
+			stats = append(stats, c)
+		}
+	}
+
+	for i := range stats {
+		stats[i].Name = goal.Name
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Visitors > stats[j].Visitors
+	})
+
+	if err := analyzer.calculateConversionRate(filter, stats); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// getGoal returns the goal for given ID or nil if it doesn't exist.
+func (analyzer *Analyzer) getGoal(tenantID sql.NullInt64, goalID int64) (*Goal, error) {
+	goals, err := analyzer.store.Goals(tenantID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range goals {
+		if goals[i].ID == goalID {
+			return &goals[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// sessionStatsToday returns the bounces and durations of today's sessions grouped by tenant and path.
+func (analyzer *Analyzer) sessionStatsToday(tenantID sql.NullInt64) (map[pathKey]Stats, error) {
+	hits, err := analyzer.store.SessionHits(nil, tenantID, today())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return countSessionStats(groupSessions(hits)), nil
+}
+
+// getFunnel returns the funnel for given ID or nil if it doesn't exist.
+func (analyzer *Analyzer) getFunnel(tenantID sql.NullInt64, funnelID int64) (*Funnel, error) {
+	funnels, err := analyzer.store.Funnels(tenantID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range funnels {
+		if funnels[i].ID == funnelID {
+			return &funnels[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// getContentGroup returns the content group for given name, ignoring the case.
+func (analyzer *Analyzer) getContentGroup(tenantID sql.NullInt64, name string) (*ContentGroup, error) {
+	groups, err := analyzer.store.ContentGroups(tenantID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range groups {
+		if strings.EqualFold(groups[i].Name, name) {
+			return &groups[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// resolveHitFilter prepares the filter for statistics counted from the hits.
+// It returns ErrNotArchived if the hits of the time frame are not available and resolves the pages for the filter (see resolvePaths).
+func (analyzer *Analyzer) resolveHitFilter(filter *Filter) error {
+	archived := today()
+
+	if analyzer.archiveRetention > 0 {
+		archived = archived.Add(-analyzer.archiveRetention)
+	}
+
+	if filter.From.Before(archived) {
+		return ErrNotArchived
+	}
+
+	return analyzer.resolvePaths(filter)
+}
+
+// resolvePaths sets the pages matching the path pattern and content group of the filter for its time frame.
+// Pages must match both, if the path pattern and content group are set.
+func (analyzer *Analyzer) resolvePaths(filter *Filter) error {
+	if !filter.hasPathPattern() {
+		return nil
+	}
+
+	var pattern *pathPattern
+	var group []*pathPattern
+	var err error
+
+	if filter.PathPattern != "" {
+		pattern, err = newPathPattern(filter.PathPattern)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if filter.ContentGroup != "" {
+		contentGroup, err := analyzer.getContentGroup(filter.TenantID, filter.ContentGroup)
+
+		if err != nil {
+			return err
+		}
+
+		if contentGroup == nil {
+			return ErrUnknownContentGroup
+		}
+
+		group, err = newPathPatterns(contentGroup.Patterns)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	paths, err := analyzer.store.Paths(filter.TenantID, filter.From, filter.To)
+
+	if err != nil {
+		return err
+	}
+
+	filter.paths = make([]string, 0, len(paths))
+
+	for _, path := range paths {
+		if (pattern == nil || pattern.match(path)) && (group == nil || matchAny(group, path)) {
+			filter.paths = append(filter.paths, path)
+		}
+	}
+
+	return nil
+}
+
+// calculateConversionRate sets the conversion rate relative to the total number of visitors for given time frame.
+// If the filter contains visitor attributes, it's relative to the visitors matching them.
+func (analyzer *Analyzer) calculateConversionRate(filter *Filter, stats []GoalStats) error {
+	var visitors int
+
+	if filter.needsFilterStats() {
+		total, err := analyzer.filterStats(filter.withoutPath())
+
+		if err != nil {
+			return err
+		}
+
+		for i := range total {
+			visitors += total[i].Visitors
+		}
+	} else {
+		total, err := analyzer.store.VisitorsSum(filter.TenantID, filter.From, filter.To, "")
+
+		if err != nil {
+			return err
+		}
+
+		visitors = total.Visitors
+
+		if today().Equal(filter.To) {
+			if visitorsToday := analyzer.store.CountVisitors(nil, filter.TenantID, today()); visitorsToday != nil {
+				visitors += visitorsToday.Visitors
+			}
+		}
+	}
+
+	for i := range stats {
+		if visitors > 0 {
+			stats[i].ConversionRate = float64(stats[i].Visitors) / float64(visitors)
+		}
+	}
+
+	return nil
+}
+
+func (analyzer *Analyzer) calculateGrowth(current, previous int) float64 {
+	return analyzer.calculateGrowthFloat(float64(current), float64(previous))
+}
+
+func (analyzer *Analyzer) calculateGrowthFloat(current, previous float64) float64 {
+	if current == 0 && previous == 0 {
+		return 0
+	} else if previous == 0 {
+		return 1
+	}
+
+	return (current - previous) / previous
+}
+
+// breakdown returns the visitors grouped by given dimension, including today.
+func (analyzer *Analyzer) breakdown(filter *Filter, dimension Dimension) ([]DimensionStats, error) {
+	stats, err := analyzer.store.VisitorDimension(filter.TenantID, filter.From, filter.To, dimension)
+
+	if err != nil {
+		return nil, err
+	}
+
+	today := today()
+
+	if today.Equal(filter.To) {
+		visitorsToday, err := analyzer.store.CountVisitorsByDimension(nil, filter.TenantID, today, dimension)
+
+		if err != nil {
+			return nil, err
+		}
+
+		index := make(map[sql.NullString]int, len(stats))
+
+		for i := range stats {
+			index[stats[i].Value] = i
+		}
+
+		for _, v := range visitorsToday {
+			if i, found := index[v.Value]; found {
+				stats[i].Visitors += v.Visitors
+				stats[i].PageViews += v.PageViews
+			} else {
+				index[v.Value] = len(stats)
+				stats = append(stats, v)
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// filterStats returns the statistics matching the path and visitor attributes of the filter grouped by given columns, including today.
+// The pages matching the path pattern and content group are resolved for the filter.
+func (analyzer *Analyzer) filterStats(filter *Filter, groupBy ...string) ([]FilterStats, error) {
+	if err := analyzer.resolveHitFilter(filter); err != nil {
+		return nil, err
+	}
+
+	return analyzer.store.FilterStats(filter, groupBy)
+}
+
+// filteredVisitors returns the visitor statistics per day matching the visitor attributes of the filter.
+func (analyzer *Analyzer) filteredVisitors(filter *Filter) ([]Stats, error) {
+	stats, err := analyzer.filterStats(filter, "day")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return analyzer.statsPerDay(filter, stats), nil
+}
+
+// filteredVisitorHours returns the visitor statistics per hour of day matching the visitor attributes of the filter.
+func (analyzer *Analyzer) filteredVisitorHours(filter *Filter) ([]VisitorTimeStats, error) {
+	stats, err := analyzer.filterStats(filter, "hour")
+
+	if err != nil {
+		return nil, err
+	}
+
+	hours := make([]VisitorTimeStats, 24)
+
+	for i := range hours {
+		hours[i].Hour = i
+	}
+
+	for _, s := range stats {
+		if s.Hour >= 0 && s.Hour < 24 {
+			hours[s.Hour].Visitors += s.Visitors
+			hours[s.Hour].PageViews += s.PageViews
+			hours[s.Hour].Sessions += s.Sessions
+		}
+	}
+
+	return hours, nil
+}
+
+// filteredBreakdown returns the visitors grouped by given dimension matching the path and visitor attributes of the filter, ordered by visitors.
+func (analyzer *Analyzer) filteredBreakdown(filter *Filter, dimension Dimension) ([]DimensionStats, error) {
+	if err := analyzer.resolveHitFilter(filter); err != nil {
+		return nil, err
+	}
+
+	return analyzer.store.FilterDimension(filter, dimension)
+}
+
+// contentGroups returns the visitors grouped by content group matching the filter, ordered by visitors.
+// Visitors are counted once per group, even if they visited multiple pages of it. Groups without visitors are left out.
+func (analyzer *Analyzer) contentGroups(filter *Filter) ([]DimensionStats, error) {
+	groups, err := analyzer.store.ContentGroups(filter.TenantID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(groups) == 0 {
+		return []DimensionStats{}, nil
+	}
+
+	if err := analyzer.resolveHitFilter(filter); err != nil {
+		return nil, err
+	}
+
+	return analyzer.store.FilterContentGroups(filter, groups)
+}
+
+// filteredReferrerSource returns the visitors grouped by referrer source and/or channel matching the visitor attributes of the filter.
+func (analyzer *Analyzer) filteredReferrerSource(filter *Filter, groupBy ...string) ([]ReferrerSourceStats, error) {
+	stats, err := analyzer.filterStats(filter, groupBy...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ReferrerSourceStats, len(stats))
+
+	for i := range stats {
+		result[i] = ReferrerSourceStats{
+			Stats:        Stats{Visitors: stats[i].Visitors, PageViews: stats[i].PageViews},
+			ReferrerName: stats[i].ReferrerName,
+			Channel:      stats[i].Channel,
+		}
+	}
+
+	analyzer.calculateReferrerSourceRelativeVisitors(result)
+	return result, nil
+}
+
+// filteredPlatform returns the visitors per platform matching the path and visitor attributes of the filter.
+func (analyzer *Analyzer) filteredPlatform(filter *Filter) *VisitorStats {
+	platform := new(VisitorStats)
+	stats, err := analyzer.filterStats(filter, "desktop", "mobile")
+
+	if err != nil {
+		return platform
+	}
+
+	for _, s := range stats {
+		if s.Desktop {
+			platform.PlatformDesktop += s.Visitors
+		} else if s.Mobile {
+			platform.PlatformMobile += s.Visitors
+		} else {
+			platform.PlatformUnknown += s.Visitors
+		}
+	}
+
+	analyzer.calculatePlatformRelativeVisitors(platform)
+	return platform
+}
+
+// filteredUTM returns the visitors grouped by given campaign parameter matching the visitor attributes of the filter.
+// Visitors without the parameter are not included.
+func (analyzer *Analyzer) filteredUTM(filter *Filter, column string, key func(*UTMStats) *sql.NullString) ([]UTMStats, error) {
+	stats, err := analyzer.filterStats(filter, column)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]UTMStats, 0, len(stats))
+
+	for i := range stats {
+		s := UTMStats{
+			Stats:       Stats{Visitors: stats[i].Visitors, PageViews: stats[i].PageViews},
+			UTMSource:   stats[i].UTMSource,
+			UTMMedium:   stats[i].UTMMedium,
+			UTMCampaign: stats[i].UTMCampaign,
+		}
+
+		if key(&s).Valid {
+			result = append(result, s)
+		}
+	}
+
+	analyzer.calculateUTMRelativeVisitors(result)
+	return result, nil
+}
+
+// filteredPageVisitors returns the visitor statistics per path and day matching the path and visitor attributes of the filter.
+func (analyzer *Analyzer) filteredPageVisitors(filter *Filter) ([]PathVisitors, error) {
+	stats, err := analyzer.filterStats(filter, "path", "day")
+
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string][]FilterStats)
+
+	for _, s := range stats {
+		paths[s.Path] = append(paths[s.Path], s)
+	}
+
+	result := make([]PathVisitors, 0, len(paths))
+
+	for path, s := range paths {
+		result = append(result, PathVisitors{
+			Path:  path,
+			Stats: analyzer.statsPerDay(filter, s),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result, nil
+}
+
+// statsPerDay returns given statistics for each day (or interval) of the filter, including days without visitors.
+// The day of an interval is its first day, which can be before the start of the filter.
+func (analyzer *Analyzer) statsPerDay(filter *Filter, stats []FilterStats) []Stats {
+	days := make(map[int64]*FilterStats, len(stats))
+
+	for i := range stats {
+		days[stats[i].Day.Unix()] = &stats[i]
+	}
+
+	result := make([]Stats, 0, filter.Days()+1)
+
+	for day := filter.intervalStart(filter.From); !day.After(filter.To); day = filter.intervalEnd(day).AddDate(0, 0, 1) {
+		var s Stats
+
+		if d, found := days[day.Unix()]; found {
+			s = d.Stats
+		}
+
+		s.Day = day
+		s.Path = ""
+		s.calculateBounceRate()
+		s.calculateViewsPerVisit()
+		s.calculateAverageDurations()
+		result = append(result, s)
+	}
+
+	return result
+}
+
+// visitorsSum returns the sum of the visitor statistics for the time frame and path of the filter, excluding today.
+func (analyzer *Analyzer) visitorsSum(filter *Filter) (*Stats, error) {
+	if !filter.needsFilterStats() {
+		return analyzer.store.VisitorsSum(filter.TenantID, filter.From, filter.To, filter.Path)
+	}
+
+	withoutToday := *filter
+
+	if today().Equal(withoutToday.To) {
+		withoutToday.To = withoutToday.To.Add(-time.Hour * 24)
+	}
+
+	sum := new(Stats)
+
+	if withoutToday.From.After(withoutToday.To) {
+		return sum, nil
+	}
+
+	stats, err := analyzer.filterStats(&withoutToday)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range stats {
+		sum.Visitors += stats[i].Visitors
+		sum.PageViews += stats[i].PageViews
+		sum.Sessions += stats[i].Sessions
+		sum.addSessionStats(&stats[i].Stats)
 	}
 
-	c := float64(current)
-	p := float64(previous)
-	return (c - p) / p
+	return sum, nil
 }
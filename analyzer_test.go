@@ -49,12 +49,14 @@ func TestAnalyzer_Visitors(t *testing.T) {
 			createHit(t, store, tenantID, "fp2", "/path", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
 			stats := &VisitorStats{
 				Stats: Stats{
-					BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
-					Day:        pastDay(2),
-					Path:       "/path",
-					Visitors:   42,
-					Sessions:   67,
-					Bounces:    30,
+					BaseEntity:           BaseEntity{TenantID: NewTenantID(tenantID)},
+					Day:                  pastDay(2),
+					Path:                 "/path",
+					Visitors:             42,
+					Sessions:             67,
+					Bounces:              30,
+					SessionsStarted:      50,
+					SessionDurationCount: 50,
 				},
 			}
 
@@ -78,7 +80,7 @@ func TestAnalyzer_Visitors(t *testing.T) {
 			}
 
 			if !visitors[0].Day.Equal(pastDay(3)) || visitors[0].Visitors != 0 || visitors[0].Sessions != 0 || visitors[0].Bounces != 0 || !inRange(visitors[0].BounceRate, 0) ||
-				!visitors[1].Day.Equal(pastDay(2)) || visitors[1].Visitors != 42 || visitors[1].Sessions != 67 || visitors[1].Bounces != 30 || !inRange(visitors[1].BounceRate, 0.71) ||
+				!visitors[1].Day.Equal(pastDay(2)) || visitors[1].Visitors != 42 || visitors[1].Sessions != 67 || visitors[1].Bounces != 30 || !inRange(visitors[1].BounceRate, 0.6) ||
 				!visitors[2].Day.Equal(pastDay(1)) || visitors[2].Visitors != 0 || visitors[2].Sessions != 0 || visitors[2].Bounces != 0 || !inRange(visitors[2].BounceRate, 0) ||
 				!visitors[3].Day.Equal(today()) || visitors[3].Visitors != 2 || visitors[3].Sessions != 2 || visitors[3].Bounces != 2 || !inRange(visitors[3].BounceRate, 1) {
 				t.Fatalf("Visitors not as expected: %v", visitors)
@@ -87,6 +89,89 @@ func TestAnalyzer_Visitors(t *testing.T) {
 	}
 }
 
+func TestAnalyzer_VisitorsInterval(t *testing.T) {
+	timezone, err := time.LoadLocation("Europe/Berlin")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, store := range testStorageBackends() {
+		cleanupDB(t)
+		// summer time starts on Sunday, March 28th, 2021 in Berlin (UTC+1 before, UTC+2 after)
+		hits := []struct {
+			fingerprint string
+			time        time.Time
+		}{
+			{"fp1", time.Date(2021, 3, 21, 23, 30, 0, 0, time.UTC)}, // Monday, March 22nd, 00:30 in Berlin
+			{"fp1", day(2021, 3, 22, 10)},
+			{"fp2", time.Date(2021, 3, 28, 22, 30, 0, 0, time.UTC)}, // Monday, March 29th, 00:30 in Berlin
+			{"fp2", time.Date(2021, 3, 31, 22, 30, 0, 0, time.UTC)}, // Thursday, April 1st, 00:30 in Berlin
+		}
+
+		for _, hit := range hits {
+			createHit(t, store, 0, hit.fingerprint, "/", "en", "ua", "", hit.time, hit.time, "", "", "", "", "", false, false, 0, 0)
+		}
+
+		analyzer := NewAnalyzer(store, &AnalyzerConfig{
+			Timezone:         timezone,
+			ArchiveRetention: time.Since(day(2021, 1, 1, 0)),
+		})
+		filter := &Filter{From: day(2021, 3, 22, 0), To: day(2021, 4, 4, 0), Interval: IntervalWeek}
+		weeks, err := analyzer.Visitors(filter)
+
+		if err != nil {
+			t.Fatalf("Visitors must be returned, but was: %v", err)
+		}
+
+		// the visitor returning on another day of the same week is counted once
+		if len(weeks) != 2 ||
+			!weeks[0].Day.Equal(day(2021, 3, 22, 0)) || weeks[0].Visitors != 1 || weeks[0].PageViews != 2 || weeks[0].Sessions != 2 || !inRange(weeks[0].BounceRate, 1) ||
+			!weeks[1].Day.Equal(day(2021, 3, 29, 0)) || weeks[1].Visitors != 1 || weeks[1].PageViews != 2 || weeks[1].Sessions != 2 {
+			t.Fatalf("Visitors per week not as expected: %v", weeks)
+		}
+
+		filter.Interval = IntervalMonth
+		pages, err := analyzer.PageVisitors(filter)
+
+		if err != nil {
+			t.Fatalf("Page visitors must be returned, but was: %v", err)
+		}
+
+		if len(pages) != 1 || pages[0].Path != "/" || len(pages[0].Stats) != 2 ||
+			!pages[0].Stats[0].Day.Equal(day(2021, 3, 1, 0)) || pages[0].Stats[0].Visitors != 2 || pages[0].Stats[0].PageViews != 3 ||
+			!pages[0].Stats[1].Day.Equal(day(2021, 4, 1, 0)) || pages[0].Stats[1].Visitors != 1 || pages[0].Stats[1].PageViews != 1 {
+			t.Fatalf("Page visitors per month not as expected: %v", pages)
+		}
+
+		filter.Interval = IntervalYear
+		years, err := analyzer.Visitors(filter)
+
+		if err != nil {
+			t.Fatalf("Visitors must be returned, but was: %v", err)
+		}
+
+		if len(years) != 1 || !years[0].Day.Equal(day(2021, 1, 1, 0)) || years[0].Visitors != 2 || years[0].PageViews != 4 {
+			t.Fatalf("Visitors per year not as expected: %v", years)
+		}
+
+		filter.Interval = IntervalWeek
+		timeOfDay, err := analyzer.TimeOfDay(filter)
+
+		if err != nil {
+			t.Fatalf("Time of day must be returned, but was: %v", err)
+		}
+
+		if len(timeOfDay) != 2 || !timeOfDay[0].Day.Equal(day(2021, 3, 22, 0)) || !timeOfDay[1].Day.Equal(day(2021, 3, 29, 0)) {
+			t.Fatalf("Time of day per week not as expected: %v", timeOfDay)
+		}
+
+		if _, err := NewAnalyzer(store, &AnalyzerConfig{Timezone: timezone}).Visitors(filter); err != ErrNotArchived {
+			t.Fatalf("ErrNotArchived must be returned for intervals of days not archived, but was: %v", err)
+		}
+	}
+}
+
 func TestAnalyzer_VisitorHours(t *testing.T) {
 	tenantIDs := []int64{0, 1}
 
@@ -468,596 +553,1679 @@ func TestAnalyzer_Country(t *testing.T) {
 	}
 }
 
-func TestAnalyzer_TimeOfDay(t *testing.T) {
+func TestAnalyzer_Breakdown(t *testing.T) {
+	dimensions := []Dimension{{Name: "utm_source", Column: "utm_source"}}
 	tenantIDs := []int64{0, 1}
 
 	for _, tenantID := range tenantIDs {
 		for _, store := range testStorageBackends() {
 			cleanupDB(t)
-			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", pastDay(1).Add(time.Hour*9), time.Time{}, "", "", "", "", "", false, false, 0, 0)
-			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", pastDay(2).Add(time.Hour*10), time.Time{}, "", "", "", "", "", false, false, 0, 0)
-			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today().Add(time.Hour*17), time.Time{}, "", "", "", "", "", false, false, 0, 0)
-			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today().Add(time.Hour*18), time.Time{}, "", "", "", "", "", false, false, 0, 0)
-			stats := []VisitorTimeStats{
-				{
-					Stats: Stats{
-						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
-						Day:        pastDay(2),
-						Path:       "/",
-						Visitors:   7,
-						Sessions:   8,
-					},
-					Hour: 9,
-				},
-				{
-					Stats: Stats{
-						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
-						Day:        pastDay(2),
-						Path:       "/",
-						Visitors:   11,
-						Sessions:   12,
-					},
-					Hour: 18,
-				},
-				{
-					Stats: Stats{
-						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
-						Day:        pastDay(1),
-						Path:       "/",
-						Visitors:   6,
-						Sessions:   7,
-					},
-					Hour: 9,
-				},
-				{
-					Stats: Stats{
-						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
-						Day:        pastDay(1),
-						Path:       "/",
-						Visitors:   9,
-						Sessions:   10,
-					},
-					Hour: 18,
-				},
-				{
-					Stats: Stats{
-						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
-						Day:        today(),
-						Path:       "/",
-						Visitors:   10,
-						Sessions:   11,
-					},
-					Hour: 9,
-				},
-				{
-					Stats: Stats{
-						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
-						Day:        today(),
-						Path:       "/",
-						Visitors:   14,
-						Sessions:   15,
-					},
-					Hour: 18,
-				},
-			}
+			createUTMHit(t, store, tenantID, "fp1", "newsletter", "email", "", pastDay(2))
+			createUTMHit(t, store, tenantID, "fp2", "newsletter", "email", "", pastDay(2))
+			createUTMHit(t, store, tenantID, "fp3", "google", "cpc", "", pastDay(2))
+			processor := NewProcessorWithConfig(store, &ProcessorConfig{Dimensions: dimensions})
 
-			for _, s := range stats {
-				if err := store.SaveVisitorTimeStats(nil, &s); err != nil {
-					t.Fatal(err)
-				}
+			if err := processor.ProcessTenant(NewTenantID(tenantID)); err != nil {
+				t.Fatal(err)
 			}
 
-			analyzer := NewAnalyzer(store, nil)
-			days, err := analyzer.TimeOfDay(&Filter{
+			createUTMHit(t, store, tenantID, "fp1", "google", "cpc", "", today())
+			createUTMHit(t, store, tenantID, "fp2", "google", "cpc", "", today())
+			createUTMHit(t, store, tenantID, "fp3", "twitter", "social", "", today())
+			analyzer := NewAnalyzer(store, &AnalyzerConfig{Dimensions: dimensions})
+			filter := &Filter{
 				TenantID: NewTenantID(tenantID),
-				From:     pastDay(2),
+				From:     pastDay(4),
 				To:       today(),
-			})
+			}
 
-			if err != nil {
-				t.Fatalf("Visitors must be returned, but was:  %v", err)
+			if _, err := analyzer.Breakdown(filter, "unknown"); err != ErrUnknownDimension {
+				t.Fatalf("Unknown dimension must return an error, but was: %v", err)
 			}
 
-			if len(days) != 3 {
-				t.Fatalf("Three results must have been returned, but was: %v", len(days))
+			if _, err := NewAnalyzer(store, nil).Breakdown(filter, "utm_source"); err != ErrUnknownDimension {
+				t.Fatalf("Dimension not configured for the Analyzer must return an error, but was: %v", err)
 			}
 
-			if !days[0].Day.Equal(pastDay(2)) ||
-				!days[1].Day.Equal(pastDay(1)) ||
-				!days[2].Day.Equal(today()) {
-				t.Fatalf("Days not as expected: %v", days)
+			visitors, err := analyzer.Breakdown(filter, "utm_source")
+
+			if err != nil {
+				t.Fatalf("Visitors must be returned, but was: %v", err)
 			}
 
-			if days[0].Stats[9].Visitors != 7 || days[0].Stats[10].Visitors != 1 || days[0].Stats[18].Visitors != 11 ||
-				days[0].Stats[9].Sessions != 8 || days[0].Stats[10].Sessions != 1 || days[0].Stats[18].Sessions != 12 {
-				t.Fatalf("First day not as expected: %v", days[0])
+			if len(visitors) != 3 ||
+				visitors[0].Value.String != "google" || visitors[0].Visitors != 3 || visitors[0].PageViews != 3 || !inRange(visitors[0].RelativeVisitors, 0.5) ||
+				visitors[1].Value.String != "newsletter" || visitors[1].Visitors != 2 || !inRange(visitors[1].RelativeVisitors, 0.333) ||
+				visitors[2].Value.String != "twitter" || visitors[2].Visitors != 1 || !inRange(visitors[2].RelativeVisitors, 0.166) {
+				t.Fatalf("Visitors not as expected: %v", visitors)
 			}
 
-			if days[1].Stats[9].Visitors != 7 || days[1].Stats[18].Visitors != 9 ||
-				days[1].Stats[9].Sessions != 8 || days[1].Stats[18].Sessions != 10 {
-				t.Fatalf("Second day not as expected: %v", days[1])
+			visitors, err = analyzer.Breakdown(filter, DimensionLanguage)
+
+			if err != nil {
+				t.Fatalf("Visitors must be returned, but was: %v", err)
 			}
 
-			if days[2].Stats[9].Visitors != 10 || days[2].Stats[17].Visitors != 1 || days[2].Stats[18].Visitors != 15 ||
-				days[2].Stats[9].Sessions != 11 || days[2].Stats[17].Sessions != 1 || days[2].Stats[18].Sessions != 16 {
-				t.Fatalf("Third day not as expected: %v", days[2])
+			if len(visitors) != 1 || visitors[0].Dimension != DimensionLanguage || visitors[0].Visitors != 6 || !inRange(visitors[0].RelativeVisitors, 1) {
+				t.Fatalf("Visitors not as expected: %v", visitors)
 			}
 		}
 	}
 }
 
-func TestAnalyzer_PageVisitors(t *testing.T) {
+func TestAnalyzer_VisitorFilter(t *testing.T) {
 	tenantIDs := []int64{0, 1}
 
 	for _, tenantID := range tenantIDs {
 		for _, store := range testStorageBackends() {
 			cleanupDB(t)
-			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
-			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
-			stats := &VisitorStats{
-				Stats: Stats{
-					BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
-					Day:        pastDay(2),
-					Path:       "/path",
-					Visitors:   42,
-					Sessions:   67,
-					Bounces:    30,
-				},
-			}
+			createHit(t, store, tenantID, "fp1", "/", "de", "ua1", "", pastDay(2), pastDay(2), OSWindows, "10", BrowserFirefox, "80.0", "de", true, false, 1920, 1080)
+			createHit(t, store, tenantID, "fp1", "/pricing", "de", "ua1", "", pastDay(2).Add(time.Second*30), pastDay(2), OSWindows, "10", BrowserFirefox, "80.0", "de", true, false, 1920, 1080)
+			createHit(t, store, tenantID, "fp2", "/", "de", "ua2", "", pastDay(2), pastDay(2), OSWindows, "10", BrowserChrome, "84.0", "de", true, false, 1920, 1080)
+			createHit(t, store, tenantID, "fp3", "/", "en", "ua3", "", pastDay(2), pastDay(2), OSMac, "10", BrowserFirefox, "80.0", "gb", true, false, 1920, 1080)
+			processor := NewProcessorWithConfig(store, &ProcessorConfig{ArchiveRetention: time.Hour * 24 * 30})
 
-			if err := store.SaveVisitorStats(nil, stats); err != nil {
+			if err := processor.ProcessTenant(NewTenantID(tenantID)); err != nil {
 				t.Fatal(err)
 			}
 
-			analyzer := NewAnalyzer(store, nil)
-			visitors, err := analyzer.PageVisitors(&Filter{
+			createHit(t, store, tenantID, "fp4", "/", "de", "ua4", "", today(), today(), OSAndroid, "10", BrowserFirefox, "80.0", "de", false, true, 400, 800)
+			createHit(t, store, tenantID, "fp5", "/", "en", "ua5", "", today(), today(), OSAndroid, "10", BrowserChrome, "84.0", "gb", false, true, 400, 800)
+			analyzer := NewAnalyzer(store, &AnalyzerConfig{ArchiveRetention: time.Hour * 24 * 30})
+			filter := &Filter{
 				TenantID: NewTenantID(tenantID),
-				From:     pastDay(3),
+				From:     pastDay(2),
 				To:       today(),
-			})
+				Country:  "de",
+				Browser:  BrowserFirefox,
+			}
+			visitors, err := analyzer.Visitors(filter)
 
 			if err != nil {
-				t.Fatalf("Visitors must be returned, but was:  %v", err)
+				t.Fatalf("Visitors must be returned, but was: %v", err)
 			}
 
-			if len(visitors) != 2 {
-				t.Fatalf("Two visitors must have been returned, but was: %v", len(visitors))
+			if len(visitors) != 3 ||
+				visitors[0].Visitors != 1 || visitors[0].PageViews != 2 || visitors[0].Sessions != 1 || visitors[0].Bounces != 0 || !inRange(visitors[0].AverageSessionDuration, 30) ||
+				visitors[1].Visitors != 0 ||
+				visitors[2].Visitors != 1 || visitors[2].Bounces != 1 || !inRange(visitors[2].BounceRate, 1) {
+				t.Fatalf("Visitors not as expected: %v", visitors)
 			}
 
-			if len(visitors[0].Stats) != 4 || visitors[0].Path != "/" ||
-				!visitors[0].Stats[0].Day.Equal(pastDay(3)) || visitors[0].Stats[0].Visitors != 0 || visitors[0].Stats[0].Sessions != 0 || visitors[0].Stats[0].Bounces != 0 || !inRange(visitors[0].Stats[0].BounceRate, 0) ||
-				!visitors[0].Stats[1].Day.Equal(pastDay(2)) || visitors[0].Stats[1].Visitors != 0 || visitors[0].Stats[1].Sessions != 0 || visitors[0].Stats[1].Bounces != 0 || !inRange(visitors[0].Stats[1].BounceRate, 0) ||
-				!visitors[0].Stats[2].Day.Equal(pastDay(1)) || visitors[0].Stats[2].Visitors != 0 || visitors[0].Stats[2].Sessions != 0 || visitors[0].Stats[2].Bounces != 0 || !inRange(visitors[0].Stats[2].BounceRate, 0) ||
-				!visitors[0].Stats[3].Day.Equal(today()) || visitors[0].Stats[3].Visitors != 1 || visitors[0].Stats[3].Sessions != 1 || visitors[0].Stats[3].Bounces != 0 || !inRange(visitors[0].Stats[3].BounceRate, 0) {
-				t.Fatalf("First path not as expected: %v", visitors)
+			pages, err := analyzer.PageVisitors(filter)
+
+			if err != nil {
+				t.Fatalf("Page visitors must be returned, but was: %v", err)
 			}
 
-			if len(visitors[1].Stats) != 4 || visitors[1].Path != "/path" ||
-				!visitors[1].Stats[0].Day.Equal(pastDay(3)) || visitors[1].Stats[0].Visitors != 0 || visitors[1].Stats[0].Sessions != 0 || visitors[1].Stats[0].Bounces != 0 || !inRange(visitors[1].Stats[0].BounceRate, 0) ||
-				!visitors[1].Stats[1].Day.Equal(pastDay(2)) || visitors[1].Stats[1].Visitors != 42 || visitors[1].Stats[1].Sessions != 67 || visitors[1].Stats[1].Bounces != 30 || !inRange(visitors[1].Stats[1].BounceRate, 0.71) ||
-				!visitors[1].Stats[2].Day.Equal(pastDay(1)) || visitors[1].Stats[2].Visitors != 0 || visitors[1].Stats[2].Sessions != 0 || visitors[1].Stats[2].Bounces != 0 || !inRange(visitors[1].Stats[2].BounceRate, 0) ||
-				!visitors[1].Stats[3].Day.Equal(today()) || visitors[1].Stats[3].Visitors != 1 || visitors[1].Stats[3].Sessions != 1 || visitors[1].Stats[3].Bounces != 0 || !inRange(visitors[1].Stats[3].BounceRate, 0) {
-				t.Fatalf("Second path not as expected: %v", visitors)
+			if len(pages) != 2 || pages[0].Path != "/" || len(pages[0].Stats) != 3 || pages[0].Stats[0].Visitors != 1 || pages[0].Stats[2].Visitors != 1 ||
+				pages[1].Path != "/pricing" || pages[1].Stats[0].Visitors != 1 || pages[1].Stats[2].Visitors != 0 {
+				t.Fatalf("Page visitors not as expected: %v", pages)
 			}
-		}
-	}
-}
 
-func TestAnalyzer_PageLanguages(t *testing.T) {
-	tenantIDs := []int64{0, 1}
+			filter.Browser = ""
+			countries, err := analyzer.Country(filter)
 
-	for _, tenantID := range tenantIDs {
-		for _, store := range testStorageBackends() {
-			cleanupDB(t)
-			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
-			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
-			createHit(t, store, tenantID, "fp1", "/path", "de", "ua1", "", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
-			stats := &LanguageStats{
-				Stats: Stats{
-					BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
-					Day:        pastDay(2),
-					Path:       "/path",
-					Visitors:   42,
-				},
-				Language: sql.NullString{String: "de", Valid: true},
+			if err != nil {
+				t.Fatalf("Countries must be returned, but was: %v", err)
 			}
 
-			if err := store.SaveLanguageStats(nil, stats); err != nil {
-				t.Fatal(err)
+			if len(countries) != 1 || countries[0].CountryCode.String != "de" || countries[0].Visitors != 3 || !inRange(countries[0].RelativeVisitors, 1) {
+				t.Fatalf("Countries not as expected: %v", countries)
 			}
 
-			analyzer := NewAnalyzer(store, nil)
-			visitors, err := analyzer.PageLanguages(&Filter{
-				TenantID: NewTenantID(tenantID),
-				Path:     "/path",
-				From:     pastDay(3),
-				To:       today(),
-			})
+			browser, err := analyzer.Browser(filter)
 
 			if err != nil {
-				t.Fatalf("Visitors must be returned, but was:  %v", err)
+				t.Fatalf("Browsers must be returned, but was: %v", err)
 			}
 
-			if len(visitors) != 2 {
-				t.Fatalf("Two visitors must have been returned, but was: %v", len(visitors))
+			if len(browser) != 2 || browser[0].Browser.String != BrowserFirefox || browser[0].Visitors != 2 || !inRange(browser[0].RelativeVisitors, 0.66) ||
+				browser[1].Browser.String != BrowserChrome || browser[1].Visitors != 1 {
+				t.Fatalf("Browsers not as expected: %v", browser)
 			}
 
-			if visitors[0].Language.String != "de" || visitors[0].Visitors != 43 || !inRange(visitors[0].RelativeVisitors, 0.977) ||
-				visitors[1].Language.String != "en" || visitors[1].Visitors != 1 || !inRange(visitors[1].RelativeVisitors, 0.022) {
-				t.Fatalf("Visitors not as expected: %v", visitors)
-			}
-		}
-	}
-}
+			screens, err := analyzer.Screen(filter)
 
-func TestAnalyzer_PageReferrer(t *testing.T) {
-	tenantIDs := []int64{0, 1}
+			if err != nil {
+				t.Fatalf("Screens must be returned, but was: %v", err)
+			}
 
-	for _, tenantID := range tenantIDs {
-		for _, store := range testStorageBackends() {
-			cleanupDB(t)
-			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "ref1", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
-			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "ref1", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
-			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "ref2", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
-			stats := &ReferrerStats{
-				Stats: Stats{
-					BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
-					Day:        pastDay(2),
-					Path:       "/path",
-					Visitors:   42,
-				},
-				Referrer: sql.NullString{String: "ref2", Valid: true},
+			if len(screens) != 2 || screens[0].Width != 1920 || screens[0].Height != 1080 || screens[0].Visitors != 2 ||
+				screens[1].Width != 400 || screens[1].Height != 800 || screens[1].Visitors != 1 {
+				t.Fatalf("Screens not as expected: %v", screens)
 			}
 
-			if err := store.SaveReferrerStats(nil, stats); err != nil {
-				t.Fatal(err)
+			filter.Country = ""
+			filter.Platform = PlatformMobile
+			platform := analyzer.Platform(filter)
+
+			if platform.PlatformDesktop != 0 || platform.PlatformMobile != 2 || !inRange(platform.RelativePlatformMobile, 1) {
+				t.Fatalf("Platforms not as expected: %v", platform)
 			}
 
-			analyzer := NewAnalyzer(store, nil)
-			visitors, err := analyzer.PageReferrer(&Filter{
-				TenantID: NewTenantID(tenantID),
-				Path:     "/path",
-				From:     pastDay(3),
-				To:       today(),
-			})
+			active, total, err := analyzer.ActiveVisitors(filter, time.Now().UTC().Sub(today())+time.Minute)
 
 			if err != nil {
-				t.Fatalf("Visitors must be returned, but was:  %v", err)
+				t.Fatalf("Active visitors must be returned, but was: %v", err)
 			}
 
-			if len(visitors) != 2 {
-				t.Fatalf("Two visitors must have been returned, but was: %v", len(visitors))
+			if total != 2 || len(active) != 1 || active[0].Visitors != 2 {
+				t.Fatalf("Active visitors not as expected: %v %v", total, active)
 			}
 
-			if visitors[0].Referrer.String != "ref2" || visitors[0].Visitors != 43 || !inRange(visitors[0].RelativeVisitors, 0.977) ||
-				visitors[1].Referrer.String != "ref1" || visitors[1].Visitors != 1 || !inRange(visitors[1].RelativeVisitors, 0.022) {
-				t.Fatalf("Visitors not as expected: %v", visitors)
+			entries, err := analyzer.EntryPages(filter)
+
+			if err != nil {
+				t.Fatalf("Entry pages must be returned, but was: %v", err)
+			}
+
+			if len(entries) != 1 || entries[0].Path != "/" || entries[0].Visitors != 2 || entries[0].Entries != 2 {
+				t.Fatalf("Entry pages not as expected: %v", entries)
+			}
+
+			filter.Platform = ""
+			filter.Country = "de"
+			filter.Browser = BrowserFirefox
+			exits, err := analyzer.ExitPages(filter)
+
+			if err != nil {
+				t.Fatalf("Exit pages must be returned, but was: %v", err)
+			}
+
+			if len(exits) != 2 {
+				t.Fatalf("Exit pages not as expected: %v", exits)
+			}
+
+			// fp1 left on /pricing and fp4 on /, so both have one exit
+			for _, exit := range exits {
+				if exit.Exits != 1 ||
+					exit.Path == "/" && !inRange(exit.ExitRate, 0.5) ||
+					exit.Path == "/pricing" && !inRange(exit.ExitRate, 1) {
+					t.Fatalf("Exit pages not as expected: %v", exits)
+				}
+			}
+		}
+	}
+}
+
+func TestAnalyzer_PathPattern(t *testing.T) {
+	tenantIDs := []int64{0, 1}
+
+	for _, tenantID := range tenantIDs {
+		for _, store := range testStorageBackends() {
+			cleanupDB(t)
+			createHit(t, store, tenantID, "fp1", "/docs/install", "en", "ua1", "", pastDay(2), pastDay(2), OSWindows, "10", BrowserFirefox, "80.0", "de", true, false, 1920, 1080)
+			createHit(t, store, tenantID, "fp1", "/docs/api/hits", "en", "ua1", "", pastDay(2).Add(time.Second*30), pastDay(2), OSWindows, "10", BrowserFirefox, "80.0", "de", true, false, 1920, 1080)
+			createHit(t, store, tenantID, "fp2", "/blog/release", "en", "ua2", "", pastDay(2), pastDay(2), OSWindows, "10", BrowserChrome, "84.0", "gb", true, false, 1920, 1080)
+			processor := NewProcessorWithConfig(store, &ProcessorConfig{ArchiveRetention: time.Hour * 24 * 30})
+
+			if err := processor.ProcessTenant(NewTenantID(tenantID)); err != nil {
+				t.Fatal(err)
+			}
+
+			createHit(t, store, tenantID, "fp3", "/docs/install", "de", "ua3", "", today(), today(), OSAndroid, "10", BrowserFirefox, "80.0", "de", false, true, 400, 800)
+			createHit(t, store, tenantID, "fp4", "/", "en", "ua4", "", today(), today(), OSAndroid, "10", BrowserChrome, "84.0", "gb", false, true, 400, 800)
+
+			if err := store.SaveContentGroup(NewContentGroup(NewTenantID(tenantID), "Docs", "/docs/**")); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := store.SaveContentGroup(NewContentGroup(NewTenantID(tenantID), "Blog", "/blog/*")); err != nil {
+				t.Fatal(err)
+			}
+
+			analyzer := NewAnalyzer(store, &AnalyzerConfig{ArchiveRetention: time.Hour * 24 * 30})
+			filter := &Filter{
+				TenantID:    NewTenantID(tenantID),
+				From:        pastDay(2),
+				To:          today(),
+				PathPattern: "/docs/*",
+			}
+			pages, err := analyzer.PageVisitors(filter)
+
+			if err != nil {
+				t.Fatalf("Page visitors must be returned, but was: %v", err)
+			}
+
+			if len(pages) != 1 || pages[0].Path != "/docs/install" || pages[0].Stats[0].Visitors != 1 || pages[0].Stats[2].Visitors != 1 {
+				t.Fatalf("Page visitors not as expected: %v", pages)
+			}
+
+			filter.PathPattern = "^/(docs|blog)/"
+			countries, err := analyzer.Country(filter)
+
+			if err != nil {
+				t.Fatalf("Countries must be returned, but was: %v", err)
+			}
+
+			if len(countries) != 2 || countries[0].CountryCode.String != "de" || countries[0].Visitors != 2 || countries[1].CountryCode.String != "gb" || countries[1].Visitors != 1 {
+				t.Fatalf("Countries not as expected: %v", countries)
+			}
+
+			filter.PathPattern = ""
+			filter.ContentGroup = "docs"
+			visitors, err := analyzer.Visitors(filter)
+
+			if err != nil {
+				t.Fatalf("Visitors must be returned, but was: %v", err)
+			}
+
+			if len(visitors) != 3 || visitors[0].Visitors != 1 || visitors[0].PageViews != 2 || visitors[2].Visitors != 1 {
+				t.Fatalf("Visitors not as expected: %v", visitors)
+			}
+
+			filter.ContentGroup = ""
+			groups, err := analyzer.Breakdown(filter, DimensionContentGroup)
+
+			if err != nil {
+				t.Fatalf("Content groups must be returned, but was: %v", err)
+			}
+
+			if len(groups) != 2 || groups[0].Value.String != "Docs" || groups[0].Visitors != 2 || !inRange(groups[0].RelativeVisitors, 0.66) ||
+				groups[1].Value.String != "Blog" || groups[1].Visitors != 1 {
+				t.Fatalf("Content groups not as expected: %v", groups)
+			}
+
+			filter.ContentGroup = "unknown"
+
+			if _, err := analyzer.Visitors(filter); err != ErrUnknownContentGroup {
+				t.Fatalf("Unknown content group must be rejected, but was: %v", err)
+			}
+
+			filter.ContentGroup = ""
+			filter.PathPattern = "^/docs("
+
+			if _, err := analyzer.Visitors(filter); err == nil {
+				t.Fatal("Invalid path pattern must be rejected")
+			}
+		}
+	}
+}
+
+func TestAnalyzer_ReferrerSource(t *testing.T) {
+	tenantIDs := []int64{0, 1}
+
+	for _, tenantID := range tenantIDs {
+		for _, store := range testStorageBackends() {
+			cleanupDB(t)
+			createReferrerSourceHit(t, store, tenantID, "fp1", "Google", ChannelSearch, today())
+			createReferrerSourceHit(t, store, tenantID, "fp2", "Twitter", ChannelSocial, today())
+			createReferrerSourceHit(t, store, tenantID, "fp3", "", ChannelDirect, today())
+			stats := []ReferrerSourceStats{
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(2),
+						Visitors:   4,
+					},
+					ReferrerName: sql.NullString{String: "Google", Valid: true},
+					Channel:      sql.NullString{String: ChannelSearch, Valid: true},
+				},
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(2),
+						Visitors:   2,
+					},
+					ReferrerName: sql.NullString{String: "Bing", Valid: true},
+					Channel:      sql.NullString{String: ChannelSearch, Valid: true},
+				},
+			}
+
+			for _, s := range stats {
+				if err := store.SaveReferrerSourceStats(nil, &s); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			analyzer := NewAnalyzer(store, nil)
+			filter := &Filter{
+				TenantID: NewTenantID(tenantID),
+				From:     pastDay(4),
+				To:       today(),
+			}
+			sources, err := analyzer.ReferrerSource(filter)
+
+			if err != nil {
+				t.Fatalf("Referrer sources must be returned, but was: %v", err)
+			}
+
+			if len(sources) != 4 ||
+				sources[0].ReferrerName.String != "Google" || sources[0].Visitors != 5 || !inRange(sources[0].RelativeVisitors, 0.555) ||
+				sources[1].ReferrerName.String != "Bing" || sources[1].Visitors != 2 {
+				t.Fatalf("Referrer sources not as expected: %v", sources)
+			}
+
+			channels, err := analyzer.Channel(filter)
+
+			if err != nil {
+				t.Fatalf("Channels must be returned, but was: %v", err)
+			}
+
+			if len(channels) != 3 ||
+				channels[0].Channel.String != ChannelSearch || channels[0].Visitors != 7 || channels[0].ReferrerName.Valid ||
+				channels[1].Visitors != 1 || channels[2].Visitors != 1 {
+				t.Fatalf("Channels not as expected: %v", channels)
+			}
+		}
+	}
+}
+
+func TestAnalyzer_UTM(t *testing.T) {
+	tenantIDs := []int64{0, 1}
+
+	for _, tenantID := range tenantIDs {
+		for _, store := range testStorageBackends() {
+			cleanupDB(t)
+			createUTMHit(t, store, tenantID, "fp1", "google", "cpc", "launch", today())
+			createUTMHit(t, store, tenantID, "fp2", "newsletter", "email", "launch", today())
+			stats := []UTMStats{
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(2),
+						Visitors:   3,
+					},
+					UTMSource:   sql.NullString{String: "google", Valid: true},
+					UTMMedium:   sql.NullString{String: "cpc", Valid: true},
+					UTMCampaign: sql.NullString{String: "launch", Valid: true},
+				},
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(2),
+						Visitors:   2,
+					},
+					UTMSource: sql.NullString{String: "twitter", Valid: true},
+				},
+			}
+
+			for _, s := range stats {
+				if err := store.SaveUTMStats(nil, &s); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			analyzer := NewAnalyzer(store, nil)
+			filter := &Filter{
+				TenantID: NewTenantID(tenantID),
+				From:     pastDay(4),
+				To:       today(),
+			}
+			source, err := analyzer.UTMSource(filter)
+
+			if err != nil {
+				t.Fatalf("Sources must be returned, but was: %v", err)
+			}
+
+			if len(source) != 3 ||
+				source[0].UTMSource.String != "google" || source[0].Visitors != 4 || !inRange(source[0].RelativeVisitors, 0.571) ||
+				source[1].UTMSource.String != "twitter" || source[1].Visitors != 2 ||
+				source[2].UTMSource.String != "newsletter" || source[2].Visitors != 1 || source[2].UTMMedium.Valid {
+				t.Fatalf("Sources not as expected: %v", source)
+			}
+
+			medium, err := analyzer.UTMMedium(filter)
+
+			if err != nil {
+				t.Fatalf("Mediums must be returned, but was: %v", err)
+			}
+
+			if len(medium) != 2 ||
+				medium[0].UTMMedium.String != "cpc" || medium[0].Visitors != 4 ||
+				medium[1].UTMMedium.String != "email" || medium[1].Visitors != 1 {
+				t.Fatalf("Mediums not as expected: %v", medium)
+			}
+
+			campaign, err := analyzer.UTMCampaign(filter)
+
+			if err != nil {
+				t.Fatalf("Campaigns must be returned, but was: %v", err)
+			}
+
+			if len(campaign) != 1 || campaign[0].UTMCampaign.String != "launch" || campaign[0].Visitors != 5 || campaign[0].RelativeVisitors != 1 {
+				t.Fatalf("Campaigns not as expected: %v", campaign)
+			}
+		}
+	}
+}
+
+func TestAnalyzer_TimeOfDay(t *testing.T) {
+	tenantIDs := []int64{0, 1}
+
+	for _, tenantID := range tenantIDs {
+		for _, store := range testStorageBackends() {
+			cleanupDB(t)
+			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", pastDay(1).Add(time.Hour*9), time.Time{}, "", "", "", "", "", false, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", pastDay(2).Add(time.Hour*10), time.Time{}, "", "", "", "", "", false, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today().Add(time.Hour*17), time.Time{}, "", "", "", "", "", false, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today().Add(time.Hour*18), time.Time{}, "", "", "", "", "", false, false, 0, 0)
+			stats := []VisitorTimeStats{
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(2),
+						Path:       "/",
+						Visitors:   7,
+						Sessions:   8,
+					},
+					Hour: 9,
+				},
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(2),
+						Path:       "/",
+						Visitors:   11,
+						Sessions:   12,
+					},
+					Hour: 18,
+				},
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(1),
+						Path:       "/",
+						Visitors:   6,
+						Sessions:   7,
+					},
+					Hour: 9,
+				},
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(1),
+						Path:       "/",
+						Visitors:   9,
+						Sessions:   10,
+					},
+					Hour: 18,
+				},
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        today(),
+						Path:       "/",
+						Visitors:   10,
+						Sessions:   11,
+					},
+					Hour: 9,
+				},
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        today(),
+						Path:       "/",
+						Visitors:   14,
+						Sessions:   15,
+					},
+					Hour: 18,
+				},
+			}
+
+			for _, s := range stats {
+				if err := store.SaveVisitorTimeStats(nil, &s); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			analyzer := NewAnalyzer(store, nil)
+			days, err := analyzer.TimeOfDay(&Filter{
+				TenantID: NewTenantID(tenantID),
+				From:     pastDay(2),
+				To:       today(),
+			})
+
+			if err != nil {
+				t.Fatalf("Visitors must be returned, but was:  %v", err)
+			}
+
+			if len(days) != 3 {
+				t.Fatalf("Three results must have been returned, but was: %v", len(days))
+			}
+
+			if !days[0].Day.Equal(pastDay(2)) ||
+				!days[1].Day.Equal(pastDay(1)) ||
+				!days[2].Day.Equal(today()) {
+				t.Fatalf("Days not as expected: %v", days)
+			}
+
+			if days[0].Stats[9].Visitors != 7 || days[0].Stats[10].Visitors != 1 || days[0].Stats[18].Visitors != 11 ||
+				days[0].Stats[9].Sessions != 8 || days[0].Stats[10].Sessions != 1 || days[0].Stats[18].Sessions != 12 {
+				t.Fatalf("First day not as expected: %v", days[0])
+			}
+
+			if days[1].Stats[9].Visitors != 7 || days[1].Stats[18].Visitors != 9 ||
+				days[1].Stats[9].Sessions != 8 || days[1].Stats[18].Sessions != 10 {
+				t.Fatalf("Second day not as expected: %v", days[1])
+			}
+
+			if days[2].Stats[9].Visitors != 10 || days[2].Stats[17].Visitors != 1 || days[2].Stats[18].Visitors != 15 ||
+				days[2].Stats[9].Sessions != 11 || days[2].Stats[17].Sessions != 1 || days[2].Stats[18].Sessions != 16 {
+				t.Fatalf("Third day not as expected: %v", days[2])
+			}
+		}
+	}
+}
+
+func TestAnalyzer_PageVisitors(t *testing.T) {
+	tenantIDs := []int64{0, 1}
+
+	for _, tenantID := range tenantIDs {
+		for _, store := range testStorageBackends() {
+			cleanupDB(t)
+			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
+			stats := &VisitorStats{
+				Stats: Stats{
+					BaseEntity:           BaseEntity{TenantID: NewTenantID(tenantID)},
+					Day:                  pastDay(2),
+					Path:                 "/path",
+					Visitors:             42,
+					Sessions:             67,
+					Bounces:              30,
+					SessionsStarted:      50,
+					SessionDurationCount: 50,
+				},
+			}
+
+			if err := store.SaveVisitorStats(nil, stats); err != nil {
+				t.Fatal(err)
+			}
+
+			analyzer := NewAnalyzer(store, nil)
+			visitors, err := analyzer.PageVisitors(&Filter{
+				TenantID: NewTenantID(tenantID),
+				From:     pastDay(3),
+				To:       today(),
+			})
+
+			if err != nil {
+				t.Fatalf("Visitors must be returned, but was:  %v", err)
+			}
+
+			if len(visitors) != 2 {
+				t.Fatalf("Two visitors must have been returned, but was: %v", len(visitors))
+			}
+
+			if len(visitors[0].Stats) != 4 || visitors[0].Path != "/" ||
+				!visitors[0].Stats[0].Day.Equal(pastDay(3)) || visitors[0].Stats[0].Visitors != 0 || visitors[0].Stats[0].Sessions != 0 || visitors[0].Stats[0].Bounces != 0 || !inRange(visitors[0].Stats[0].BounceRate, 0) ||
+				!visitors[0].Stats[1].Day.Equal(pastDay(2)) || visitors[0].Stats[1].Visitors != 0 || visitors[0].Stats[1].Sessions != 0 || visitors[0].Stats[1].Bounces != 0 || !inRange(visitors[0].Stats[1].BounceRate, 0) ||
+				!visitors[0].Stats[2].Day.Equal(pastDay(1)) || visitors[0].Stats[2].Visitors != 0 || visitors[0].Stats[2].Sessions != 0 || visitors[0].Stats[2].Bounces != 0 || !inRange(visitors[0].Stats[2].BounceRate, 0) ||
+				!visitors[0].Stats[3].Day.Equal(today()) || visitors[0].Stats[3].Visitors != 1 || visitors[0].Stats[3].Sessions != 1 || visitors[0].Stats[3].Bounces != 0 || !inRange(visitors[0].Stats[3].BounceRate, 0) {
+				t.Fatalf("First path not as expected: %v", visitors)
+			}
+
+			if len(visitors[1].Stats) != 4 || visitors[1].Path != "/path" ||
+				!visitors[1].Stats[0].Day.Equal(pastDay(3)) || visitors[1].Stats[0].Visitors != 0 || visitors[1].Stats[0].Sessions != 0 || visitors[1].Stats[0].Bounces != 0 || !inRange(visitors[1].Stats[0].BounceRate, 0) ||
+				!visitors[1].Stats[1].Day.Equal(pastDay(2)) || visitors[1].Stats[1].Visitors != 42 || visitors[1].Stats[1].Sessions != 67 || visitors[1].Stats[1].Bounces != 30 || !inRange(visitors[1].Stats[1].BounceRate, 0.6) ||
+				!visitors[1].Stats[2].Day.Equal(pastDay(1)) || visitors[1].Stats[2].Visitors != 0 || visitors[1].Stats[2].Sessions != 0 || visitors[1].Stats[2].Bounces != 0 || !inRange(visitors[1].Stats[2].BounceRate, 0) ||
+				!visitors[1].Stats[3].Day.Equal(today()) || visitors[1].Stats[3].Visitors != 1 || visitors[1].Stats[3].Sessions != 1 || visitors[1].Stats[3].Bounces != 0 || !inRange(visitors[1].Stats[3].BounceRate, 0) {
+				t.Fatalf("Second path not as expected: %v", visitors)
+			}
+		}
+	}
+}
+
+func TestAnalyzer_PageLanguages(t *testing.T) {
+	tenantIDs := []int64{0, 1}
+
+	for _, tenantID := range tenantIDs {
+		for _, store := range testStorageBackends() {
+			cleanupDB(t)
+			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/path", "de", "ua1", "", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
+			stats := &LanguageStats{
+				Stats: Stats{
+					BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+					Day:        pastDay(2),
+					Path:       "/path",
+					Visitors:   42,
+				},
+				Language: sql.NullString{String: "de", Valid: true},
+			}
+
+			if err := store.SaveLanguageStats(nil, stats); err != nil {
+				t.Fatal(err)
+			}
+
+			analyzer := NewAnalyzer(store, nil)
+			visitors, err := analyzer.PageLanguages(&Filter{
+				TenantID: NewTenantID(tenantID),
+				Path:     "/path",
+				From:     pastDay(3),
+				To:       today(),
+			})
+
+			if err != nil {
+				t.Fatalf("Visitors must be returned, but was:  %v", err)
+			}
+
+			if len(visitors) != 2 {
+				t.Fatalf("Two visitors must have been returned, but was: %v", len(visitors))
+			}
+
+			if visitors[0].Language.String != "de" || visitors[0].Visitors != 43 || !inRange(visitors[0].RelativeVisitors, 0.977) ||
+				visitors[1].Language.String != "en" || visitors[1].Visitors != 1 || !inRange(visitors[1].RelativeVisitors, 0.022) {
+				t.Fatalf("Visitors not as expected: %v", visitors)
+			}
+		}
+	}
+}
+
+func TestAnalyzer_PageReferrer(t *testing.T) {
+	tenantIDs := []int64{0, 1}
+
+	for _, tenantID := range tenantIDs {
+		for _, store := range testStorageBackends() {
+			cleanupDB(t)
+			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "ref1", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "ref1", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "ref2", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
+			stats := &ReferrerStats{
+				Stats: Stats{
+					BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+					Day:        pastDay(2),
+					Path:       "/path",
+					Visitors:   42,
+				},
+				Referrer: sql.NullString{String: "ref2", Valid: true},
+			}
+
+			if err := store.SaveReferrerStats(nil, stats); err != nil {
+				t.Fatal(err)
+			}
+
+			analyzer := NewAnalyzer(store, nil)
+			visitors, err := analyzer.PageReferrer(&Filter{
+				TenantID: NewTenantID(tenantID),
+				Path:     "/path",
+				From:     pastDay(3),
+				To:       today(),
+			})
+
+			if err != nil {
+				t.Fatalf("Visitors must be returned, but was:  %v", err)
+			}
+
+			if len(visitors) != 2 {
+				t.Fatalf("Two visitors must have been returned, but was: %v", len(visitors))
+			}
+
+			if visitors[0].Referrer.String != "ref2" || visitors[0].Visitors != 43 || !inRange(visitors[0].RelativeVisitors, 0.977) ||
+				visitors[1].Referrer.String != "ref1" || visitors[1].Visitors != 1 || !inRange(visitors[1].RelativeVisitors, 0.022) {
+				t.Fatalf("Visitors not as expected: %v", visitors)
+			}
+		}
+	}
+}
+
+func TestAnalyzer_PageOS(t *testing.T) {
+	tenantIDs := []int64{0, 1}
+
+	for _, tenantID := range tenantIDs {
+		for _, store := range testStorageBackends() {
+			cleanupDB(t)
+			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today(), time.Time{}, OSMac, "", "", "", "", false, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, OSMac, "", "", "", "", false, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, OSWindows, "", "", "", "", false, false, 0, 0)
+			stats := &OSStats{
+				Stats: Stats{
+					BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+					Day:        pastDay(2),
+					Path:       "/path",
+					Visitors:   42,
+				},
+				OS: sql.NullString{String: OSWindows, Valid: true},
+			}
+
+			if err := store.SaveOSStats(nil, stats); err != nil {
+				t.Fatal(err)
+			}
+
+			analyzer := NewAnalyzer(store, nil)
+			visitors, err := analyzer.PageOS(&Filter{
+				TenantID: NewTenantID(tenantID),
+				Path:     "/path",
+				From:     pastDay(3),
+				To:       today(),
+			})
+
+			if err != nil {
+				t.Fatalf("Visitors must be returned, but was:  %v", err)
+			}
+
+			if len(visitors) != 2 {
+				t.Fatalf("Two visitors must have been returned, but was: %v", len(visitors))
+			}
+
+			if visitors[0].OS.String != OSWindows || visitors[0].Visitors != 43 || !inRange(visitors[0].RelativeVisitors, 0.977) ||
+				visitors[1].OS.String != OSMac || visitors[1].Visitors != 1 || !inRange(visitors[1].RelativeVisitors, 0.022) {
+				t.Fatalf("Visitors not as expected: %v", visitors)
+			}
+		}
+	}
+}
+
+func TestAnalyzer_PageBrowser(t *testing.T) {
+	tenantIDs := []int64{0, 1}
+
+	for _, tenantID := range tenantIDs {
+		for _, store := range testStorageBackends() {
+			cleanupDB(t)
+			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today(), time.Time{}, "", "", BrowserFirefox, "", "", false, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, "", "", BrowserFirefox, "", "", false, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, "", "", BrowserChrome, "", "", false, false, 0, 0)
+			stats := &BrowserStats{
+				Stats: Stats{
+					BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+					Day:        pastDay(2),
+					Path:       "/path",
+					Visitors:   42,
+				},
+				Browser: sql.NullString{String: BrowserChrome, Valid: true},
+			}
+
+			if err := store.SaveBrowserStats(nil, stats); err != nil {
+				t.Fatal(err)
+			}
+
+			analyzer := NewAnalyzer(store, nil)
+			visitors, err := analyzer.PageBrowser(&Filter{
+				TenantID: NewTenantID(tenantID),
+				Path:     "/path",
+				From:     pastDay(3),
+				To:       today(),
+			})
+
+			if err != nil {
+				t.Fatalf("Visitors must be returned, but was:  %v", err)
+			}
+
+			if len(visitors) != 2 {
+				t.Fatalf("Two visitors must have been returned, but was: %v", len(visitors))
+			}
+
+			if visitors[0].Browser.String != BrowserChrome || visitors[0].Visitors != 43 || !inRange(visitors[0].RelativeVisitors, 0.977) ||
+				visitors[1].Browser.String != BrowserFirefox || visitors[1].Visitors != 1 || !inRange(visitors[1].RelativeVisitors, 0.022) {
+				t.Fatalf("Visitors not as expected: %v", visitors)
+			}
+		}
+	}
+}
+
+func TestAnalyzer_PagePlatform(t *testing.T) {
+	tenantIDs := []int64{0, 1}
+
+	for _, tenantID := range tenantIDs {
+		for _, store := range testStorageBackends() {
+			cleanupDB(t)
+			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", true, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", true, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", false, true, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
+			stats := &VisitorStats{
+				Stats: Stats{
+					BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+					Day:        pastDay(2),
+					Path:       "/path",
+				},
+				PlatformDesktop: 42,
+				PlatformMobile:  43,
+				PlatformUnknown: 44,
+			}
+
+			if err := store.SaveVisitorStats(nil, stats); err != nil {
+				t.Fatal(err)
+			}
+
+			analyzer := NewAnalyzer(store, nil)
+			visitors := analyzer.PagePlatform(&Filter{
+				TenantID: NewTenantID(tenantID),
+				Path:     "/path",
+				From:     pastDay(3),
+				To:       today(),
+			})
+
+			if visitors.PlatformDesktop != 43 || !inRange(visitors.RelativePlatformDesktop, 0.325) ||
+				visitors.PlatformMobile != 44 || !inRange(visitors.RelativePlatformMobile, 0.33) ||
+				visitors.PlatformUnknown != 45 || !inRange(visitors.RelativePlatformUnknown, 0.34) {
+				t.Fatalf("Visitors not as expected: %v", visitors)
+			}
+		}
+	}
+}
+
+func TestAnalyzer_PagePlatformNoData(t *testing.T) {
+	tenantIDs := []int64{0, 1}
+
+	for _, tenantID := range tenantIDs {
+		for _, store := range testStorageBackends() {
+			cleanupDB(t)
+			analyzer := NewAnalyzer(store, nil)
+			visitors := analyzer.PagePlatform(&Filter{
+				TenantID: NewTenantID(tenantID),
+				Path:     "/path",
+				From:     pastDay(3),
+				To:       today(),
+			})
+
+			if visitors.PlatformDesktop != 0 || !inRange(visitors.RelativePlatformDesktop, 0.001) ||
+				visitors.PlatformMobile != 0 || !inRange(visitors.RelativePlatformMobile, 0.001) ||
+				visitors.PlatformUnknown != 0 || !inRange(visitors.RelativePlatformUnknown, 0.001) {
+				t.Fatalf("Visitors not as expected: %v", visitors)
+			}
+		}
+	}
+}
+
+func TestAnalyzer_TimeOfDayTimezone(t *testing.T) {
+	for _, store := range testStorageBackends() {
+		cleanupDB(t)
+		d := day(2020, 9, 24, 15)
+		createHit(t, store, 0, "fp", "/", "en", "ua", "", d, time.Time{}, "", "", "", "", "", false, false, 0, 0)
+		tz := time.FixedZone("test", 3600*3)
+		targetDate := d.In(tz)
+
+		if targetDate.Hour() != 18 {
+			t.Fatalf("Fixed time not as expected: %v", targetDate)
+		}
+
+		analyzer := NewAnalyzer(store, &AnalyzerConfig{
+			Timezone: tz,
+		})
+		visitors, _ := analyzer.TimeOfDay(&Filter{
+			From: day(2020, 9, 24, 0),
+			To:   day(2020, 9, 24, 0),
+		})
+
+		if len(visitors) != 1 || len(visitors[0].Stats) != 24 ||
+			!visitors[0].Day.Equal(day(2020, 9, 24, 0)) ||
+			visitors[0].Stats[18].Visitors != 1 {
+			t.Fatalf("Visitors not as expected: %v", visitors)
+		}
+	}
+}
+
+func TestAnalyzer_Growth(t *testing.T) {
+	tenantIDs := []int64{0, 1}
+
+	for _, tenantID := range tenantIDs {
+		for _, store := range testStorageBackends() {
+			cleanupDB(t)
+			stats := []VisitorStats{
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(2),
+						Path:       "/home",
+						Visitors:   5,
+						Sessions:   6,
+						Bounces:    3,
+					},
+				},
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(3),
+						Path:       "/about",
+						Visitors:   6,
+						Sessions:   7,
+						Bounces:    4,
+					},
+				},
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(4),
+						Path:       "/home",
+						Visitors:   2,
+						Sessions:   3,
+						Bounces:    1,
+					},
+				},
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(5),
+						Path:       "/about",
+						Visitors:   8,
+						Sessions:   9,
+						Bounces:    6,
+					},
+				},
+			}
+
+			for _, s := range stats {
+				if err := store.SaveVisitorStats(nil, &s); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			analyzer := NewAnalyzer(store, nil)
+			growth, err := analyzer.Growth(&Filter{
+				TenantID: NewTenantID(tenantID),
+				From:     pastDay(3),
+				To:       pastDay(1),
+			})
+
+			if err != nil {
+				t.Fatalf("Growth must be returned, but was: %v", err)
+			}
+
+			if growth.Current.Visitors != 11 ||
+				growth.Current.Sessions != 13 ||
+				growth.Current.Bounces != 7 {
+				t.Fatalf("Current sums not as expected: %v", growth.Current)
+			}
+
+			if growth.Previous.Visitors != 10 ||
+				growth.Previous.Sessions != 12 ||
+				growth.Previous.Bounces != 7 {
+				t.Fatalf("Previous sums not as expected: %v", growth.Current)
+			}
+
+			if !inRange(growth.VisitorsGrowth, 0.1) ||
+				!inRange(growth.SessionsGrowth, 0.08333) ||
+				!inRange(growth.BouncesGrowth, 0) {
+				t.Fatalf("Growth not as expected: %v %v %v", growth.VisitorsGrowth, growth.SessionsGrowth, growth.BouncesGrowth)
+			}
+
+			growth, err = analyzer.Growth(&Filter{
+				TenantID: NewTenantID(tenantID),
+				From:     pastDay(3),
+				To:       pastDay(1),
+				Path:     "/home",
+			})
+
+			if err != nil {
+				t.Fatalf("Growth for path must be returned, but was: %v", err)
+			}
+
+			if !inRange(growth.VisitorsGrowth, 1.5) ||
+				!inRange(growth.SessionsGrowth, 1) ||
+				!inRange(growth.BouncesGrowth, 2) {
+				t.Fatalf("Growth for path not as expected: %v %v %v", growth.VisitorsGrowth, growth.SessionsGrowth, growth.BouncesGrowth)
 			}
 		}
 	}
 }
 
-func TestAnalyzer_PageOS(t *testing.T) {
+func TestAnalyzer_Durations(t *testing.T) {
 	tenantIDs := []int64{0, 1}
 
 	for _, tenantID := range tenantIDs {
 		for _, store := range testStorageBackends() {
 			cleanupDB(t)
-			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today(), time.Time{}, OSMac, "", "", "", "", false, false, 0, 0)
-			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, OSMac, "", "", "", "", false, false, 0, 0)
-			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, OSWindows, "", "", "", "", false, false, 0, 0)
-			stats := &OSStats{
+			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today(), today(), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/pricing", "en", "ua1", "", today().Add(time.Second*20), today(), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+			stats := []VisitorStats{
+				{
+					Stats: Stats{
+						BaseEntity:           BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:                  pastDay(2),
+						Path:                 "/",
+						Visitors:             4,
+						SessionDuration:      100,
+						SessionDurationCount: 4,
+						TimeOnPage:           50,
+						TimeOnPageCount:      5,
+					},
+				},
+				{
+					Stats: Stats{
+						BaseEntity:           BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:                  pastDay(5),
+						Path:                 "/",
+						Visitors:             2,
+						SessionDuration:      40,
+						SessionDurationCount: 2,
+						TimeOnPage:           16,
+						TimeOnPageCount:      2,
+					},
+				},
+			}
+
+			for _, s := range stats {
+				if err := store.SaveVisitorStats(nil, &s); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			analyzer := NewAnalyzer(store, nil)
+			filter := &Filter{
+				TenantID: NewTenantID(tenantID),
+				From:     pastDay(2),
+				To:       today(),
+			}
+			visitors, err := analyzer.Visitors(filter)
+
+			if err != nil {
+				t.Fatalf("Visitors must be returned, but was: %v", err)
+			}
+
+			if len(visitors) != 3 ||
+				!inRange(visitors[0].AverageSessionDuration, 25) || !inRange(visitors[0].AverageTimeOnPage, 10) ||
+				!inRange(visitors[2].AverageSessionDuration, 20) || !inRange(visitors[2].AverageTimeOnPage, 20) {
+				t.Fatalf("Visitors not as expected: %v", visitors)
+			}
+
+			filter.Path = "/"
+			pageVisitors, err := analyzer.PageVisitors(filter)
+
+			if err != nil {
+				t.Fatalf("Page visitors must be returned, but was: %v", err)
+			}
+
+			if len(pageVisitors) != 1 || len(pageVisitors[0].Stats) != 3 ||
+				!inRange(pageVisitors[0].Stats[0].AverageSessionDuration, 25) ||
+				!inRange(pageVisitors[0].Stats[2].AverageSessionDuration, 20) || !inRange(pageVisitors[0].Stats[2].AverageTimeOnPage, 20) {
+				t.Fatalf("Page visitors not as expected: %v", pageVisitors)
+			}
+
+			growth, err := analyzer.Growth(&Filter{
+				TenantID: NewTenantID(tenantID),
+				From:     pastDay(3),
+				To:       pastDay(1),
+			})
+
+			if err != nil {
+				t.Fatalf("Growth must be returned, but was: %v", err)
+			}
+
+			if !inRange(growth.Current.AverageSessionDuration, 25) ||
+				!inRange(growth.Previous.AverageSessionDuration, 20) ||
+				!inRange(growth.SessionDurationGrowth, 0.25) ||
+				!inRange(growth.TimeOnPageGrowth, 0.25) {
+				t.Fatalf("Growth not as expected: %v", growth)
+			}
+		}
+	}
+}
+
+func TestAnalyzer_PageViews(t *testing.T) {
+	tenantIDs := []int64{0, 1}
+
+	for _, tenantID := range tenantIDs {
+		for _, store := range testStorageBackends() {
+			cleanupDB(t)
+			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today(), today(), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today().Add(time.Second*20), today(), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/pricing", "en", "ua1", "", today().Add(time.Second*40), today(), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+			stats := []VisitorStats{
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(2),
+						Path:       "/",
+						Visitors:   4,
+						PageViews:  12,
+						Sessions:   5,
+					},
+				},
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(5),
+						Path:       "/",
+						Visitors:   2,
+						PageViews:  4,
+						Sessions:   2,
+					},
+				},
+			}
+
+			for _, s := range stats {
+				if err := store.SaveVisitorStats(nil, &s); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			analyzer := NewAnalyzer(store, nil)
+			filter := &Filter{
+				TenantID: NewTenantID(tenantID),
+				From:     pastDay(2),
+				To:       today(),
+			}
+			visitors, err := analyzer.Visitors(filter)
+
+			if err != nil {
+				t.Fatalf("Visitors must be returned, but was: %v", err)
+			}
+
+			if len(visitors) != 3 ||
+				visitors[0].PageViews != 12 || !inRange(visitors[0].ViewsPerVisit, 2.4) ||
+				visitors[1].PageViews != 0 || !inRange(visitors[1].ViewsPerVisit, 0) ||
+				visitors[2].PageViews != 3 || !inRange(visitors[2].ViewsPerVisit, 3) {
+				t.Fatalf("Visitors not as expected: %v", visitors)
+			}
+
+			filter.Path = "/"
+			pageVisitors, err := analyzer.PageVisitors(filter)
+
+			if err != nil {
+				t.Fatalf("Page visitors must be returned, but was: %v", err)
+			}
+
+			if len(pageVisitors) != 1 || len(pageVisitors[0].Stats) != 3 ||
+				pageVisitors[0].Stats[0].PageViews != 12 || !inRange(pageVisitors[0].Stats[0].ViewsPerVisit, 2.4) ||
+				pageVisitors[0].Stats[2].PageViews != 2 || !inRange(pageVisitors[0].Stats[2].ViewsPerVisit, 2) {
+				t.Fatalf("Page visitors not as expected: %v", pageVisitors)
+			}
+
+			growth, err := analyzer.Growth(&Filter{
+				TenantID: NewTenantID(tenantID),
+				From:     pastDay(3),
+				To:       pastDay(1),
+			})
+
+			if err != nil {
+				t.Fatalf("Growth must be returned, but was: %v", err)
+			}
+
+			if growth.Current.PageViews != 12 || !inRange(growth.Current.ViewsPerVisit, 2.4) ||
+				growth.Previous.PageViews != 4 || !inRange(growth.Previous.ViewsPerVisit, 2) ||
+				!inRange(growth.PageViewsGrowth, 2) {
+				t.Fatalf("Growth not as expected: %v", growth)
+			}
+		}
+	}
+}
+
+func TestAnalyzer_GrowthNoData(t *testing.T) {
+	for _, store := range testStorageBackends() {
+		cleanupDB(t)
+		analyzer := NewAnalyzer(store, nil)
+		growth, err := analyzer.Growth(&Filter{
+			From: pastDay(3),
+			To:   pastDay(1),
+		})
+
+		if err != nil {
+			t.Fatalf("Growth must be returned, but was: %v", err)
+		}
+
+		if growth.Current.Visitors != 0 ||
+			growth.Current.Sessions != 0 ||
+			growth.Current.Bounces != 0 {
+			t.Fatalf("Current sums not as expected: %v", growth.Current)
+		}
+
+		if growth.Previous.Visitors != 0 ||
+			growth.Previous.Sessions != 0 ||
+			growth.Previous.Bounces != 0 {
+			t.Fatalf("Previous sums not as expected: %v", growth.Current)
+		}
+
+		if !inRange(growth.VisitorsGrowth, 0) ||
+			!inRange(growth.SessionsGrowth, 0) ||
+			!inRange(growth.BouncesGrowth, 0) {
+			t.Fatalf("Growth not as expected: %v %v %v", growth.VisitorsGrowth, growth.SessionsGrowth, growth.BouncesGrowth)
+		}
+	}
+}
+
+func TestAnalyzer_Events(t *testing.T) {
+	tenantIDs := []int64{0, 1}
+
+	for _, tenantID := range tenantIDs {
+		for _, store := range testStorageBackends() {
+			cleanupDB(t)
+			createEvent(t, store, tenantID, "fp1", "/", "signup", nil, 0, today())
+			createEvent(t, store, tenantID, "fp1", "/pricing", "purchase", map[string]string{"plan": "pro"}, 20, today())
+			createEvent(t, store, tenantID, "fp1", "/", "purchase", map[string]string{"plan": "pro"}, 20, today())
+			stats := []EventStats{
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(2),
+						Path:       "/pricing",
+						Visitors:   3,
+					},
+					Name:   "purchase",
+					Events: 4,
+					Value:  60,
+				},
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(2),
+						Path:       "/",
+						Visitors:   1,
+					},
+					Name:   "purchase",
+					Events: 1,
+					Value:  20,
+				},
+			}
+
+			for _, s := range stats {
+				if err := store.SaveEventStats(nil, &s); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := store.SaveEventMetadataStats(nil, &EventMetadataStats{
 				Stats: Stats{
 					BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
 					Day:        pastDay(2),
-					Path:       "/path",
-					Visitors:   42,
+					Path:       "/pricing",
+					Visitors:   3,
 				},
-				OS: sql.NullString{String: OSWindows, Valid: true},
+				Name:      "purchase",
+				MetaKey:   "plan",
+				MetaValue: "basic",
+				Events:    4,
+			}); err != nil {
+				t.Fatal(err)
 			}
 
-			if err := store.SaveOSStats(nil, stats); err != nil {
+			analyzer := NewAnalyzer(store, nil)
+			filter := &Filter{
+				TenantID: NewTenantID(tenantID),
+				From:     pastDay(4),
+				To:       today(),
+			}
+			events, err := analyzer.Events(filter)
+
+			if err != nil {
+				t.Fatalf("Events must be returned, but was: %v", err)
+			}
+
+			if len(events) != 2 ||
+				events[0].Name != "purchase" || events[0].Visitors != 5 || events[0].Events != 7 || events[0].Value != 120 || !inRange(events[0].AverageValue, 17.142) ||
+				events[1].Name != "signup" || events[1].Visitors != 1 || events[1].Events != 1 {
+				t.Fatalf("Events not as expected: %v", events)
+			}
+
+			filter.Path = "/pricing"
+			events, err = analyzer.Events(filter)
+
+			if err != nil {
+				t.Fatalf("Events must be returned, but was: %v", err)
+			}
+
+			if len(events) != 1 || events[0].Name != "purchase" || events[0].Visitors != 4 || events[0].Events != 5 || events[0].Value != 80 {
+				t.Fatalf("Events not as expected: %v", events)
+			}
+
+			filter.Path = ""
+			metadata, err := analyzer.EventMetadata(filter, "purchase")
+
+			if err != nil {
+				t.Fatalf("Event metadata must be returned, but was: %v", err)
+			}
+
+			if len(metadata) != 2 ||
+				metadata[0].MetaKey != "plan" || metadata[0].MetaValue != "basic" || metadata[0].Visitors != 3 || !inRange(metadata[0].RelativeVisitors, 0.75) ||
+				metadata[1].MetaKey != "plan" || metadata[1].MetaValue != "pro" || metadata[1].Visitors != 1 || metadata[1].Events != 2 {
+				t.Fatalf("Event metadata not as expected: %v", metadata)
+			}
+		}
+	}
+}
+
+func TestAnalyzer_Goals(t *testing.T) {
+	tenantIDs := []int64{0, 1}
+
+	for _, tenantID := range tenantIDs {
+		for _, store := range testStorageBackends() {
+			cleanupDB(t)
+			signup := NewPathGoal(NewTenantID(tenantID), "Signup", "/signup")
+			download := NewEventGoal(NewTenantID(tenantID), "Download", "download")
+			newsletter := NewEventGoal(NewTenantID(tenantID), "Newsletter", "newsletter")
+
+			for _, goal := range []*Goal{signup, download, newsletter} {
+				if err := store.SaveGoal(goal); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "ref1", today(), time.Time{}, OSWindows, "10", BrowserChrome, "84.0", "de", true, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/signup", "en", "ua1", "ref1", today(), time.Time{}, OSWindows, "10", BrowserChrome, "84.0", "de", true, false, 0, 0)
+			createHit(t, store, tenantID, "fp2", "/", "en", "ua2", "ref2", today(), time.Time{}, OSMac, "10.15.3", BrowserFirefox, "53.0", "gb", true, false, 0, 0)
+			createEvent(t, store, tenantID, "fp2", "/", "download", nil, 0, today())
+			stats := []GoalStats{
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(2),
+						Visitors:   2,
+					},
+					GoalID:      signup.ID,
+					Referrer:    sql.NullString{String: "ref1", Valid: true},
+					CountryCode: sql.NullString{String: "de", Valid: true},
+					Browser:     sql.NullString{String: BrowserChrome, Valid: true},
+				},
+				{
+					Stats: Stats{
+						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+						Day:        pastDay(2),
+						Visitors:   1,
+					},
+					GoalID:      signup.ID,
+					Referrer:    sql.NullString{String: "ref2", Valid: true},
+					CountryCode: sql.NullString{String: "gb", Valid: true},
+					Browser:     sql.NullString{String: BrowserFirefox, Valid: true},
+				},
+			}
+
+			for _, s := range stats {
+				if err := store.SaveGoalStats(nil, &s); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := store.SaveVisitorStats(nil, &VisitorStats{
+				Stats: Stats{
+					BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
+					Day:        pastDay(2),
+					Path:       "/",
+					Visitors:   8,
+				},
+			}); err != nil {
 				t.Fatal(err)
 			}
 
 			analyzer := NewAnalyzer(store, nil)
-			visitors, err := analyzer.PageOS(&Filter{
+			filter := &Filter{
 				TenantID: NewTenantID(tenantID),
-				Path:     "/path",
-				From:     pastDay(3),
+				From:     pastDay(4),
 				To:       today(),
-			})
+			}
+			goals, err := analyzer.Goals(filter)
+
+			if err != nil {
+				t.Fatalf("Goals must be returned, but was: %v", err)
+			}
+
+			if len(goals) != 3 ||
+				goals[0].Name != "Signup" || goals[0].Visitors != 4 || !inRange(goals[0].ConversionRate, 0.4) ||
+				goals[1].Name != "Download" || goals[1].Visitors != 1 || !inRange(goals[1].ConversionRate, 0.1) ||
+				goals[2].Name != "Newsletter" || goals[2].Visitors != 0 || goals[2].ConversionRate != 0 {
+				t.Fatalf("Goals not as expected: %v", goals)
+			}
+
+			referrer, err := analyzer.GoalReferrer(filter, signup.ID)
+
+			if err != nil {
+				t.Fatalf("Goal referrer must be returned, but was: %v", err)
+			}
+
+			if len(referrer) != 2 ||
+				referrer[0].Referrer.String != "ref1" || referrer[0].Visitors != 3 || !inRange(referrer[0].ConversionRate, 0.3) ||
+				referrer[1].Referrer.String != "ref2" || referrer[1].Visitors != 1 {
+				t.Fatalf("Goal referrer not as expected: %v", referrer)
+			}
+
+			country, err := analyzer.GoalCountry(filter, download.ID)
 
 			if err != nil {
-				t.Fatalf("Visitors must be returned, but was:  %v", err)
+				t.Fatalf("Goal countries must be returned, but was: %v", err)
 			}
 
-			if len(visitors) != 2 {
-				t.Fatalf("Two visitors must have been returned, but was: %v", len(visitors))
+			if len(country) != 1 || country[0].CountryCode.String != "gb" || country[0].Visitors != 1 || country[0].Name != "Download" {
+				t.Fatalf("Goal countries not as expected: %v", country)
 			}
 
-			if visitors[0].OS.String != OSWindows || visitors[0].Visitors != 43 || !inRange(visitors[0].RelativeVisitors, 0.977) ||
-				visitors[1].OS.String != OSMac || visitors[1].Visitors != 1 || !inRange(visitors[1].RelativeVisitors, 0.022) {
-				t.Fatalf("Visitors not as expected: %v", visitors)
+			browser, err := analyzer.GoalBrowser(filter, 42)
+
+			if err != nil {
+				t.Fatalf("Goal browsers must be returned, but was: %v", err)
+			}
+
+			if len(browser) != 0 {
+				t.Fatalf("Goal browsers for unknown goal must be empty, but was: %v", browser)
 			}
 		}
 	}
 }
 
-func TestAnalyzer_PageBrowser(t *testing.T) {
+func TestAnalyzer_EntryExitPages(t *testing.T) {
 	tenantIDs := []int64{0, 1}
 
 	for _, tenantID := range tenantIDs {
 		for _, store := range testStorageBackends() {
 			cleanupDB(t)
-			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today(), time.Time{}, "", "", BrowserFirefox, "", "", false, false, 0, 0)
-			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, "", "", BrowserFirefox, "", "", false, false, 0, 0)
-			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, "", "", BrowserChrome, "", "", false, false, 0, 0)
-			stats := &BrowserStats{
-				Stats: Stats{
-					BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
-					Day:        pastDay(2),
-					Path:       "/path",
-					Visitors:   42,
-				},
-				Browser: sql.NullString{String: BrowserChrome, Valid: true},
+			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today(), today(), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/pricing", "en", "ua1", "", today().Add(time.Second), today(), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+			createHit(t, store, tenantID, "fp2", "/", "en", "ua2", "", today(), today(), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+			entries := []EntryStats{
+				{Stats: Stats{Path: "/", Visitors: 3}, Entries: 4},
+				{Stats: Stats{Path: "/about", Visitors: 2}, Entries: 2},
+			}
+			exits := []ExitStats{
+				{Stats: Stats{Path: "/", Visitors: 1, Sessions: 5}, Exits: 1},
+				{Stats: Stats{Path: "/about", Visitors: 3, Sessions: 4}, Exits: 3},
+				{Stats: Stats{Path: "/blog", Sessions: 3}},
 			}
 
-			if err := store.SaveBrowserStats(nil, stats); err != nil {
-				t.Fatal(err)
+			for _, e := range entries {
+				e.TenantID = NewTenantID(tenantID)
+				e.Day = pastDay(2)
+
+				if err := store.SaveEntryStats(nil, &e); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, e := range exits {
+				e.TenantID = NewTenantID(tenantID)
+				e.Day = pastDay(2)
+
+				if err := store.SaveExitStats(nil, &e); err != nil {
+					t.Fatal(err)
+				}
 			}
 
 			analyzer := NewAnalyzer(store, nil)
-			visitors, err := analyzer.PageBrowser(&Filter{
+			filter := &Filter{
 				TenantID: NewTenantID(tenantID),
-				Path:     "/path",
-				From:     pastDay(3),
+				From:     pastDay(4),
 				To:       today(),
-			})
+			}
+			entryPages, err := analyzer.EntryPages(filter)
 
 			if err != nil {
-				t.Fatalf("Visitors must be returned, but was:  %v", err)
+				t.Fatalf("Entry pages must be returned, but was: %v", err)
 			}
 
-			if len(visitors) != 2 {
-				t.Fatalf("Two visitors must have been returned, but was: %v", len(visitors))
+			if len(entryPages) != 2 ||
+				entryPages[0].Path != "/" || entryPages[0].Visitors != 5 || entryPages[0].Entries != 6 || !inRange(entryPages[0].RelativeVisitors, 0.7142) ||
+				entryPages[1].Path != "/about" || entryPages[1].Visitors != 2 || entryPages[1].Entries != 2 {
+				t.Fatalf("Entry pages not as expected: %v", entryPages)
 			}
 
-			if visitors[0].Browser.String != BrowserChrome || visitors[0].Visitors != 43 || !inRange(visitors[0].RelativeVisitors, 0.977) ||
-				visitors[1].Browser.String != BrowserFirefox || visitors[1].Visitors != 1 || !inRange(visitors[1].RelativeVisitors, 0.022) {
-				t.Fatalf("Visitors not as expected: %v", visitors)
+			exitPages, err := analyzer.ExitPages(filter)
+
+			if err != nil {
+				t.Fatalf("Exit pages must be returned, but was: %v", err)
+			}
+
+			if len(exitPages) != 3 ||
+				exitPages[0].Path != "/about" || exitPages[0].Exits != 3 || !inRange(exitPages[0].ExitRate, 0.75) || !inRange(exitPages[0].RelativeVisitors, 0.5) ||
+				exitPages[1].Path != "/" || exitPages[1].Exits != 2 || exitPages[1].Sessions != 7 || !inRange(exitPages[1].ExitRate, 0.2857) ||
+				exitPages[2].Path != "/pricing" || exitPages[2].Exits != 1 || exitPages[2].ExitRate != 1 {
+				t.Fatalf("Exit pages not as expected: %v", exitPages)
+			}
+
+			filter.Path = "/pricing"
+			exitPages, err = analyzer.ExitPages(filter)
+
+			if err != nil {
+				t.Fatalf("Exit pages must be returned, but was: %v", err)
+			}
+
+			if len(exitPages) != 1 || exitPages[0].Path != "/pricing" {
+				t.Fatalf("Exit pages must be filtered by path, but was: %v", exitPages)
 			}
 		}
 	}
 }
 
-func TestAnalyzer_PagePlatform(t *testing.T) {
+func TestAnalyzer_Transitions(t *testing.T) {
 	tenantIDs := []int64{0, 1}
 
 	for _, tenantID := range tenantIDs {
 		for _, store := range testStorageBackends() {
 			cleanupDB(t)
-			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", true, false, 0, 0)
-			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", true, false, 0, 0)
-			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", false, true, 0, 0)
-			createHit(t, store, tenantID, "fp1", "/path", "en", "ua1", "", today(), time.Time{}, "", "", "", "", "", false, false, 0, 0)
-			stats := &VisitorStats{
-				Stats: Stats{
-					BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
-					Day:        pastDay(2),
-					Path:       "/path",
-				},
-				PlatformDesktop: 42,
-				PlatformMobile:  43,
-				PlatformUnknown: 44,
+			createHit(t, store, tenantID, "fp1", "/", "en", "ua1", "", today(), today(), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/pricing", "en", "ua1", "", today().Add(time.Second), today(), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+			transitions := []TransitionStats{
+				{Stats: Stats{Path: "/", Visitors: 2}, NextPath: "/about", Transitions: 3},
+				{Stats: Stats{Path: "/", Visitors: 1}, NextPath: "/pricing", Transitions: 1},
+				{Stats: Stats{Path: "/blog", Visitors: 2}, NextPath: "/pricing", Transitions: 4},
 			}
 
-			if err := store.SaveVisitorStats(nil, stats); err != nil {
-				t.Fatal(err)
+			for _, tr := range transitions {
+				tr.TenantID = NewTenantID(tenantID)
+				tr.Day = pastDay(2)
+
+				if err := store.SaveTransitionStats(nil, &tr); err != nil {
+					t.Fatal(err)
+				}
 			}
 
 			analyzer := NewAnalyzer(store, nil)
-			visitors := analyzer.PagePlatform(&Filter{
+			filter := &Filter{
 				TenantID: NewTenantID(tenantID),
-				Path:     "/path",
-				From:     pastDay(3),
+				From:     pastDay(4),
 				To:       today(),
-			})
-
-			if visitors.PlatformDesktop != 43 || !inRange(visitors.RelativePlatformDesktop, 0.325) ||
-				visitors.PlatformMobile != 44 || !inRange(visitors.RelativePlatformMobile, 0.33) ||
-				visitors.PlatformUnknown != 45 || !inRange(visitors.RelativePlatformUnknown, 0.34) {
-				t.Fatalf("Visitors not as expected: %v", visitors)
 			}
-		}
-	}
-}
+			next, err := analyzer.NextPages(filter)
 
-func TestAnalyzer_PagePlatformNoData(t *testing.T) {
-	tenantIDs := []int64{0, 1}
+			if err != nil || len(next) != 0 {
+				t.Fatalf("No next pages must be returned without path, but was: %v %v", err, next)
+			}
 
-	for _, tenantID := range tenantIDs {
-		for _, store := range testStorageBackends() {
-			cleanupDB(t)
-			analyzer := NewAnalyzer(store, nil)
-			visitors := analyzer.PagePlatform(&Filter{
-				TenantID: NewTenantID(tenantID),
-				Path:     "/path",
-				From:     pastDay(3),
-				To:       today(),
-			})
+			filter.Path = "/"
+			next, err = analyzer.NextPages(filter)
 
-			if visitors.PlatformDesktop != 0 || !inRange(visitors.RelativePlatformDesktop, 0.001) ||
-				visitors.PlatformMobile != 0 || !inRange(visitors.RelativePlatformMobile, 0.001) ||
-				visitors.PlatformUnknown != 0 || !inRange(visitors.RelativePlatformUnknown, 0.001) {
-				t.Fatalf("Visitors not as expected: %v", visitors)
+			if err != nil {
+				t.Fatalf("Next pages must be returned, but was: %v", err)
 			}
-		}
-	}
-}
 
-func TestAnalyzer_TimeOfDayTimezone(t *testing.T) {
-	for _, store := range testStorageBackends() {
-		cleanupDB(t)
-		d := day(2020, 9, 24, 15)
-		createHit(t, store, 0, "fp", "/", "en", "ua", "", d, time.Time{}, "", "", "", "", "", false, false, 0, 0)
-		tz := time.FixedZone("test", 3600*3)
-		targetDate := d.In(tz)
+			if len(next) != 2 ||
+				next[0].NextPath != "/about" || next[0].Visitors != 2 || next[0].Transitions != 3 || !inRange(next[0].RelativeVisitors, 0.5) ||
+				next[1].NextPath != "/pricing" || next[1].Visitors != 2 || next[1].Transitions != 2 || !inRange(next[1].RelativeVisitors, 0.5) {
+				t.Fatalf("Next pages not as expected: %v", next)
+			}
 
-		if targetDate.Hour() != 18 {
-			t.Fatalf("Fixed time not as expected: %v", targetDate)
-		}
+			filter.Path = "/pricing"
+			previous, err := analyzer.PreviousPages(filter)
 
-		analyzer := NewAnalyzer(store, &AnalyzerConfig{
-			Timezone: tz,
-		})
-		visitors, _ := analyzer.TimeOfDay(&Filter{
-			From: day(2020, 9, 24, 0),
-			To:   day(2020, 9, 24, 0),
-		})
+			if err != nil {
+				t.Fatalf("Previous pages must be returned, but was: %v", err)
+			}
 
-		if len(visitors) != 1 || len(visitors[0].Stats) != 24 ||
-			!visitors[0].Day.Equal(day(2020, 9, 24, 0)) ||
-			visitors[0].Stats[18].Visitors != 1 {
-			t.Fatalf("Visitors not as expected: %v", visitors)
+			if len(previous) != 2 ||
+				previous[0].Path != "/blog" || previous[0].Visitors != 2 || previous[0].Transitions != 4 ||
+				previous[1].Path != "/" || previous[1].Visitors != 2 || previous[1].Transitions != 2 {
+				t.Fatalf("Previous pages not as expected: %v", previous)
+			}
 		}
 	}
 }
 
-func TestAnalyzer_Growth(t *testing.T) {
+func TestAnalyzer_Funnel(t *testing.T) {
 	tenantIDs := []int64{0, 1}
 
 	for _, tenantID := range tenantIDs {
 		for _, store := range testStorageBackends() {
 			cleanupDB(t)
-			stats := []VisitorStats{
-				{
+			funnel := NewFunnel(NewTenantID(tenantID), "Signup", "/pricing", "/signup", "/welcome")
+
+			if err := store.SaveFunnel(funnel); err != nil {
+				t.Fatal(err)
+			}
+
+			createHit(t, store, tenantID, "fp1", "/pricing", "en", "ua1", "", today(), today(), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+			createHit(t, store, tenantID, "fp1", "/signup", "en", "ua1", "", today().Add(time.Second), today(), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+
+			for step, visitors := range []int{6, 3, 1} {
+				if err := store.SaveFunnelStats(nil, &FunnelStats{
 					Stats: Stats{
 						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
 						Day:        pastDay(2),
-						Path:       "/home",
-						Visitors:   5,
-						Sessions:   6,
-						Bounces:    3,
-					},
-				},
-				{
-					Stats: Stats{
-						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
-						Day:        pastDay(3),
-						Path:       "/about",
-						Visitors:   6,
-						Sessions:   7,
-						Bounces:    4,
-					},
-				},
-				{
-					Stats: Stats{
-						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
-						Day:        pastDay(4),
-						Path:       "/home",
-						Visitors:   2,
-						Sessions:   3,
-						Bounces:    1,
-					},
-				},
-				{
-					Stats: Stats{
-						BaseEntity: BaseEntity{TenantID: NewTenantID(tenantID)},
-						Day:        pastDay(5),
-						Path:       "/about",
-						Visitors:   8,
-						Sessions:   9,
-						Bounces:    6,
+						Visitors:   visitors,
 					},
-				},
-			}
-
-			for _, s := range stats {
-				if err := store.SaveVisitorStats(nil, &s); err != nil {
+					FunnelID: funnel.ID,
+					Step:     step,
+				}); err != nil {
 					t.Fatal(err)
 				}
 			}
 
 			analyzer := NewAnalyzer(store, nil)
-			growth, err := analyzer.Growth(&Filter{
+			filter := &Filter{
 				TenantID: NewTenantID(tenantID),
-				From:     pastDay(3),
-				To:       pastDay(1),
-			})
-
-			if err != nil {
-				t.Fatalf("Growth must be returned, but was: %v", err)
-			}
-
-			if growth.Current.Visitors != 11 ||
-				growth.Current.Sessions != 13 ||
-				growth.Current.Bounces != 7 {
-				t.Fatalf("Current sums not as expected: %v", growth.Current)
+				From:     pastDay(4),
+				To:       today(),
 			}
+			stats, err := analyzer.Funnel(filter, funnel.ID)
 
-			if growth.Previous.Visitors != 10 ||
-				growth.Previous.Sessions != 12 ||
-				growth.Previous.Bounces != 7 {
-				t.Fatalf("Previous sums not as expected: %v", growth.Current)
+			if err != nil {
+				t.Fatalf("Funnel must be returned, but was: %v", err)
 			}
 
-			if !inRange(growth.VisitorsGrowth, 0.1) ||
-				!inRange(growth.SessionsGrowth, 0.08333) ||
-				!inRange(growth.BouncesGrowth, 0) {
-				t.Fatalf("Growth not as expected: %v %v %v", growth.VisitorsGrowth, growth.SessionsGrowth, growth.BouncesGrowth)
+			if len(stats) != 3 ||
+				stats[0].Pattern != "/pricing" || stats[0].Visitors != 7 || stats[0].RelativeVisitors != 1 || stats[0].DropOff != 0 ||
+				stats[1].Pattern != "/signup" || stats[1].Visitors != 4 || !inRange(stats[1].RelativeVisitors, 0.5714) || !inRange(stats[1].DropOff, 0.4285) ||
+				stats[2].Pattern != "/welcome" || stats[2].Visitors != 1 || !inRange(stats[2].DropOff, 0.75) {
+				t.Fatalf("Funnel not as expected: %v", stats)
 			}
 
-			growth, err = analyzer.Growth(&Filter{
-				TenantID: NewTenantID(tenantID),
-				From:     pastDay(3),
-				To:       pastDay(1),
-				Path:     "/home",
-			})
+			stats, err = analyzer.Funnel(filter, 42)
 
 			if err != nil {
-				t.Fatalf("Growth for path must be returned, but was: %v", err)
+				t.Fatalf("Funnel must be returned, but was: %v", err)
 			}
 
-			if !inRange(growth.VisitorsGrowth, 1.5) ||
-				!inRange(growth.SessionsGrowth, 1) ||
-				!inRange(growth.BouncesGrowth, 2) {
-				t.Fatalf("Growth for path not as expected: %v %v %v", growth.VisitorsGrowth, growth.SessionsGrowth, growth.BouncesGrowth)
+			if len(stats) != 0 {
+				t.Fatalf("Unknown funnel must be empty, but was: %v", stats)
 			}
 		}
 	}
 }
 
-func TestAnalyzer_GrowthNoData(t *testing.T) {
-	for _, store := range testStorageBackends() {
-		cleanupDB(t)
-		analyzer := NewAnalyzer(store, nil)
-		growth, err := analyzer.Growth(&Filter{
-			From: pastDay(3),
-			To:   pastDay(1),
-		})
+func TestCalculateRelativeVisitors(t *testing.T) {
+	stats := []EntryStats{{Stats: Stats{Visitors: 3}}, {Stats: Stats{Visitors: 1}}}
+	calculateRelativeVisitors(len(stats), func(i int) *Stats {
+		return &stats[i].Stats
+	})
 
-		if err != nil {
-			t.Fatalf("Growth must be returned, but was: %v", err)
-		}
+	if !inRange(stats[0].RelativeVisitors, 0.75) || !inRange(stats[1].RelativeVisitors, 0.25) {
+		t.Fatalf("Relative visitors not as expected: %v", stats)
+	}
 
-		if growth.Current.Visitors != 0 ||
-			growth.Current.Sessions != 0 ||
-			growth.Current.Bounces != 0 {
-			t.Fatalf("Current sums not as expected: %v", growth.Current)
-		}
+	empty := []EntryStats{{}, {}}
+	calculateRelativeVisitors(len(empty), func(i int) *Stats {
+		return &empty[i].Stats
+	})
 
-		if growth.Previous.Visitors != 0 ||
-			growth.Previous.Sessions != 0 ||
-			growth.Previous.Bounces != 0 {
-			t.Fatalf("Previous sums not as expected: %v", growth.Current)
-		}
+	if empty[0].RelativeVisitors != 0 || empty[1].RelativeVisitors != 0 {
+		t.Fatalf("Relative visitors must be 0 without visitors, but was: %v", empty)
+	}
+}
 
-		if !inRange(growth.VisitorsGrowth, 0) ||
-			!inRange(growth.SessionsGrowth, 0) ||
-			!inRange(growth.BouncesGrowth, 0) {
-			t.Fatalf("Growth not as expected: %v %v %v", growth.VisitorsGrowth, growth.SessionsGrowth, growth.BouncesGrowth)
-		}
+func TestAnalyzer_NotArchived(t *testing.T) {
+	analyzer := NewAnalyzer(newTestStore(), nil)
+	filter := &Filter{From: pastDay(2), To: today(), Country: "de"}
+
+	if _, err := analyzer.Visitors(filter); err != ErrNotArchived {
+		t.Fatalf("Filtered visitors for processed days must be rejected without the archive, but was: %v", err)
+	}
+
+	if _, err := analyzer.EntryPages(filter); err != ErrNotArchived {
+		t.Fatalf("Filtered entry pages for processed days must be rejected without the archive, but was: %v", err)
+	}
+
+	analyzer = NewAnalyzer(newTestStore(), &AnalyzerConfig{ArchiveRetention: time.Hour * 24})
+
+	if _, err := analyzer.Visitors(filter); err != ErrNotArchived {
+		t.Fatalf("Filtered visitors for days before the archive retention must be rejected, but was: %v", err)
 	}
 }
 
@@ -1083,6 +2251,10 @@ func TestAnalyzer_CalculateGrowth(t *testing.T) {
 	if growth := analyzer.calculateGrowth(50, 100); !inRange(growth, -0.5) {
 		t.Fatalf("Growth must be -0.5, but was: %v", growth)
 	}
+
+	if growth := analyzer.calculateGrowthFloat(37.5, 25); !inRange(growth, 0.5) {
+		t.Fatalf("Growth must be 0.5, but was: %v", growth)
+	}
 }
 
 func pastDay(n int) time.Time {
@@ -0,0 +1,401 @@
+package pirsch
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	defaultCollectMaxBodySize  = 1024 * 64
+	defaultCollectMaxBatchSize = 50
+)
+
+var (
+	errCollectInvalidLocation = errors.New("invalid location")
+	errCollectHostname        = errors.New("hostname not allowed")
+	errCollectTenant          = errors.New("tenant not allowed")
+	errCollectToken           = errors.New("invalid token")
+	errCollectEventName       = errors.New("event name missing")
+)
+
+// transparentGIF is a transparent 1x1 pixel GIF image.
+var transparentGIF = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// CollectConfig is the (optional) configuration for the CollectHandler.
+type CollectConfig struct {
+	// Domains is the list of hostnames hits are accepted for.
+	// The Origin header (if sent) and the location of the hit must match one of them.
+	// If empty, hits are accepted for all hostnames.
+	Domains []string
+
+	// DomainsIncludeSubdomains set to true to accept all subdomains of Domains as well.
+	DomainsIncludeSubdomains bool
+
+	// TenantIDs is the list of accepted tenant IDs.
+	// If empty and no TenantResolver is set, only hits without tenant ID are accepted.
+	TenantIDs []int64
+
+	// TenantResolver is called to check if the tenant ID (which might be null) is valid for the hostname of the hit.
+	// It takes precedence over TenantIDs.
+	TenantResolver func(tenantID sql.NullInt64, hostname string) bool
+
+	// TokenSecret enables verifying the site token sent with each hit if set.
+	// Hits without token, or with a token not issued for the tenant ID and hostname of the location, are rejected.
+	// Use NewSiteToken to create the token for a site.
+	TokenSecret []byte
+
+	// GIF set to true to respond with a transparent 1x1 pixel GIF instead of 204 No Content.
+	// This is useful if the endpoint is called using an image tag.
+	// It doesn't apply to POST requests.
+	GIF bool
+
+	// MaxBodySize is the maximum size in bytes of the body of POST requests.
+	// The default is 64 KB.
+	MaxBodySize int64
+
+	// MaxBatchSize is the maximum number of hits and events accepted in a single POST request.
+	// The default is 50.
+	MaxBatchSize int
+}
+
+func (config *CollectConfig) validate() {
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = defaultCollectMaxBodySize
+	}
+
+	if config.MaxBatchSize <= 0 {
+		config.MaxBatchSize = defaultCollectMaxBatchSize
+	}
+}
+
+// CollectRequest is the JSON body accepted by the CollectHandler for POST requests.
+// The tenant ID and token apply to all hits and events.
+type CollectRequest struct {
+	TenantID int64          `json:"tenant_id"`
+	Token    string         `json:"token"`
+	Hits     []CollectHit   `json:"hits"`
+	Events   []CollectEvent `json:"events"`
+}
+
+// CollectHit is a single hit within a CollectRequest.
+type CollectHit struct {
+	Location string `json:"location"`
+	Referrer string `json:"referrer"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+// CollectEvent is a single event within a CollectRequest.
+// The location, referrer, and screen size are the ones of the page the event has been triggered on.
+type CollectEvent struct {
+	CollectHit
+
+	Name  string            `json:"name"`
+	Meta  map[string]string `json:"meta"`
+	Value float64           `json:"value"`
+}
+
+func (hit *CollectHit) hitOptions(tenantID int64) *HitOptions {
+	return &HitOptions{
+		TenantID:     NewTenantID(tenantID),
+		URL:          getURLQueryParam(hit.Location),
+		Referrer:     getURLQueryParam(hit.Referrer),
+		ScreenWidth:  hit.Width,
+		ScreenHeight: hit.Height,
+	}
+}
+
+// trackInput returns the TrackInput for a batched hit or event with the validated options.
+// The Referer header of the request is not used, as it's the page that sent the batch and not the referrer of the item.
+func trackInput(r *http.Request, options *HitOptions) *TrackInput {
+	return &TrackInput{
+		TenantID:       options.TenantID,
+		IP:             getIP(r),
+		UserAgent:      r.UserAgent(),
+		AcceptLanguage: r.Header.Get("Accept-Language"),
+		URL:            options.URL,
+		Referrer:       options.Referrer,
+		ScreenWidth:    options.ScreenWidth,
+		ScreenHeight:   options.ScreenHeight,
+	}
+}
+
+// CollectResponse is the JSON response to POST requests sent by the CollectHandler.
+type CollectResponse struct {
+	Accepted int                   `json:"accepted"`
+	Rejected []CollectRejectedItem `json:"rejected"`
+}
+
+// CollectRejectedItem is an item of the CollectRequest that has been rejected.
+// The index counts the hits first and the events afterwards.
+type CollectRejectedItem struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// collectHandler implements the CollectHandler.
+type collectHandler struct {
+	tracker *Tracker
+	config  CollectConfig
+}
+
+// CollectHandler returns a http.Handler to accept hits sent by pirsch.js.
+// It validates the request method, the Origin header and location against the configured domains, the tenant ID,
+// and the site token (if enabled).
+// A single hit is sent as a GET request using query parameters (see HitOptionsFromRequest).
+// Multiple hits and events can be sent at once as a POST request with a CollectRequest as JSON body, which is compatible
+// with navigator.sendBeacon. Each item is validated on its own and the handler responds with a CollectResponse,
+// telling which items have been rejected.
+// Cross-origin requests and CORS preflight requests are answered for allowed origins.
+// Responses are never cached. Pass nil for the config to accept hits for all domains without tenant ID.
+func CollectHandler(tracker *Tracker, config *CollectConfig) http.Handler {
+	if config == nil {
+		config = new(CollectConfig)
+	}
+
+	config.validate()
+	return &collectHandler{
+		tracker: tracker,
+		config:  *config,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (handler *collectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+
+	if origin != "" {
+		if !handler.validOrigin(origin) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+	}
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Max-Age", "86400")
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodGet:
+		options := HitOptionsFromRequest(r)
+
+		if err := handler.validate(options, r.URL.Query().Get("token")); err != nil {
+			if err == errCollectInvalidLocation {
+				w.WriteHeader(http.StatusBadRequest)
+			} else {
+				w.WriteHeader(http.StatusForbidden)
+			}
+
+			return
+		}
+
+		handler.tracker.Hit(r, options)
+		handler.respond(w)
+	case http.MethodPost:
+		handler.collectBatch(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, OPTIONS")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// collectBatch reads the CollectRequest from the body and tracks all valid hits and events.
+// The content type is not checked, as navigator.sendBeacon sends text/plain to prevent a preflight request.
+func (handler *collectHandler) collectBatch(w http.ResponseWriter, r *http.Request) {
+	var req CollectRequest
+	read := &countingReader{Reader: r.Body}
+	body := http.MaxBytesReader(w, ioutil.NopCloser(read), handler.config.MaxBodySize)
+
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		// http.MaxBytesReader reads one byte more than allowed to detect bodies that are too large
+		if read.n > handler.config.MaxBodySize {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+
+		return
+	}
+
+	if len(req.Hits) == 0 && len(req.Events) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Hits)+len(req.Events) > handler.config.MaxBatchSize {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	resp := CollectResponse{Rejected: make([]CollectRejectedItem, 0)}
+
+	for i, hit := range req.Hits {
+		options := hit.hitOptions(req.TenantID)
+
+		if err := handler.validate(options, req.Token); err != nil {
+			resp.Rejected = append(resp.Rejected, CollectRejectedItem{Index: i, Error: err.Error()})
+			continue
+		}
+
+		handler.tracker.Track(trackInput(r, options))
+		resp.Accepted++
+	}
+
+	for i, event := range req.Events {
+		options := event.hitOptions(req.TenantID)
+		err := handler.validate(options, req.Token)
+
+		if err == nil && strings.TrimSpace(event.Name) == "" {
+			err = errCollectEventName
+		}
+
+		if err != nil {
+			resp.Rejected = append(resp.Rejected, CollectRejectedItem{Index: len(req.Hits) + i, Error: err.Error()})
+			continue
+		}
+
+		handler.tracker.TrackEvent(trackInput(r, options), EventOptions{
+			Name:  event.Name,
+			Meta:  event.Meta,
+			Value: event.Value,
+		})
+		resp.Accepted++
+	}
+
+	handler.respondJSON(w, &resp)
+}
+
+// validate checks the HitOptions and site token and returns an error if the hit must be rejected.
+// It also sets the Tracker configuration that is usually set when no HitOptions are passed.
+func (handler *collectHandler) validate(options *HitOptions, token string) error {
+	location, err := url.ParseRequestURI(options.URL)
+
+	if options.URL == "" || err != nil {
+		return errCollectInvalidLocation
+	}
+
+	hostname := location.Hostname()
+
+	if !handler.validHostname(hostname) {
+		return errCollectHostname
+	}
+
+	options.TenantID = NewTenantID(options.TenantID.Int64)
+
+	if !handler.validTenant(options.TenantID, hostname) {
+		return errCollectTenant
+	}
+
+	if len(handler.config.TokenSecret) != 0 && !VerifySiteToken(handler.config.TokenSecret, token, options.TenantID, hostname) {
+		return errCollectToken
+	}
+
+	options.ReferrerDomainBlacklist = handler.tracker.referrerDomainBlacklist
+	options.ReferrerDomainBlacklistIncludesSubdomains = handler.tracker.referrerDomainBlacklistIncludesSubdomains
+	return nil
+}
+
+func (handler *collectHandler) respond(w http.ResponseWriter) {
+	setNoCacheHeaders(w)
+
+	if handler.config.GIF {
+		w.Header().Set("Content-Type", "image/gif")
+		w.WriteHeader(http.StatusOK)
+		w.Write(transparentGIF)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *collectHandler) respondJSON(w http.ResponseWriter, resp *CollectResponse) {
+	setNoCacheHeaders(w)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (handler *collectHandler) validOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+
+	if err != nil {
+		return false
+	}
+
+	return handler.validHostname(u.Hostname())
+}
+
+func (handler *collectHandler) validHostname(hostname string) bool {
+	if len(handler.config.Domains) == 0 {
+		return true
+	}
+
+	hostname = strings.ToLower(hostname)
+
+	for _, domain := range handler.config.Domains {
+		domain = strings.ToLower(domain)
+
+		if hostname == domain ||
+			handler.config.DomainsIncludeSubdomains && strings.HasSuffix(hostname, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (handler *collectHandler) validTenant(tenantID sql.NullInt64, hostname string) bool {
+	if handler.config.TenantResolver != nil {
+		return handler.config.TenantResolver(tenantID, hostname)
+	}
+
+	if len(handler.config.TenantIDs) == 0 {
+		return !tenantID.Valid
+	}
+
+	if !tenantID.Valid {
+		return false
+	}
+
+	for _, id := range handler.config.TenantIDs {
+		if id == tenantID.Int64 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func setNoCacheHeaders(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+}
+
+// countingReader counts the bytes read from the underlying io.Reader.
+type countingReader struct {
+	io.Reader
+
+	n int64
+}
+
+// Read implements the io.Reader interface.
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,265 @@
+package pirsch
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCollectHandler(t *testing.T) {
+	store := newTestStore()
+	tracker := NewTracker(store, "salt", &TrackerConfig{Worker: 1})
+	handler := CollectHandler(tracker, &CollectConfig{
+		Domains:                  []string{"example.com"},
+		DomainsIncludeSubdomains: true,
+		TenantIDs:                []int64{42},
+	})
+	input := []struct {
+		method string
+		origin string
+		query  string
+		status int
+	}{
+		{http.MethodGet, "", "?tenantid=42&location=https%3A%2F%2Fexample.com%2Ffoo", http.StatusNoContent},
+		{http.MethodGet, "https://blog.example.com", "?tenantid=42&location=https%3A%2F%2Fblog.example.com%2Fbar", http.StatusNoContent},
+		{http.MethodGet, "https://evil.com", "?tenantid=42&location=https%3A%2F%2Fexample.com%2F", http.StatusForbidden},
+		{http.MethodGet, "", "?tenantid=42&location=https%3A%2F%2Fevil.com%2F", http.StatusForbidden},
+		{http.MethodGet, "", "?tenantid=43&location=https%3A%2F%2Fexample.com%2F", http.StatusForbidden},
+		{http.MethodGet, "", "?location=https%3A%2F%2Fexample.com%2F", http.StatusForbidden},
+		{http.MethodGet, "", "?tenantid=42", http.StatusBadRequest},
+		{http.MethodPut, "", "?tenantid=42&location=https%3A%2F%2Fexample.com%2F", http.StatusMethodNotAllowed},
+	}
+
+	for _, in := range input {
+		req := httptest.NewRequest(in.method, "/count"+in.query, nil)
+		req.Header.Set("User-Agent", "valid")
+
+		if in.origin != "" {
+			req.Header.Set("Origin", in.origin)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != in.status {
+			t.Fatalf("Status for %v %v %v must be %v, but was: %v", in.method, in.origin, in.query, in.status, rec.Code)
+		}
+	}
+
+	tracker.Stop()
+
+	if len(store.hits) != 2 ||
+		store.hits[0].TenantID.Int64 != 42 || store.hits[0].Path.String != "/foo" ||
+		store.hits[1].TenantID.Int64 != 42 || store.hits[1].Path.String != "/bar" {
+		t.Fatalf("Hits not as expected: %v", store.hits)
+	}
+}
+
+func TestCollectHandlerResponse(t *testing.T) {
+	tracker := NewTracker(newTestStore(), "salt", nil)
+	defer tracker.Stop()
+	req := httptest.NewRequest(http.MethodGet, "/count?location=https%3A%2F%2Fexample.com%2F", nil)
+	req.Header.Set("Origin", "https://example.com")
+	rec := httptest.NewRecorder()
+	CollectHandler(tracker, &CollectConfig{GIF: true}).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK ||
+		rec.Header().Get("Content-Type") != "image/gif" ||
+		rec.Header().Get("Cache-Control") != "no-cache, no-store, must-revalidate" ||
+		rec.Header().Get("Access-Control-Allow-Origin") != "https://example.com" ||
+		!bytes.Equal(rec.Body.Bytes(), transparentGIF) {
+		t.Fatalf("Response not as expected: %v %v", rec.Code, rec.Header())
+	}
+
+	req = httptest.NewRequest(http.MethodOptions, "/count", nil)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	rec = httptest.NewRecorder()
+	CollectHandler(tracker, nil).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent ||
+		rec.Header().Get("Access-Control-Allow-Origin") != "https://example.com" ||
+		rec.Header().Get("Access-Control-Allow-Methods") == "" {
+		t.Fatalf("Preflight response not as expected: %v %v", rec.Code, rec.Header())
+	}
+}
+
+func TestCollectHandlerTenantResolver(t *testing.T) {
+	store := newTestStore()
+	tracker := NewTracker(store, "salt", nil)
+	handler := CollectHandler(tracker, &CollectConfig{
+		TenantResolver: func(tenantID sql.NullInt64, hostname string) bool {
+			return tenantID.Int64 == 1 && hostname == "one.com" || tenantID.Int64 == 2 && hostname == "two.com"
+		},
+	})
+	queries := []string{
+		"?tenantid=1&location=https%3A%2F%2Fone.com%2F",
+		"?tenantid=2&location=https%3A%2F%2Fone.com%2F",
+		"?tenantid=2&location=https%3A%2F%2Ftwo.com%2F",
+	}
+	status := []int{http.StatusNoContent, http.StatusForbidden, http.StatusNoContent}
+
+	for i, query := range queries {
+		req := httptest.NewRequest(http.MethodGet, "/count"+query, nil)
+		req.Header.Set("User-Agent", "valid")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != status[i] {
+			t.Fatalf("Status for %v must be %v, but was: %v", query, status[i], rec.Code)
+		}
+	}
+
+	tracker.Stop()
+
+	if len(store.hits) != 2 {
+		t.Fatalf("Two hits must have been tracked, but was: %v", len(store.hits))
+	}
+}
+
+func TestCollectHandlerToken(t *testing.T) {
+	store := newTestStore()
+	tracker := NewTracker(store, "salt", nil)
+	secret := []byte("secret")
+	handler := CollectHandler(tracker, &CollectConfig{
+		TenantIDs:   []int64{1, 2},
+		TokenSecret: secret,
+	})
+	queries := []string{
+		"?tenantid=1&location=https%3A%2F%2Fone.com%2F&token=" + NewSiteToken(secret, NewTenantID(1), "one.com"),
+		"?tenantid=2&location=https%3A%2F%2Fone.com%2F&token=" + NewSiteToken(secret, NewTenantID(1), "one.com"),
+		"?tenantid=1&location=https%3A%2F%2Ftwo.com%2F&token=" + NewSiteToken(secret, NewTenantID(1), "one.com"),
+		"?tenantid=1&location=https%3A%2F%2Fone.com%2F",
+	}
+	status := []int{http.StatusNoContent, http.StatusForbidden, http.StatusForbidden, http.StatusForbidden}
+
+	for i, query := range queries {
+		req := httptest.NewRequest(http.MethodGet, "/count"+query, nil)
+		req.Header.Set("User-Agent", "valid")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != status[i] {
+			t.Fatalf("Status for %v must be %v, but was: %v", query, status[i], rec.Code)
+		}
+	}
+
+	tracker.Stop()
+
+	if len(store.hits) != 1 {
+		t.Fatalf("One hit must have been tracked, but was: %v", len(store.hits))
+	}
+}
+
+func TestCollectHandlerBatch(t *testing.T) {
+	store := newTestStore()
+	tracker := NewTracker(store, "salt", &TrackerConfig{Worker: 1})
+	handler := CollectHandler(tracker, &CollectConfig{
+		Domains:      []string{"example.com"},
+		TenantIDs:    []int64{42},
+		MaxBatchSize: 3,
+	})
+	body := `{"tenant_id": 42, "hits": [
+		{"location": "https://example.com/foo", "referrer": "https://google.com", "width": 1920, "height": 1080},
+		{"location": "https://evil.com/"},
+		{"location": "https://example.com/bar"}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/count", strings.NewReader(body))
+	req.Header.Set("User-Agent", "valid")
+	req.Header.Set("Content-Type", "text/plain;charset=UTF-8")
+	req.Header.Set("Referer", "https://example.com/sender")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Response not as expected: %v %v", rec.Code, rec.Header())
+	}
+
+	var resp CollectResponse
+
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Response must be valid JSON, but was: %v", err)
+	}
+
+	if resp.Accepted != 2 || len(resp.Rejected) != 1 ||
+		resp.Rejected[0].Index != 1 || resp.Rejected[0].Error != errCollectHostname.Error() {
+		t.Fatalf("Response not as expected: %v", resp)
+	}
+
+	tracker.Stop()
+
+	if len(store.hits) != 2 ||
+		store.hits[0].Path.String != "/foo" || store.hits[0].TenantID.Int64 != 42 ||
+		store.hits[0].Referrer.String != "https://google.com" || store.hits[0].ScreenWidth != 1920 ||
+		store.hits[1].Path.String != "/bar" || store.hits[1].Referrer.Valid {
+		t.Fatalf("Hits not as expected: %v", store.hits)
+	}
+}
+
+func TestCollectHandlerBatchInvalid(t *testing.T) {
+	tracker := NewTracker(newTestStore(), "salt", nil)
+	defer tracker.Stop()
+	handler := CollectHandler(tracker, &CollectConfig{
+		MaxBodySize:  256,
+		MaxBatchSize: 2,
+	})
+	input := []struct {
+		body   string
+		status int
+	}{
+		{"", http.StatusBadRequest},
+		{"{invalid", http.StatusBadRequest},
+		{`{"hits": []}`, http.StatusBadRequest},
+		{`{"hits": [{"location": "https://a.com"}, {"location": "https://b.com"}, {"location": "https://c.com"}]}`, http.StatusRequestEntityTooLarge},
+		{`{"hits": [{"location": "https://example.com/` + strings.Repeat("a", 256) + `"}]}`, http.StatusRequestEntityTooLarge},
+		{`{"hits": [{"location": "https://example.com/"}]}`, http.StatusOK},
+	}
+
+	for _, in := range input {
+		req := httptest.NewRequest(http.MethodPost, "/count", strings.NewReader(in.body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != in.status {
+			t.Fatalf("Status for %v must be %v, but was: %v", in.body, in.status, rec.Code)
+		}
+	}
+}
+
+func TestCollectHandlerBatchEvents(t *testing.T) {
+	store := newTestStore()
+	tracker := NewTracker(store, "salt", &TrackerConfig{Worker: 1})
+	handler := CollectHandler(tracker, nil)
+	body := `{"hits": [{"location": "https://example.com/"}], "events": [
+		{"name": "download", "meta": {"file": "report.pdf"}, "value": 3, "location": "https://example.com/downloads"},
+		{"name": "", "location": "https://example.com/"},
+		{"name": "signup"}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/count", strings.NewReader(body))
+	req.Header.Set("User-Agent", "valid")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var resp CollectResponse
+
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Response must be valid JSON, but was: %v", err)
+	}
+
+	if resp.Accepted != 2 || len(resp.Rejected) != 2 ||
+		resp.Rejected[0].Index != 2 || resp.Rejected[0].Error != errCollectEventName.Error() ||
+		resp.Rejected[1].Index != 3 || resp.Rejected[1].Error != errCollectInvalidLocation.Error() {
+		t.Fatalf("Response not as expected: %v", resp)
+	}
+
+	tracker.Stop()
+
+	if len(store.hits) != 1 || len(store.events) != 1 ||
+		store.events[0].Name != "download" || store.events[0].Path.String != "/downloads" ||
+		store.events[0].Value != 3 || len(store.events[0].MetaKeys) != 1 || store.events[0].MetaValues[0] != "report.pdf" {
+		t.Fatalf("Hits and events not as expected: %v %v", store.hits, store.events)
+	}
+}
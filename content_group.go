@@ -0,0 +1,70 @@
+package pirsch
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"strings"
+)
+
+// ErrUnknownContentGroup is returned by the Analyzer if the Filter references a content group that does not exist.
+var ErrUnknownContentGroup = errors.New("unknown content group")
+
+var (
+	errContentGroupName     = errors.New("content group name missing")
+	errContentGroupPatterns = errors.New("content group requires at least one pattern")
+)
+
+// ContentGroup is a named group of pages, like "Docs" for /docs/**.
+// Each pattern is a path pattern (glob or regular expression) like the MiddlewareRules.
+// A page belongs to the group if it matches any of the patterns. Pages can belong to multiple groups.
+type ContentGroup struct {
+	BaseEntity
+
+	Name     string         `db:"name" json:"name"`
+	Patterns pq.StringArray `db:"patterns" json:"patterns"`
+}
+
+// NewContentGroup returns a new ContentGroup for given tenant, name, and patterns.
+func NewContentGroup(tenantID sql.NullInt64, name string, patterns ...string) *ContentGroup {
+	return &ContentGroup{
+		BaseEntity: BaseEntity{TenantID: tenantID},
+		Name:       name,
+		Patterns:   patterns,
+	}
+}
+
+// Validate trims the content group and checks that it has a name and valid patterns.
+func (group *ContentGroup) Validate() error {
+	group.Name = strings.TrimSpace(group.Name)
+
+	if group.Name == "" {
+		return errContentGroupName
+	}
+
+	if len(group.Patterns) == 0 {
+		return errContentGroupPatterns
+	}
+
+	for i := range group.Patterns {
+		group.Patterns[i] = strings.TrimSpace(group.Patterns[i])
+
+		if group.Patterns[i] == "" {
+			return errContentGroupPatterns
+		}
+	}
+
+	_, err := newPathPatterns(group.Patterns)
+	return err
+}
+
+// pathRegex returns the regular expression matching any of the patterns, to be used in a case-insensitive query.
+func (group *ContentGroup) pathRegex() string {
+	regex := make([]string, len(group.Patterns))
+
+	for i, pattern := range group.Patterns {
+		regex[i] = "(?:" + pathPatternToRegex(pattern) + ")"
+	}
+
+	return strings.Join(regex, "|")
+}
@@ -0,0 +1,41 @@
+package pirsch
+
+import (
+	"testing"
+)
+
+func TestContentGroupValidate(t *testing.T) {
+	group := NewContentGroup(NullTenant, " Docs ", " /docs/** ", "/guide")
+
+	if err := group.Validate(); err != nil {
+		t.Fatalf("Content group must be valid, but was: %v", err)
+	}
+
+	if group.Name != "Docs" || group.Patterns[0] != "/docs/**" {
+		t.Fatalf("Content group must have been trimmed, but was: %v", group)
+	}
+
+	if err := NewContentGroup(NullTenant, "", "/docs/**").Validate(); err != errContentGroupName {
+		t.Fatalf("Content group without name must be invalid, but was: %v", err)
+	}
+
+	if err := NewContentGroup(NullTenant, "Docs").Validate(); err != errContentGroupPatterns {
+		t.Fatalf("Content group without patterns must be invalid, but was: %v", err)
+	}
+
+	if err := NewContentGroup(NullTenant, "Docs", "/docs/**", " ").Validate(); err != errContentGroupPatterns {
+		t.Fatalf("Content group with empty pattern must be invalid, but was: %v", err)
+	}
+
+	if err := NewContentGroup(NullTenant, "Docs", "^/docs(").Validate(); err == nil {
+		t.Fatal("Content group with invalid pattern must be invalid")
+	}
+}
+
+func TestContentGroupPathRegex(t *testing.T) {
+	group := NewContentGroup(NullTenant, "Docs", "/docs/**", "/guide")
+
+	if regex := group.pathRegex(); regex != "(?:^/docs/.*$)|(?:^/guide$)" {
+		t.Fatalf("Regular expression not as expected: %v", regex)
+	}
+}
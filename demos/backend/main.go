@@ -27,19 +27,18 @@ func main() {
 		}
 	})
 
-	// Create a handler to serve traffic.
-	// We prevent tracking resources by checking the path. So a file on /my-file.txt won't create a new hit
-	// but all page calls will be tracked.
-	http.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/" {
-			go tracker.Hit(r, nil)
-		}
-
+	// Create a handler to serve traffic and wrap it in the tracking middleware.
+	// The middleware only tracks successful page calls returning HTML, so a file on /my-file.txt won't create a new hit.
+	// You can further narrow down what is tracked by passing include and exclude path patterns.
+	trackingMiddleware := pirsch.Middleware(tracker, &pirsch.MiddlewareRules{
+		Exclude: []string{"/admin/**"},
+	})
+	http.Handle("/", trackingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("<h1>Hello World!</h1>"))
-	}))
+	})))
 
 	// And finally, start the server.
-	// We don't flush hits on shutdown but you should add that in a real application by calling Tracker.Flush().
+	// We don't save the queued hits on shutdown but you should add that in a real application by calling Tracker.Stop().
 	log.Println("Starting server on port 8080...")
 	http.ListenAndServe(":8080", nil)
 }
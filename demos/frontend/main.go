@@ -17,13 +17,10 @@ func main() {
 	tracker := pirsch.NewTracker(store, "salt", nil)
 
 	// Create an endpoint to handle client tracking requests.
-	// HitOptionsFromRequest is a utility function to process the required parameters.
-	// You might want to additional checks, like for the tenant ID.
-	http.Handle("/count", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// We don't need to call Hit in a new goroutine, as this is the only call in the handler
-		// (running in its own goroutine already).
-		tracker.Hit(r, pirsch.HitOptionsFromRequest(r))
-		log.Println("Counted one hit")
+	// The CollectHandler reads the parameters sent by pirsch.js and validates the domain and tenant ID.
+	// Hits without tenant ID are accepted for all domains by default.
+	http.Handle("/count", pirsch.CollectHandler(tracker, &pirsch.CollectConfig{
+		Domains: []string{"localhost"},
 	}))
 
 	// Add a handler to serve index.html and pirsch.js.
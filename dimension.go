@@ -0,0 +1,233 @@
+package pirsch
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Names of the built-in dimensions.
+const (
+	DimensionLanguage = "language"
+	DimensionReferrer = "referrer"
+	DimensionOS       = "os"
+	DimensionBrowser  = "browser"
+	DimensionScreen   = "screen"
+	DimensionCountry  = "country"
+
+	// DimensionContentGroup breaks down the visitors by the ContentGroups of the tenant.
+	// It cannot be configured, as the groups are stored per tenant.
+	DimensionContentGroup = "content_group"
+)
+
+// ErrUnknownDimension is returned by Analyzer.Breakdown for dimensions that are neither built-in nor configured.
+var ErrUnknownDimension = errors.New("unknown dimension")
+
+var (
+	errDimensionName   = errors.New("dimension name missing")
+	errDimensionColumn = errors.New("dimension column must only contain lowercase letters and underscores")
+	errDimensionExists = errors.New("dimension already exists")
+)
+
+var dimensionColumnRegex = regexp.MustCompile(`^[a-z_]+$`)
+
+// Dimension is an attribute of hits visitors can be broken down by, like the language or browser.
+// Custom dimensions are passed to the Processor and Analyzer using the ProcessorConfig and AnalyzerConfig.
+type Dimension struct {
+	// Name is the unique name of the dimension, which is passed to Analyzer.Breakdown.
+	Name string
+
+	// Column is the name of the column on the "hit" table the visitors are grouped by, like "utm_source".
+	// It must only contain lowercase letters and underscores and must exist on the "hit_archive" table as well.
+	Column string
+
+	// hitColumn is the SQL expression on the "hit" table of the built-in dimensions.
+	// The Column is used for custom dimensions.
+	hitColumn string
+
+	// table and statsColumn are the statistics table and SQL expression the built-in dimensions are read from.
+	// Custom dimensions are processed into the "dimension_stats" table instead.
+	table       string
+	statsColumn string
+}
+
+// Validate trims the dimension and checks that it has a name and a valid column.
+// The name must not be used by a built-in dimension.
+func (dimension *Dimension) Validate() error {
+	dimension.Name = strings.TrimSpace(dimension.Name)
+	dimension.Column = strings.TrimSpace(dimension.Column)
+
+	if dimension.Name == "" {
+		return errDimensionName
+	}
+
+	if !dimensionColumnRegex.MatchString(dimension.Column) {
+		return errDimensionColumn
+	}
+
+	if _, found := builtinDimensions[dimension.Name]; found || dimension.Name == DimensionContentGroup {
+		return errDimensionExists
+	}
+
+	return nil
+}
+
+// column returns the SQL expression on the "hit" table the visitors are grouped by.
+func (dimension *Dimension) column() (string, error) {
+	if dimension.hitColumn != "" {
+		return dimension.hitColumn, nil
+	}
+
+	if !dimensionColumnRegex.MatchString(dimension.Column) {
+		return "", errDimensionColumn
+	}
+
+	return fmt.Sprintf(`"%s"`, dimension.Column), nil
+}
+
+var builtinDimensions = map[string]Dimension{
+	DimensionLanguage: {
+		Name:        DimensionLanguage,
+		Column:      "language",
+		table:       "language_stats",
+		statsColumn: `"language"`,
+	},
+	DimensionReferrer: {
+		Name:        DimensionReferrer,
+		Column:      "referrer",
+		table:       "referrer_stats",
+		statsColumn: `"referrer"`,
+	},
+	DimensionOS: {
+		Name:        DimensionOS,
+		Column:      "os",
+		table:       "os_stats",
+		statsColumn: `"os"`,
+	},
+	DimensionBrowser: {
+		Name:        DimensionBrowser,
+		Column:      "browser",
+		table:       "browser_stats",
+		statsColumn: `"browser"`,
+	},
+	DimensionScreen: {
+		Name:        DimensionScreen,
+		hitColumn:   `"screen_width" || 'x' || "screen_height"`,
+		table:       "screen_stats",
+		statsColumn: `"width" || 'x' || "height"`,
+	},
+	DimensionCountry: {
+		Name:        DimensionCountry,
+		Column:      "country_code",
+		table:       "country_stats",
+		statsColumn: `"country_code"`,
+	},
+}
+
+// newDimensions validates given custom dimensions and returns them together with the built-in dimensions by name.
+func newDimensions(custom []Dimension) (map[string]Dimension, error) {
+	dimensions := make(map[string]Dimension, len(builtinDimensions)+len(custom))
+
+	for name, dimension := range builtinDimensions {
+		dimensions[name] = dimension
+	}
+
+	for _, dimension := range custom {
+		if err := dimension.Validate(); err != nil {
+			return nil, err
+		}
+
+		if _, found := dimensions[dimension.Name]; found {
+			return nil, errDimensionExists
+		}
+
+		dimensions[dimension.Name] = Dimension{Name: dimension.Name, Column: dimension.Column}
+	}
+
+	return dimensions, nil
+}
+
+// customDimensions returns the dimensions which are processed into the "dimension_stats" table.
+func customDimensions(dimensions map[string]Dimension) []Dimension {
+	custom := make([]Dimension, 0)
+
+	for _, dimension := range dimensions {
+		if dimension.table == "" {
+			custom = append(custom, dimension)
+		}
+	}
+
+	sort.Slice(custom, func(i, j int) bool {
+		return custom[i].Name < custom[j].Name
+	})
+	return custom
+}
+
+func languageStats(stats []DimensionStats) []LanguageStats {
+	result := make([]LanguageStats, len(stats))
+
+	for i := range stats {
+		result[i] = LanguageStats{Stats: stats[i].Stats, Language: stats[i].Value}
+	}
+
+	return result
+}
+
+func referrerStats(stats []DimensionStats) []ReferrerStats {
+	result := make([]ReferrerStats, len(stats))
+
+	for i := range stats {
+		result[i] = ReferrerStats{Stats: stats[i].Stats, Referrer: stats[i].Value}
+	}
+
+	return result
+}
+
+func osStats(stats []DimensionStats) []OSStats {
+	result := make([]OSStats, len(stats))
+
+	for i := range stats {
+		result[i] = OSStats{Stats: stats[i].Stats, OS: stats[i].Value}
+	}
+
+	return result
+}
+
+func browserStats(stats []DimensionStats) []BrowserStats {
+	result := make([]BrowserStats, len(stats))
+
+	for i := range stats {
+		result[i] = BrowserStats{Stats: stats[i].Stats, Browser: stats[i].Value}
+	}
+
+	return result
+}
+
+func countryStats(stats []DimensionStats) []CountryStats {
+	result := make([]CountryStats, len(stats))
+
+	for i := range stats {
+		result[i] = CountryStats{Stats: stats[i].Stats, CountryCode: stats[i].Value}
+	}
+
+	return result
+}
+
+func screenStats(stats []DimensionStats) ([]ScreenStats, error) {
+	result := make([]ScreenStats, len(stats))
+
+	for i := range stats {
+		result[i].Stats = stats[i].Stats
+
+		// the value is formatted as "<width>x<height>"
+		if stats[i].Value.Valid {
+			if _, err := fmt.Sscanf(stats[i].Value.String, "%dx%d", &result[i].Width, &result[i].Height); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,87 @@
+package pirsch
+
+import (
+	"testing"
+)
+
+func TestDimension_Validate(t *testing.T) {
+	input := []Dimension{
+		{Column: "utm_campaign"},
+		{Name: "utm_campaign", Column: " "},
+		{Name: "utm_campaign", Column: `"utm_campaign"`},
+		{Name: "utm_campaign", Column: "utm_campaign; DROP TABLE hit"},
+		{Name: DimensionLanguage, Column: "language"},
+		{Name: DimensionContentGroup, Column: "path"},
+	}
+	expected := []error{errDimensionName, errDimensionColumn, errDimensionColumn, errDimensionColumn, errDimensionExists, errDimensionExists}
+
+	for i, dimension := range input {
+		if err := dimension.Validate(); err != expected[i] {
+			t.Fatalf("Expected error %v for %v, but was: %v", expected[i], dimension, err)
+		}
+	}
+
+	dimension := Dimension{Name: " utm_campaign ", Column: " utm_campaign "}
+
+	if err := dimension.Validate(); err != nil || dimension.Name != "utm_campaign" || dimension.Column != "utm_campaign" {
+		t.Fatalf("Dimension must be valid, but was: %v %v", dimension, err)
+	}
+}
+
+func TestNewDimensions(t *testing.T) {
+	dimensions, err := newDimensions([]Dimension{{Name: "utm_campaign", Column: "utm_campaign"}, {Name: "utm_source", Column: "utm_source"}})
+
+	if err != nil {
+		t.Fatalf("Dimensions must have been created, but was: %v", err)
+	}
+
+	if len(dimensions) != len(builtinDimensions)+2 || dimensions["utm_campaign"].Column != "utm_campaign" || dimensions[DimensionLanguage].table == "" {
+		t.Fatalf("Dimensions not as expected: %v", dimensions)
+	}
+
+	custom := customDimensions(dimensions)
+
+	if len(custom) != 2 || custom[0].Name != "utm_campaign" || custom[1].Name != "utm_source" {
+		t.Fatalf("Only the configured dimensions must be custom, but was: %v", custom)
+	}
+
+	if _, err := newDimensions([]Dimension{{Name: "utm_campaign", Column: "utm_campaign"}, {Name: "utm_campaign", Column: "utm_source"}}); err != errDimensionExists {
+		t.Fatalf("Dimension must not be configured twice, but was: %v", err)
+	}
+
+	if len(customDimensions(builtinDimensions)) != 0 {
+		t.Fatal("Built-in dimensions must not be custom")
+	}
+}
+
+func TestDimension_column(t *testing.T) {
+	dimension := builtinDimensions[DimensionScreen]
+	column, err := dimension.column()
+
+	if err != nil || column != `"screen_width" || 'x' || "screen_height"` {
+		t.Fatalf("Built-in column not as expected: %v %v", column, err)
+	}
+
+	dimension = Dimension{Name: "utm_source", Column: "utm_source"}
+	column, err = dimension.column()
+
+	if err != nil || column != `"utm_source"` {
+		t.Fatalf("Custom column must be quoted, but was: %v %v", column, err)
+	}
+
+	dimension = Dimension{Name: "utm_source", Column: `utm_source" || "path`}
+
+	if _, err := dimension.column(); err != errDimensionColumn {
+		t.Fatalf("Invalid column must be rejected, but was: %v", err)
+	}
+}
+
+func TestNewAnalyzerInvalidDimension(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("NewAnalyzer must panic for invalid dimensions")
+		}
+	}()
+
+	NewAnalyzer(nil, &AnalyzerConfig{Dimensions: []Dimension{{Name: "utm_source", Column: "utm-source"}}})
+}
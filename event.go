@@ -0,0 +1,176 @@
+package pirsch
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/lib/pq"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	maxEventMetadata = 20
+)
+
+// Event is a custom event triggered by a visitor, like a signup, download, or playing a video.
+// It's tracked for the page the visitor is on and belongs to the visitor and session of the page visit.
+type Event struct {
+	BaseEntity
+
+	Fingerprint string         `db:"fingerprint" json:"fingerprint"`
+	Session     sql.NullTime   `db:"session" json:"session"`
+	Path        sql.NullString `db:"path" json:"path,omitempty"`
+	Name        string         `db:"name" json:"name"`
+	MetaKeys    pq.StringArray `db:"meta_keys" json:"meta_keys,omitempty"`
+	MetaValues  pq.StringArray `db:"meta_values" json:"meta_values,omitempty"`
+	Value       float64        `db:"value" json:"value"`
+	Time        time.Time      `db:"time" json:"time"`
+}
+
+// String implements the Stringer interface.
+func (event Event) String() string {
+	out, _ := json.Marshal(event)
+	return string(out)
+}
+
+// EventOptions are the details of an event passed to the Tracker.
+type EventOptions struct {
+	// Name is the name of the event, like "signup" or "download". It's required.
+	Name string
+
+	// Meta is optional metadata stored with the event, like the name of the file downloaded.
+	// Up to 20 keys are stored, sorted by key.
+	Meta map[string]string
+
+	// Value is an optional numeric value, like the price of a purchase or the duration a video was played.
+	Value float64
+}
+
+// newEvent returns a new Event for given hit the event has been triggered on.
+// It returns false if the event has no name.
+func newEvent(hit Hit, options *EventOptions) (Event, bool) {
+	name := shortenString(strings.TrimSpace(options.Name), 200)
+
+	if name == "" {
+		return Event{}, false
+	}
+
+	keys := make([]string, 0, len(options.Meta))
+
+	for key := range options.Meta {
+		if strings.TrimSpace(key) != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	if len(keys) > maxEventMetadata {
+		keys = keys[:maxEventMetadata]
+	}
+
+	values := make([]string, 0, len(keys))
+
+	for i, key := range keys {
+		values = append(values, shortenString(options.Meta[key], 200))
+		keys[i] = shortenString(key, 200)
+	}
+
+	return Event{
+		BaseEntity:  hit.BaseEntity,
+		Fingerprint: hit.Fingerprint,
+		Session:     hit.Session,
+		Path:        hit.Path,
+		Name:        name,
+		MetaKeys:    keys,
+		MetaValues:  values,
+		Value:       options.Value,
+		Time:        hit.Time,
+	}, true
+}
+
+// countEvents returns the visitor and event count and the sum of the values per event for given events.
+// Only events triggered on pages matching the path and path pattern of the filter are counted. Visitors are counted once per event and tenant.
+func countEvents(filter *Filter, events []Event) []EventStats {
+	type visitor struct {
+		tenantID    sql.NullInt64
+		fingerprint string
+	}
+	stats := make(map[string]*EventStats)
+	visitors := make(map[string]map[visitor]bool)
+	names := make([]string, 0)
+
+	for _, event := range events {
+		if !filter.matchesPath(event.Path.String) {
+			continue
+		}
+
+		if stats[event.Name] == nil {
+			stats[event.Name] = &EventStats{Name: event.Name}
+			visitors[event.Name] = make(map[visitor]bool)
+			names = append(names, event.Name)
+		}
+
+		stats[event.Name].Events++
+		stats[event.Name].Value += event.Value
+		visitors[event.Name][visitor{event.TenantID, event.Fingerprint}] = true
+	}
+
+	result := make([]EventStats, 0, len(names))
+
+	for _, name := range names {
+		stats[name].Visitors = len(visitors[name])
+		result = append(result, *stats[name])
+	}
+
+	return result
+}
+
+// countEventMetadata returns the visitor and event count per metadata key and value for given events with given name.
+// Only events triggered on pages matching the path and path pattern of the filter are counted. Visitors are counted once per key, value, and tenant.
+func countEventMetadata(filter *Filter, events []Event, name string) []EventMetadataStats {
+	type metadata struct {
+		key   string
+		value string
+	}
+	type visitor struct {
+		tenantID    sql.NullInt64
+		fingerprint string
+	}
+	stats := make(map[metadata]*EventMetadataStats)
+	visitors := make(map[metadata]map[visitor]bool)
+	keys := make([]metadata, 0)
+
+	for _, event := range events {
+		if event.Name != name || !filter.matchesPath(event.Path.String) {
+			continue
+		}
+
+		for i, key := range event.MetaKeys {
+			m := metadata{key: key}
+
+			if i < len(event.MetaValues) {
+				m.value = event.MetaValues[i]
+			}
+
+			if stats[m] == nil {
+				stats[m] = &EventMetadataStats{Name: name, MetaKey: m.key, MetaValue: m.value}
+				visitors[m] = make(map[visitor]bool)
+				keys = append(keys, m)
+			}
+
+			stats[m].Events++
+			visitors[m][visitor{event.TenantID, event.Fingerprint}] = true
+		}
+	}
+
+	result := make([]EventMetadataStats, 0, len(keys))
+
+	for _, m := range keys {
+		stats[m].Visitors = len(visitors[m])
+		result = append(result, *stats[m])
+	}
+
+	return result
+}
@@ -0,0 +1,83 @@
+package pirsch
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewEvent(t *testing.T) {
+	hit := Hit{
+		BaseEntity:  BaseEntity{TenantID: NewTenantID(42)},
+		Fingerprint: "fp",
+		Session:     sql.NullTime{Time: time.Now(), Valid: true},
+		Path:        sql.NullString{String: "/path", Valid: true},
+		Time:        time.Now(),
+	}
+	meta := map[string]string{"b": "2", "a": "1", " ": "empty"}
+
+	for i := 0; i < 30; i++ {
+		meta[fmt.Sprintf("key%02d", i)] = strings.Repeat("v", 300)
+	}
+
+	event, ok := newEvent(hit, &EventOptions{
+		Name:  " signup ",
+		Meta:  meta,
+		Value: 9.99,
+	})
+
+	if !ok {
+		t.Fatal("Event must have been created")
+	}
+
+	if event.TenantID.Int64 != 42 ||
+		event.Fingerprint != "fp" ||
+		!event.Session.Valid ||
+		event.Path.String != "/path" ||
+		!event.Time.Equal(hit.Time) ||
+		event.Name != "signup" ||
+		event.Value != 9.99 {
+		t.Fatalf("Event not as expected: %v", event)
+	}
+
+	if len(event.MetaKeys) != maxEventMetadata || len(event.MetaValues) != maxEventMetadata ||
+		event.MetaKeys[0] != "a" || event.MetaValues[0] != "1" ||
+		event.MetaKeys[1] != "b" || event.MetaValues[1] != "2" ||
+		event.MetaKeys[2] != "key00" || len(event.MetaValues[2]) != 200 {
+		t.Fatalf("Metadata not as expected: %v %v", event.MetaKeys, event.MetaValues)
+	}
+
+	if _, ok := newEvent(hit, &EventOptions{Name: " "}); ok {
+		t.Fatal("Event without name must be ignored")
+	}
+}
+
+func TestCountEvents(t *testing.T) {
+	events := []Event{
+		{Fingerprint: "fp1", Path: sql.NullString{String: "/", Valid: true}, Name: "signup", MetaKeys: []string{"plan"}, MetaValues: []string{"pro"}, Value: 10},
+		{Fingerprint: "fp1", Path: sql.NullString{String: "/Pricing", Valid: true}, Name: "signup", MetaKeys: []string{"plan"}, MetaValues: []string{"free"}, Value: 5},
+		{Fingerprint: "fp2", Path: sql.NullString{String: "/pricing", Valid: true}, Name: "signup", MetaKeys: []string{"plan"}, MetaValues: []string{"free"}},
+		{Fingerprint: "fp2", Path: sql.NullString{String: "/pricing", Valid: true}, Name: "download"},
+	}
+	stats := countEvents(&Filter{}, events)
+
+	if len(stats) != 2 || stats[0].Name != "signup" || stats[0].Visitors != 2 || stats[0].Events != 3 || stats[0].Value != 15 ||
+		stats[1].Name != "download" || stats[1].Visitors != 1 || stats[1].Events != 1 {
+		t.Fatalf("Event statistics not as expected: %v", stats)
+	}
+
+	stats = countEvents(&Filter{Path: "/pricing"}, events)
+
+	if len(stats) != 2 || stats[0].Visitors != 2 || stats[0].Events != 2 || stats[0].Value != 5 {
+		t.Fatalf("Event statistics not as expected: %v", stats)
+	}
+
+	metadata := countEventMetadata(&Filter{}, events, "signup")
+
+	if len(metadata) != 2 || metadata[0].MetaValue != "pro" || metadata[0].Visitors != 1 ||
+		metadata[1].MetaKey != "plan" || metadata[1].MetaValue != "free" || metadata[1].Visitors != 2 || metadata[1].Events != 2 {
+		t.Fatalf("Event metadata not as expected: %v", metadata)
+	}
+}
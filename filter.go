@@ -6,7 +6,24 @@ import (
 	"time"
 )
 
-// Filter is used to specify the time frame, path and tenant for the Analyzer.
+// Platforms to filter for, see Filter.Platform.
+const (
+	PlatformDesktop = "desktop"
+	PlatformMobile  = "mobile"
+	PlatformUnknown = "unknown"
+)
+
+// Intervals to group time series by, see Filter.Interval.
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+	IntervalYear  = "year"
+)
+
+// Filter is used to specify the time frame, path, tenant, and visitor attributes for the Analyzer.
+// Filtering by visitor attributes, a path pattern, or a content group requires the archive
+// (see AnalyzerConfig.ArchiveRetention) for days that have been processed.
 type Filter struct {
 	// TenantID is the optional tenant ID used to filter results.
 	TenantID sql.NullInt64
@@ -14,11 +31,54 @@ type Filter struct {
 	// Path is the optional path for the selection.
 	Path string
 
+	// PathPattern is an optional path pattern (glob or regular expression) like the MiddlewareRules, like /blog/*.
+	// Unlike Path, it filters all statistics by the pages matching it.
+	PathPattern string
+
+	// ContentGroup is the optional name of a ContentGroup to filter for.
+	// Like PathPattern, it filters all statistics by the pages belonging to the group.
+	ContentGroup string
+
 	// From is the start of the selection.
 	From time.Time
 
 	// To is the end of the selection.
 	To time.Time
+
+	// Referrer is the optional referrer to filter for.
+	Referrer string
+
+	// Country is the optional country code to filter for, like "de".
+	Country string
+
+	// Language is the optional language code to filter for, like "en".
+	Language string
+
+	// OS is the optional operating system to filter for, like OSWindows.
+	OS string
+
+	// Browser is the optional browser to filter for, like BrowserFirefox.
+	Browser string
+
+	// Platform is the optional platform to filter for (PlatformDesktop, PlatformMobile, or PlatformUnknown).
+	// Other values are ignored.
+	Platform string
+
+	// ScreenWidth and ScreenHeight are the optional screen size to filter for.
+	ScreenWidth  int
+	ScreenHeight int
+
+	// Interval is the optional interval time series are grouped by (IntervalDay, IntervalWeek, IntervalMonth, or IntervalYear).
+	// Weeks start on Monday (ISO 8601). Other values are ignored and the statistics are returned per day.
+	// Weeks, months, and years are made of the days in the Analyzer time zone and count each visitor once per interval.
+	// They are counted from the hits of the time frame, so ErrNotArchived is returned for days that have not been archived.
+	Interval string
+
+	// paths are the pages matching the PathPattern and ContentGroup, as resolved by the Analyzer.
+	paths []string
+
+	// timezone is the time zone of the Analyzer, in which the days of an interval are bucketed.
+	timezone *time.Location
 }
 
 // NewFilter returns a new default filter for given tenant and the past week.
@@ -38,6 +98,15 @@ func (filter *Filter) Days() int {
 
 func (filter *Filter) validate() {
 	filter.Path = strings.TrimSpace(filter.Path)
+	filter.PathPattern = strings.TrimSpace(filter.PathPattern)
+	filter.ContentGroup = strings.TrimSpace(filter.ContentGroup)
+	filter.Referrer = strings.TrimSpace(filter.Referrer)
+	filter.Country = strings.ToLower(strings.TrimSpace(filter.Country))
+	filter.Language = strings.ToLower(strings.TrimSpace(filter.Language))
+	filter.OS = strings.TrimSpace(filter.OS)
+	filter.Browser = strings.TrimSpace(filter.Browser)
+	filter.Platform = strings.ToLower(strings.TrimSpace(filter.Platform))
+	filter.Interval = strings.ToLower(strings.TrimSpace(filter.Interval))
 	today := today()
 
 	if filter.From.IsZero() && filter.To.IsZero() {
@@ -55,4 +124,126 @@ func (filter *Filter) validate() {
 	if filter.To.After(today) {
 		filter.To = today
 	}
+
+	if filter.Platform != PlatformDesktop && filter.Platform != PlatformMobile && filter.Platform != PlatformUnknown {
+		filter.Platform = ""
+	}
+
+	if !isInterval(filter.Interval) {
+		filter.Interval = ""
+	}
+
+	if filter.ScreenWidth < 0 {
+		filter.ScreenWidth = 0
+	}
+
+	if filter.ScreenHeight < 0 {
+		filter.ScreenHeight = 0
+	}
+}
+
+// needsFilterStats returns true if the filter contains at least one visitor attribute, a path pattern, or a content group.
+// Statistics for these filters are counted from the hits of the matching sessions (see Store.FilterStats).
+func (filter *Filter) needsFilterStats() bool {
+	return filter.hasPathPattern() ||
+		filter.Referrer != "" ||
+		filter.Country != "" ||
+		filter.Language != "" ||
+		filter.OS != "" ||
+		filter.Browser != "" ||
+		filter.Platform != "" ||
+		filter.ScreenWidth != 0 ||
+		filter.ScreenHeight != 0
+}
+
+// hasPathPattern returns true if the filter contains a path pattern or content group.
+// The matching pages must have been resolved by the Analyzer before querying statistics.
+func (filter *Filter) hasPathPattern() bool {
+	return filter.PathPattern != "" || filter.ContentGroup != ""
+}
+
+// matchesPaths returns true if given path is one of the pages resolved for the path pattern and content group.
+func (filter *Filter) matchesPaths(path string) bool {
+	for _, p := range filter.paths {
+		if strings.EqualFold(p, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// groupsByInterval returns true if the filter groups time series by week, month, or year.
+// These are counted from the hits of the matching sessions, like the statistics for visitor attributes (see Store.FilterStats).
+func (filter *Filter) groupsByInterval() bool {
+	return filter.Interval == IntervalWeek || filter.Interval == IntervalMonth || filter.Interval == IntervalYear
+}
+
+// location returns the time zone the days of the filter are bucketed in, which is UTC unless the filter groups by interval.
+func (filter *Filter) location() *time.Location {
+	if filter.timezone == nil || !filter.groupsByInterval() {
+		return time.UTC
+	}
+
+	return filter.timezone
+}
+
+// timeRange returns the start of the first day and the end (exclusive) of the last day of the filter in UTC.
+// The days are those in the time zone of the filter, so that intervals are made of whole days.
+func (filter *Filter) timeRange() (time.Time, time.Time) {
+	location := filter.location()
+	from := time.Date(filter.From.Year(), filter.From.Month(), filter.From.Day(), 0, 0, 0, 0, location)
+	to := time.Date(filter.To.Year(), filter.To.Month(), filter.To.Day()+1, 0, 0, 0, 0, location)
+	return from.UTC(), to.UTC()
+}
+
+// intervalStart returns the first day of the interval given day belongs to.
+// The interval is calculated from the calendar date, which is the day in the time zone of the filter for statistics grouped by interval.
+func (filter *Filter) intervalStart(day time.Time) time.Time {
+	y, m, d := day.Date()
+
+	switch filter.Interval {
+	case IntervalWeek:
+		return time.Date(y, m, d-(int(day.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+	case IntervalMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case IntervalYear:
+		return time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// intervalEnd returns the last day of the interval given day belongs to.
+func (filter *Filter) intervalEnd(day time.Time) time.Time {
+	start := filter.intervalStart(day)
+
+	switch filter.Interval {
+	case IntervalWeek:
+		return start.AddDate(0, 0, 6)
+	case IntervalMonth:
+		return start.AddDate(0, 1, -1)
+	case IntervalYear:
+		return start.AddDate(1, 0, -1)
+	}
+
+	return start
+}
+
+// withoutPath returns a copy of the filter without path, for statistics that are not filtered by path.
+func (filter *Filter) withoutPath() *Filter {
+	f := *filter
+	f.Path = ""
+	return &f
+}
+
+// matchesPath returns true if given path matches the path and path pattern of the filter.
+// Like the Store, paths are compared case-insensitively.
+func (filter *Filter) matchesPath(path string) bool {
+	return (filter.Path == "" || strings.EqualFold(filter.Path, path)) &&
+		(!filter.hasPathPattern() || filter.matchesPaths(path))
+}
+
+func isInterval(interval string) bool {
+	return interval == IntervalDay || interval == IntervalWeek || interval == IntervalMonth || interval == IntervalYear
 }
@@ -0,0 +1,314 @@
+package pirsch
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	filterDateFormat = "2006-01-02"
+)
+
+var (
+	errFilterTokenKey      = errors.New("unknown key")
+	errFilterTokenValue    = errors.New("value missing")
+	errFilterTokenQuote    = errors.New("invalid quoted value")
+	errFilterTokenDup      = errors.New("key set more than once")
+	errFilterTokenDate     = errors.New("date must be formatted as YYYY-MM-DD")
+	errFilterTokenTenant   = errors.New("tenant must be a positive number")
+	errFilterTokenPlatform = errors.New("platform must be desktop, mobile, or unknown")
+	errFilterTokenScreen   = errors.New("screen must be formatted as WIDTHxHEIGHT")
+	errFilterTokenInterval = errors.New("interval must be day, week, month, or year")
+)
+
+// FilterSyntaxError is returned by ParseFilter for invalid tokens of a filter expression.
+type FilterSyntaxError struct {
+	// Token is the invalid token, like "country:".
+	Token string
+
+	// Offset is the byte offset of the token in the expression.
+	Offset int
+
+	// Err is the reason the token is invalid.
+	Err error
+}
+
+// Error implements the error interface.
+func (err *FilterSyntaxError) Error() string {
+	return fmt.Sprintf("invalid filter token %q at offset %d: %s", err.Token, err.Offset, err.Err)
+}
+
+// Unwrap returns the reason the token is invalid.
+func (err *FilterSyntaxError) Unwrap() error {
+	return err.Err
+}
+
+// filterToken is a key:value pair of a filter expression.
+type filterToken struct {
+	token  string
+	offset int
+	key    string
+	value  string
+}
+
+// ParseFilter parses a filter expression like `country:de browser:Firefox path:/blog/* from:2020-10-01 to:2020-10-31`.
+// The expression is a list of key:value pairs separated by whitespace. Values containing whitespace must be quoted, like os:"Windows Mobile".
+// Supported keys are tenant, from, to, path, pattern, group, referrer, country, language, os, browser, platform, screen (like 1920x1080), and interval.
+// Paths containing a wildcard or starting with ^ are used as Filter.PathPattern, while pattern always sets the path pattern.
+// A *FilterSyntaxError is returned for the first invalid token. The filter is not validated, which is done by the Analyzer.
+func ParseFilter(expression string) (*Filter, error) {
+	tokens, err := tokenizeFilter(expression)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter := new(Filter)
+	set := make(map[string]bool)
+
+	for _, t := range tokens {
+		if err := parseFilterToken(filter, set, t); err != nil {
+			return nil, &FilterSyntaxError{Token: t.token, Offset: t.offset, Err: err}
+		}
+	}
+
+	return filter, nil
+}
+
+// String returns the filter as an expression that can be parsed by ParseFilter, like `country:de browser:Firefox`.
+// Fields that are not set are left out.
+func (filter *Filter) String() string {
+	var expression []string
+	add := func(key, value string) {
+		if value != "" {
+			expression = append(expression, key+":"+quoteFilterValue(value))
+		}
+	}
+
+	if filter.TenantID.Valid {
+		add("tenant", strconv.FormatInt(filter.TenantID.Int64, 10))
+	}
+
+	if !filter.From.IsZero() {
+		add("from", filter.From.Format(filterDateFormat))
+	}
+
+	if !filter.To.IsZero() {
+		add("to", filter.To.Format(filterDateFormat))
+	}
+
+	add("path", filter.Path)
+
+	if isPathPattern(filter.PathPattern) {
+		add("path", filter.PathPattern)
+	} else {
+		add("pattern", filter.PathPattern)
+	}
+
+	add("group", filter.ContentGroup)
+	add("referrer", filter.Referrer)
+	add("country", filter.Country)
+	add("language", filter.Language)
+	add("os", filter.OS)
+	add("browser", filter.Browser)
+	add("platform", filter.Platform)
+	add("interval", filter.Interval)
+
+	if filter.ScreenWidth != 0 || filter.ScreenHeight != 0 {
+		add("screen", fmt.Sprintf("%dx%d", filter.ScreenWidth, filter.ScreenHeight))
+	}
+
+	return strings.Join(expression, " ")
+}
+
+// tokenizeFilter splits given expression into key:value pairs.
+// Tokens are separated by whitespace outside of quotes.
+func tokenizeFilter(expression string) ([]filterToken, error) {
+	tokens := make([]filterToken, 0)
+	start := -1
+	quoted, escaped := false, false
+
+	for i, r := range expression + " " {
+		if start == -1 {
+			if !unicode.IsSpace(r) {
+				start = i
+			} else {
+				continue
+			}
+		}
+
+		if quoted {
+			if escaped {
+				escaped = false
+			} else if r == '\\' {
+				escaped = true
+			} else if r == '"' {
+				quoted = false
+			}
+		} else if r == '"' {
+			quoted = true
+		} else if unicode.IsSpace(r) {
+			token, err := newFilterToken(expression[start:i], start)
+
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, token)
+			start = -1
+		}
+	}
+
+	if start != -1 {
+		return nil, &FilterSyntaxError{Token: expression[start:], Offset: start, Err: errFilterTokenQuote}
+	}
+
+	return tokens, nil
+}
+
+// newFilterToken splits given token into key and value and unquotes the value.
+func newFilterToken(token string, offset int) (filterToken, error) {
+	t := filterToken{token: token, offset: offset}
+	i := strings.Index(token, ":")
+
+	if i == -1 {
+		return t, &FilterSyntaxError{Token: token, Offset: offset, Err: errFilterTokenValue}
+	}
+
+	t.key = strings.ToLower(token[:i])
+	t.value = token[i+1:]
+
+	if strings.HasPrefix(t.value, `"`) {
+		value, err := strconv.Unquote(t.value)
+
+		if err != nil {
+			return t, &FilterSyntaxError{Token: token, Offset: offset, Err: errFilterTokenQuote}
+		}
+
+		t.value = value
+	}
+
+	if strings.TrimSpace(t.value) == "" {
+		return t, &FilterSyntaxError{Token: token, Offset: offset, Err: errFilterTokenValue}
+	}
+
+	return t, nil
+}
+
+// parseFilterToken sets the filter field for given token.
+// The keys that have been set already are tracked in set.
+func parseFilterToken(filter *Filter, set map[string]bool, t filterToken) error {
+	key := t.key
+
+	if key == "path" && isPathPattern(t.value) {
+		key = "pattern"
+	}
+
+	if set[key] {
+		return errFilterTokenDup
+	}
+
+	set[key] = true
+
+	switch key {
+	case "tenant":
+		id, err := strconv.ParseInt(t.value, 10, 64)
+
+		if err != nil || id <= 0 {
+			return errFilterTokenTenant
+		}
+
+		filter.TenantID = NewTenantID(id)
+	case "from", "to":
+		date, err := time.Parse(filterDateFormat, t.value)
+
+		if err != nil {
+			return errFilterTokenDate
+		}
+
+		if key == "from" {
+			filter.From = date
+		} else {
+			filter.To = date
+		}
+	case "path":
+		filter.Path = t.value
+	case "pattern":
+		if _, err := newPathPattern(t.value); err != nil {
+			return err
+		}
+
+		filter.PathPattern = t.value
+	case "group":
+		filter.ContentGroup = t.value
+	case "referrer":
+		filter.Referrer = t.value
+	case "country":
+		filter.Country = t.value
+	case "language":
+		filter.Language = t.value
+	case "os":
+		filter.OS = t.value
+	case "browser":
+		filter.Browser = t.value
+	case "platform":
+		platform := strings.ToLower(t.value)
+
+		if platform != PlatformDesktop && platform != PlatformMobile && platform != PlatformUnknown {
+			return errFilterTokenPlatform
+		}
+
+		filter.Platform = platform
+	case "interval":
+		interval := strings.ToLower(t.value)
+
+		if !isInterval(interval) {
+			return errFilterTokenInterval
+		}
+
+		filter.Interval = interval
+	case "screen":
+		size := strings.Split(strings.ToLower(t.value), "x")
+
+		if len(size) != 2 {
+			return errFilterTokenScreen
+		}
+
+		width, err := strconv.Atoi(size[0])
+
+		if err != nil || width < 0 {
+			return errFilterTokenScreen
+		}
+
+		height, err := strconv.Atoi(size[1])
+
+		if err != nil || height < 0 {
+			return errFilterTokenScreen
+		}
+
+		filter.ScreenWidth = width
+		filter.ScreenHeight = height
+	default:
+		return errFilterTokenKey
+	}
+
+	return nil
+}
+
+// isPathPattern returns true if given path contains a wildcard or is a regular expression.
+func isPathPattern(path string) bool {
+	return strings.HasPrefix(path, "^") || strings.ContainsAny(path, "*?")
+}
+
+// quoteFilterValue quotes given value if it contains whitespace or quotes.
+func quoteFilterValue(value string) string {
+	if strings.IndexFunc(value, func(r rune) bool { return unicode.IsSpace(r) || r == '"' }) != -1 {
+		return strconv.Quote(value)
+	}
+
+	return value
+}
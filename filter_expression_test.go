@@ -0,0 +1,107 @@
+package pirsch
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseFilter(t *testing.T) {
+	filter, err := ParseFilter(` country:de  browser:Firefox path:/blog/* from:2020-10-01 to:2020-10-31 os:"Windows Mobile" Platform:Mobile screen:1920x1080 tenant:42 interval:Week`)
+
+	if err != nil {
+		t.Fatalf("Filter must have been parsed, but was: %v", err)
+	}
+
+	if filter.Country != "de" || filter.Browser != BrowserFirefox || filter.Path != "" || filter.PathPattern != "/blog/*" ||
+		!filter.From.Equal(time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)) || !filter.To.Equal(time.Date(2020, 10, 31, 0, 0, 0, 0, time.UTC)) ||
+		filter.OS != OSWindowsMobile || filter.Platform != PlatformMobile || filter.ScreenWidth != 1920 || filter.ScreenHeight != 1080 ||
+		filter.TenantID != NewTenantID(42) || filter.Interval != IntervalWeek {
+		t.Fatalf("Filter not as expected: %v", filter)
+	}
+
+	filter, err = ParseFilter("path:/pricing pattern:^/docs/ group:Docs")
+
+	if err != nil {
+		t.Fatalf("Filter must have been parsed, but was: %v", err)
+	}
+
+	if filter.Path != "/pricing" || filter.PathPattern != "^/docs/" || filter.ContentGroup != "Docs" {
+		t.Fatalf("Filter not as expected: %v", filter)
+	}
+
+	filter, err = ParseFilter("")
+
+	if err != nil || filter.needsFilterStats() || !filter.From.IsZero() {
+		t.Fatalf("Empty filter must have been parsed, but was: %v %v", filter, err)
+	}
+}
+
+func TestParseFilterError(t *testing.T) {
+	input := []string{
+		"country:de colour:red",
+		"country:de country:gb",
+		"path:/blog/* pattern:/docs/**",
+		"country:",
+		"country",
+		`os:"Windows Mobile`,
+		"from:01.10.2020",
+		"tenant:-1",
+		"platform:tv",
+		"screen:1920",
+		"pattern:^/docs(",
+		"interval:hour",
+	}
+	tokens := []string{"colour:red", "country:gb", "pattern:/docs/**", "country:", "country", `os:"Windows Mobile`, "from:01.10.2020", "tenant:-1", "platform:tv", "screen:1920", "pattern:^/docs(", "interval:hour"}
+	offsets := []int{11, 11, 13, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	reasons := []error{errFilterTokenKey, errFilterTokenDup, errFilterTokenDup, errFilterTokenValue, errFilterTokenValue, errFilterTokenQuote, errFilterTokenDate, errFilterTokenTenant, errFilterTokenPlatform, errFilterTokenScreen, nil, errFilterTokenInterval}
+
+	for i, in := range input {
+		_, err := ParseFilter(in)
+		var syntaxErr *FilterSyntaxError
+
+		if !errors.As(err, &syntaxErr) {
+			t.Fatalf("Syntax error must be returned for %q, but was: %v", in, err)
+		}
+
+		if syntaxErr.Token != tokens[i] || syntaxErr.Offset != offsets[i] || reasons[i] != nil && !errors.Is(err, reasons[i]) {
+			t.Fatalf("Syntax error not as expected for %q: %v", in, err)
+		}
+	}
+}
+
+func TestFilter_String(t *testing.T) {
+	filter := &Filter{
+		TenantID:     NewTenantID(42),
+		From:         time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC),
+		To:           time.Date(2020, 10, 31, 0, 0, 0, 0, time.UTC),
+		Path:         "/pricing",
+		PathPattern:  "/blog/*",
+		ContentGroup: "Getting Started",
+		Country:      "de",
+		OS:           OSWindowsMobile,
+		Platform:     PlatformMobile,
+		ScreenWidth:  1920,
+		Interval:     IntervalMonth,
+	}
+	expression := filter.String()
+	expected := `tenant:42 from:2020-10-01 to:2020-10-31 path:/pricing path:/blog/* group:"Getting Started" country:de os:"Windows Mobile" platform:mobile interval:month screen:1920x0`
+
+	if expression != expected {
+		t.Fatalf("Expression not as expected: %v", expression)
+	}
+
+	parsed, err := ParseFilter(expression)
+
+	if err != nil {
+		t.Fatalf("Expression must have been parsed, but was: %v", err)
+	}
+
+	if parsed.String() != expression {
+		t.Fatalf("Parsed filter must match the original, but was: %v", parsed)
+	}
+
+	if (&Filter{PathPattern: "/blog"}).String() != "pattern:/blog" || (&Filter{}).String() != "" {
+		t.Fatal("Path pattern without wildcard must use the pattern key")
+	}
+}
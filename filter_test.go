@@ -44,3 +44,122 @@ func TestFilter_Validate(t *testing.T) {
 		t.Fatalf("Filter not as expected: %v", filter)
 	}
 }
+
+func TestFilter_VisitorFilter(t *testing.T) {
+	filter := &Filter{Country: " DE ", Language: "EN", Platform: "Desktop", ScreenWidth: -1}
+	filter.validate()
+
+	if filter.Country != "de" || filter.Language != "en" || filter.Platform != PlatformDesktop || filter.ScreenWidth != 0 {
+		t.Fatalf("Filter not as expected: %v", filter)
+	}
+
+	if !filter.needsFilterStats() || filter.withoutPath().Country != "de" {
+		t.Fatal("Filter must have visitor attributes")
+	}
+
+	filter = &Filter{Path: "/", Platform: "tv"}
+	filter.validate()
+
+	if filter.Platform != "" || filter.needsFilterStats() || filter.withoutPath().Path != "" {
+		t.Fatalf("Filter must not have visitor attributes, but was: %v", filter)
+	}
+
+	filter = &Filter{Path: "/", PathPattern: " /blog/* ", ContentGroup: " Docs "}
+	filter.validate()
+
+	if filter.PathPattern != "/blog/*" || filter.ContentGroup != "Docs" || !filter.needsFilterStats() || !filter.withoutPath().hasPathPattern() {
+		t.Fatalf("Filter must keep path pattern and content group, but was: %v", filter)
+	}
+}
+
+func TestFilter_MatchesPath(t *testing.T) {
+	filter := []Filter{
+		{},
+		{Path: "/pricing"},
+		{Path: "/"},
+		{PathPattern: "/pricing*", paths: []string{"/pricing"}},
+		{ContentGroup: "Docs", paths: []string{"/docs"}},
+		{PathPattern: "/pricing*"},
+		{Path: "/PRICING", PathPattern: "/pricing*", paths: []string{"/Pricing"}},
+	}
+	matches := []bool{true, true, false, true, false, false, true}
+
+	for i, f := range filter {
+		if f.matchesPath("/Pricing") != matches[i] {
+			t.Fatalf("Filter %v must return %v", f, matches[i])
+		}
+	}
+}
+
+func TestFilter_Interval(t *testing.T) {
+	filter := &Filter{Interval: " Week "}
+	filter.validate()
+
+	if filter.Interval != IntervalWeek {
+		t.Fatalf("Interval must have been normalized, but was: %v", filter.Interval)
+	}
+
+	filter = &Filter{Interval: "hour"}
+	filter.validate()
+
+	if filter.Interval != "" {
+		t.Fatalf("Invalid interval must have been reset, but was: %v", filter.Interval)
+	}
+
+	local := time.FixedZone("UTC-5", -5*60*60)
+	days := []time.Time{
+		time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC), // Thursday
+		time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC),   // Sunday
+		time.Date(2021, 1, 4, 0, 0, 0, 0, local),      // Monday, read in another time zone
+	}
+	intervals := []string{IntervalDay, IntervalWeek, IntervalMonth, IntervalYear}
+	start := [][]time.Time{
+		{day(2020, 12, 31, 0), day(2021, 1, 3, 0), day(2021, 1, 4, 0)},
+		{day(2020, 12, 28, 0), day(2020, 12, 28, 0), day(2021, 1, 4, 0)},
+		{day(2020, 12, 1, 0), day(2021, 1, 1, 0), day(2021, 1, 1, 0)},
+		{day(2020, 1, 1, 0), day(2021, 1, 1, 0), day(2021, 1, 1, 0)},
+	}
+	end := [][]time.Time{
+		{day(2020, 12, 31, 0), day(2021, 1, 3, 0), day(2021, 1, 4, 0)},
+		{day(2021, 1, 3, 0), day(2021, 1, 3, 0), day(2021, 1, 10, 0)},
+		{day(2020, 12, 31, 0), day(2021, 1, 31, 0), day(2021, 1, 31, 0)},
+		{day(2020, 12, 31, 0), day(2021, 12, 31, 0), day(2021, 12, 31, 0)},
+	}
+
+	for i, interval := range intervals {
+		filter = &Filter{Interval: interval}
+
+		for j, d := range days {
+			if s := filter.intervalStart(d); !s.Equal(start[i][j]) {
+				t.Fatalf("Start of %v for %v must be %v, but was: %v", interval, d, start[i][j], s)
+			}
+
+			if e := filter.intervalEnd(d); !e.Equal(end[i][j]) {
+				t.Fatalf("End of %v for %v must be %v, but was: %v", interval, d, end[i][j], e)
+			}
+		}
+	}
+}
+
+func TestFilter_TimeRange(t *testing.T) {
+	timezone, err := time.LoadLocation("Europe/Berlin")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// summer time starts on Sunday, March 28th, 2021 in Berlin (UTC+1 before, UTC+2 after)
+	filter := &Filter{From: day(2021, 3, 22, 0), To: day(2021, 4, 4, 0), Interval: IntervalWeek, timezone: timezone}
+	from, to := filter.timeRange()
+
+	if !from.Equal(time.Date(2021, 3, 21, 23, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2021, 4, 4, 22, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Time range must be made of the days in the time zone, but was: %v %v", from, to)
+	}
+
+	filter.Interval = IntervalDay
+	from, to = filter.timeRange()
+
+	if !from.Equal(day(2021, 3, 22, 0)) || !to.Equal(day(2021, 4, 5, 0)) {
+		t.Fatalf("Time range must be made of UTC days if not grouped by interval, but was: %v %v", from, to)
+	}
+}
@@ -12,10 +12,20 @@ import (
 // Fingerprint returns a hash for given request and salt.
 // The hash is unique for the visitor.
 func Fingerprint(r *http.Request, salt string) string {
+	return fingerprint(r, salt, time.Now())
+}
+
+// fingerprint returns a hash for given request and salt on the day of given time.
+func fingerprint(r *http.Request, salt string, t time.Time) string {
+	return fingerprintVisitor(r.Header.Get("User-Agent"), getIP(r), salt, t)
+}
+
+// fingerprintVisitor returns a hash for given User-Agent, IP, and salt on the day of given time.
+func fingerprintVisitor(userAgent, ip, salt string, t time.Time) string {
 	var sb strings.Builder
-	sb.WriteString(r.Header.Get("User-Agent"))
-	sb.WriteString(getIP(r))
-	sb.WriteString(time.Now().UTC().Format("20060102"))
+	sb.WriteString(userAgent)
+	sb.WriteString(ip)
+	sb.WriteString(t.UTC().Format("20060102"))
 	sb.WriteString(salt)
 	hash := md5.New()
 
@@ -0,0 +1,107 @@
+package pirsch
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"strings"
+)
+
+const (
+	maxFunnelSteps = 20
+)
+
+var (
+	errFunnelName  = errors.New("funnel name missing")
+	errFunnelSteps = errors.New("funnel requires between two and 20 steps")
+)
+
+// Funnel is an ordered list of steps a visitor is expected to take within one session, like /pricing -> /signup -> /welcome.
+// Each step is a path pattern (glob or regular expression) like the MiddlewareRules.
+// A visitor reaches a step if the session visits a page matching it after all previous steps have been reached.
+type Funnel struct {
+	BaseEntity
+
+	Name  string         `db:"name" json:"name"`
+	Steps pq.StringArray `db:"steps" json:"steps"`
+}
+
+// NewFunnel returns a new Funnel for given tenant, name, and steps.
+func NewFunnel(tenantID sql.NullInt64, name string, steps ...string) *Funnel {
+	return &Funnel{
+		BaseEntity: BaseEntity{TenantID: tenantID},
+		Name:       name,
+		Steps:      steps,
+	}
+}
+
+// Validate trims the funnel and checks that it has a name and valid steps.
+func (funnel *Funnel) Validate() error {
+	funnel.Name = strings.TrimSpace(funnel.Name)
+
+	if funnel.Name == "" {
+		return errFunnelName
+	}
+
+	if len(funnel.Steps) < 2 || len(funnel.Steps) > maxFunnelSteps {
+		return errFunnelSteps
+	}
+
+	for i := range funnel.Steps {
+		funnel.Steps[i] = strings.TrimSpace(funnel.Steps[i])
+
+		if funnel.Steps[i] == "" {
+			return errFunnelSteps
+		}
+	}
+
+	_, err := newPathPatterns(funnel.Steps)
+	return err
+}
+
+// countSteps returns the number of visitors reaching each step of the funnel for given sessions.
+// A visitor is counted once per step, even if the step was reached in multiple sessions.
+// Sessions of other tenants are ignored, if the funnel belongs to a tenant.
+func (funnel *Funnel) countSteps(sessions [][]Hit) ([]int, error) {
+	steps, err := newPathPatterns(funnel.Steps)
+
+	if err != nil {
+		return nil, err
+	}
+
+	type visitor struct {
+		tenantID    sql.NullInt64
+		fingerprint string
+	}
+	reached := make(map[visitor]int) // number of steps reached
+
+	for _, session := range sessions {
+		if len(session) == 0 || funnel.TenantID.Valid && session[0].TenantID != funnel.TenantID {
+			continue
+		}
+
+		step := 0
+
+		for _, hit := range session {
+			if step < len(steps) && steps[step].match(hit.Path.String) {
+				step++
+			}
+		}
+
+		v := visitor{session[0].TenantID, session[0].Fingerprint}
+
+		if step > reached[v] {
+			reached[v] = step
+		}
+	}
+
+	visitors := make([]int, len(steps))
+
+	for _, n := range reached {
+		for i := 0; i < n; i++ {
+			visitors[i]++
+		}
+	}
+
+	return visitors, nil
+}
@@ -0,0 +1,86 @@
+package pirsch
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestFunnelValidate(t *testing.T) {
+	funnel := NewFunnel(NullTenant, " Signup ", " /pricing ", "/signup", "/welcome")
+
+	if err := funnel.Validate(); err != nil {
+		t.Fatalf("Funnel must be valid, but was: %v", err)
+	}
+
+	if funnel.Name != "Signup" || funnel.Steps[0] != "/pricing" {
+		t.Fatalf("Funnel must have been trimmed, but was: %v", funnel)
+	}
+
+	if err := NewFunnel(NullTenant, "", "/pricing", "/signup").Validate(); err != errFunnelName {
+		t.Fatalf("Funnel without name must be invalid, but was: %v", err)
+	}
+
+	if err := NewFunnel(NullTenant, "Signup", "/pricing").Validate(); err != errFunnelSteps {
+		t.Fatalf("Funnel with one step must be invalid, but was: %v", err)
+	}
+
+	if err := NewFunnel(NullTenant, "Signup", "/pricing", " ").Validate(); err != errFunnelSteps {
+		t.Fatalf("Funnel with empty step must be invalid, but was: %v", err)
+	}
+
+	if err := NewFunnel(NullTenant, "Signup", "/pricing", "^/signup(").Validate(); err == nil {
+		t.Fatal("Funnel with invalid pattern must be invalid")
+	}
+}
+
+func TestFunnelCountSteps(t *testing.T) {
+	session1 := time.Date(2020, 9, 7, 4, 0, 0, 0, time.UTC)
+	session2 := time.Date(2020, 9, 7, 8, 0, 0, 0, time.UTC)
+	hits := []Hit{
+		funnelHit(0, "fp1", session1, "/pricing"),
+		funnelHit(0, "fp1", session1, "/about"),
+		funnelHit(0, "fp1", session1, "/signup"),
+		funnelHit(0, "fp1", session2, "/pricing"), // fp1 reaches two steps in both sessions, but is only counted once
+		funnelHit(0, "fp1", session2, "/signup"),
+		funnelHit(0, "fp2", session1, "/signup"), // wrong order
+		funnelHit(0, "fp2", session1, "/pricing"),
+		funnelHit(0, "fp3", session1, "/pricing"), // all steps, but in different sessions
+		funnelHit(0, "fp3", session2, "/signup"),
+		funnelHit(0, "fp3", session2, "/welcome"),
+		funnelHit(0, "fp4", session1, "/pricing"),
+		funnelHit(0, "fp4", session1, "/signup"),
+		funnelHit(0, "fp4", session1, "/welcome"),
+		funnelHit(1, "fp4", session1, "/pricing"), // other tenant
+	}
+	funnel := NewFunnel(NullTenant, "Signup", "/pricing", "/signup", "/welcome")
+	steps, err := funnel.countSteps(groupSessions(hits))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(steps) != 3 || steps[0] != 5 || steps[1] != 2 || steps[2] != 1 {
+		t.Fatalf("Steps not as expected: %v", steps)
+	}
+
+	funnel.TenantID = NewTenantID(1)
+	steps, err = funnel.countSteps(groupSessions(hits))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(steps) != 3 || steps[0] != 1 || steps[1] != 0 || steps[2] != 0 {
+		t.Fatalf("Steps for tenant not as expected: %v", steps)
+	}
+}
+
+func funnelHit(tenantID int64, fingerprint string, session time.Time, path string) Hit {
+	return Hit{
+		BaseEntity:  BaseEntity{TenantID: NewTenantID(tenantID)},
+		Fingerprint: fingerprint,
+		Session:     sql.NullTime{Time: session, Valid: true},
+		Path:        sql.NullString{String: path, Valid: true},
+	}
+}
@@ -0,0 +1,71 @@
+package pirsch
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+var (
+	errGoalName   = errors.New("goal name missing")
+	errGoalTarget = errors.New("goal requires either a path pattern or an event name")
+)
+
+// Goal is a conversion goal defined for a tenant.
+// A visitor converts by visiting a page matching the path pattern or by triggering the event.
+// The path pattern is a glob or regular expression, like the MiddlewareRules (e.g. /signup/thanks or /docs/**).
+type Goal struct {
+	BaseEntity
+
+	Name        string         `db:"name" json:"name"`
+	PathPattern sql.NullString `db:"path_pattern" json:"path_pattern,omitempty"`
+	EventName   sql.NullString `db:"event_name" json:"event_name,omitempty"`
+}
+
+// NewPathGoal returns a new Goal for given tenant, name, and path pattern.
+func NewPathGoal(tenantID sql.NullInt64, name, pattern string) *Goal {
+	return &Goal{
+		BaseEntity:  BaseEntity{TenantID: tenantID},
+		Name:        name,
+		PathPattern: sql.NullString{String: pattern, Valid: pattern != ""},
+	}
+}
+
+// NewEventGoal returns a new Goal for given tenant, name, and event name.
+func NewEventGoal(tenantID sql.NullInt64, name, event string) *Goal {
+	return &Goal{
+		BaseEntity: BaseEntity{TenantID: tenantID},
+		Name:       name,
+		EventName:  sql.NullString{String: event, Valid: event != ""},
+	}
+}
+
+// Validate trims the goal and checks that it has a name and exactly one valid target.
+func (goal *Goal) Validate() error {
+	goal.Name = strings.TrimSpace(goal.Name)
+	goal.PathPattern.String = strings.TrimSpace(goal.PathPattern.String)
+	goal.PathPattern.Valid = goal.PathPattern.String != ""
+	goal.EventName.String = strings.TrimSpace(goal.EventName.String)
+	goal.EventName.Valid = goal.EventName.String != ""
+
+	if goal.Name == "" {
+		return errGoalName
+	}
+
+	if goal.PathPattern.Valid == goal.EventName.Valid {
+		return errGoalTarget
+	}
+
+	if goal.PathPattern.Valid {
+		if _, err := newPathPattern(goal.PathPattern.String); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pathRegex returns the regular expression for the path pattern, to be used in a case-insensitive query.
+func (goal *Goal) pathRegex() string {
+	return pathPatternToRegex(goal.PathPattern.String)
+}
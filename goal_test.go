@@ -0,0 +1,48 @@
+package pirsch
+
+import (
+	"testing"
+)
+
+func TestGoalValidate(t *testing.T) {
+	goal := NewPathGoal(NullTenant, " Signup ", " /signup/thanks ")
+
+	if err := goal.Validate(); err != nil {
+		t.Fatalf("Goal must be valid, but was: %v", err)
+	}
+
+	if goal.Name != "Signup" || goal.PathPattern.String != "/signup/thanks" || goal.EventName.Valid {
+		t.Fatalf("Goal must have been trimmed, but was: %v", goal)
+	}
+
+	if err := NewEventGoal(NullTenant, "Download", "download").Validate(); err != nil {
+		t.Fatalf("Goal must be valid, but was: %v", err)
+	}
+
+	if err := NewPathGoal(NullTenant, " ", "/").Validate(); err != errGoalName {
+		t.Fatalf("Goal without name must be invalid, but was: %v", err)
+	}
+
+	if err := NewPathGoal(NullTenant, "Signup", "").Validate(); err != errGoalTarget {
+		t.Fatalf("Goal without target must be invalid, but was: %v", err)
+	}
+
+	goal = NewPathGoal(NullTenant, "Signup", "/signup")
+	goal.EventName.String = "signup"
+
+	if err := goal.Validate(); err != errGoalTarget {
+		t.Fatalf("Goal with path pattern and event name must be invalid, but was: %v", err)
+	}
+
+	if err := NewPathGoal(NullTenant, "Signup", "^/signup(").Validate(); err == nil {
+		t.Fatal("Goal with invalid pattern must be invalid")
+	}
+}
+
+func TestGoalPathRegex(t *testing.T) {
+	goal := NewPathGoal(NullTenant, "Docs", "/docs/**")
+
+	if regex := goal.pathRegex(); regex != "^/docs/.*$" {
+		t.Fatalf("Regular expression not as expected: %v", regex)
+	}
+}
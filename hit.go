@@ -11,6 +11,24 @@ import (
 	"time"
 )
 
+// Reasons for a hit to be ignored, as reported by TrackerStats.Ignored.
+const (
+	// IgnoreReasonUserAgent is used for requests without User-Agent.
+	IgnoreReasonUserAgent = "user_agent"
+
+	// IgnoreReasonPrefetch is used for requests made by browsers pre-fetching data.
+	IgnoreReasonPrefetch = "prefetch"
+
+	// IgnoreReasonReferrerSpam is used for requests with a referrer on the referrer blacklist.
+	IgnoreReasonReferrerSpam = "referrer_spam"
+
+	// IgnoreReasonBot is used for requests with a User-Agent on the bot blacklist.
+	IgnoreReasonBot = "bot"
+
+	// IgnoreReasonEventName is used for events without name.
+	IgnoreReasonEventName = "event_name"
+)
+
 var referrerQueryParams = []string{
 	"ref",
 	"referer",
@@ -28,6 +46,8 @@ type Hit struct {
 	Language       sql.NullString `db:"language" json:"language,omitempty"`
 	UserAgent      sql.NullString `db:"user_agent" json:"user_agent,omitempty"`
 	Referrer       sql.NullString `db:"referrer" json:"referrer,omitempty"`
+	ReferrerName   sql.NullString `db:"referrer_name" json:"referrer_name,omitempty"`
+	Channel        sql.NullString `db:"channel" json:"channel,omitempty"`
 	OS             sql.NullString `db:"os" json:"os,omitempty"`
 	OSVersion      sql.NullString `db:"os_version" json:"os_version,omitempty"`
 	Browser        sql.NullString `db:"browser" json:"browser,omitempty"`
@@ -37,6 +57,12 @@ type Hit struct {
 	Mobile         bool           `db:"mobile" json:"mobile"`
 	ScreenWidth    int            `db:"screen_width" json:"screen_width"`
 	ScreenHeight   int            `db:"screen_height" json:"screen_height"`
+	UTMSource      sql.NullString `db:"utm_source" json:"utm_source,omitempty"`
+	UTMMedium      sql.NullString `db:"utm_medium" json:"utm_medium,omitempty"`
+	UTMCampaign    sql.NullString `db:"utm_campaign" json:"utm_campaign,omitempty"`
+	UTMTerm        sql.NullString `db:"utm_term" json:"utm_term,omitempty"`
+	UTMContent     sql.NullString `db:"utm_content" json:"utm_content,omitempty"`
+	Status         int            `db:"status" json:"status,omitempty"`
 	Time           time.Time      `db:"time" json:"time"`
 }
 
@@ -79,54 +105,132 @@ type HitOptions struct {
 	// ScreenHeight sets the screen height to be stored with the hit.
 	ScreenHeight int
 
+	// Time sets the time the hit is recorded for, which can be used to backfill data or to store queued hits.
+	// The fingerprint and session are derived from this time. If not set, the current time is used.
+	Time time.Time
+
+	// Status sets the HTTP status code of the response to be stored with the hit.
+	// It is set by the Middleware.
+	Status int
+
 	geoDB        *GeoDB
 	sessionCache *sessionCache
 }
 
+// TrackInput is the transport-neutral information about a page visit.
+// It can be used to track visitors without a http.Request, like in gRPC services, queue consumers,
+// or backends forwarding the visitor information of (mobile) clients. See Tracker.Track.
+type TrackInput struct {
+	// TenantID is optionally saved with a hit to split the data between multiple tenants.
+	TenantID sql.NullInt64
+
+	// IP is the IP address of the visitor. It's used to generate the fingerprint and look up the country code.
+	IP string
+
+	// UserAgent is the User-Agent of the visitor's browser.
+	UserAgent string
+
+	// AcceptLanguage is the value of the Accept-Language header sent by the visitor's browser.
+	AcceptLanguage string
+
+	// URL is the full URL of the page visited.
+	URL string
+
+	// Path can be set to overwrite the path of the URL.
+	Path string
+
+	// Referrer is the URL of the page the visitor came from.
+	Referrer string
+
+	// ScreenWidth is the screen width of the visitor's device.
+	ScreenWidth int
+
+	// ScreenHeight is the screen height of the visitor's device.
+	ScreenHeight int
+
+	// Time is the time the page was visited. If not set, the current time is used.
+	Time time.Time
+}
+
 // HitFromRequest returns a new Hit for given request, salt and HitOptions.
 // The salt must stay consistent to track visitors across multiple calls.
 // The easiest way to track visitors is to use the Tracker.
 func HitFromRequest(r *http.Request, salt string, options *HitOptions) Hit {
-	now := time.Now().UTC() // capture first to get as close as possible
-
 	// set default options in case they're nil
 	if options == nil {
 		options = &HitOptions{}
 	}
 
-	// shorten strings if required and parse User-Agent to extract more data (OS, Browser)
 	getRequestURI(r, options)
-	fingerprint := Fingerprint(r, salt)
-	path := shortenString(options.Path, 2000)
-	requestURL := shortenString(options.URL, 2000)
-	ua := r.UserAgent()
+	referrer := options.Referrer
+
+	if referrer == "" {
+		referrer = getReferrerFromHeaderOrQuery(r)
+	}
+
+	hit := hitFromInput(&TrackInput{
+		TenantID:       options.TenantID,
+		IP:             getIP(r),
+		UserAgent:      r.UserAgent(),
+		AcceptLanguage: r.Header.Get("Accept-Language"),
+		URL:            options.URL,
+		Path:           options.Path,
+		Referrer:       referrer,
+		ScreenWidth:    options.ScreenWidth,
+		ScreenHeight:   options.ScreenHeight,
+		Time:           options.Time,
+	}, salt, options)
+	hit.Status = options.Status
+	return hit
+}
+
+// hitFromInput returns a new Hit for given TrackInput and salt.
+// Only the referrer blacklist, GeoDB, and session cache are used from the HitOptions.
+func hitFromInput(input *TrackInput, salt string, options *HitOptions) Hit {
+	now := time.Now().UTC() // capture first to get as close as possible
+
+	if !input.Time.IsZero() {
+		now = input.Time.UTC()
+	}
+
+	// shorten strings if required and parse User-Agent to extract more data (OS, Browser)
+	requestURL, path := resolveURL(input.URL, input.Path)
+	fingerprint := fingerprintVisitor(input.UserAgent, input.IP, salt, now)
+	path = shortenString(path, 2000)
+	requestURL = shortenString(requestURL, 2000)
+	ua := input.UserAgent
 	uaInfo := ParseUserAgent(ua)
 	uaInfo.OS = shortenString(uaInfo.OS, 20)
 	uaInfo.OSVersion = shortenString(uaInfo.OSVersion, 20)
 	uaInfo.Browser = shortenString(uaInfo.Browser, 20)
 	uaInfo.BrowserVersion = shortenString(uaInfo.BrowserVersion, 20)
 	ua = shortenString(ua, 200)
-	lang := shortenString(getLanguage(r), 10)
-	referrer := shortenString(getReferrer(r, options.Referrer, options.ReferrerDomainBlacklist, options.ReferrerDomainBlacklistIncludesSubdomains), 200)
+	lang := shortenString(parseLanguage(input.AcceptLanguage), 10)
+	referrer := shortenString(cleanReferrer(input.Referrer, options.ReferrerDomainBlacklist, options.ReferrerDomainBlacklistIncludesSubdomains), 200)
+	utm := getUTMParams(input.URL)
+	referrerName, channel := getReferrerSource(referrer, utm.medium)
+	referrerName = shortenString(referrerName, 200)
 	countryCode := ""
 
 	if options.geoDB != nil {
-		countryCode = options.geoDB.CountryCode(getIP(r))
+		countryCode = options.geoDB.CountryCode(input.IP)
 	}
 
 	var session time.Time
 
 	if options.sessionCache != nil {
-		session = options.sessionCache.find(options.TenantID, fingerprint)
+		session = options.sessionCache.find(input.TenantID, fingerprint, now)
 	}
 
-	if options.ScreenWidth <= 0 || options.ScreenHeight <= 0 {
-		options.ScreenWidth = 0
-		options.ScreenHeight = 0
+	screenWidth, screenHeight := input.ScreenWidth, input.ScreenHeight
+
+	if screenWidth <= 0 || screenHeight <= 0 {
+		screenWidth = 0
+		screenHeight = 0
 	}
 
 	return Hit{
-		BaseEntity:     BaseEntity{TenantID: options.TenantID},
+		BaseEntity:     BaseEntity{TenantID: input.TenantID},
 		Fingerprint:    fingerprint,
 		Session:        sql.NullTime{Time: session, Valid: !session.IsZero()},
 		Path:           sql.NullString{String: path, Valid: path != ""},
@@ -134,6 +238,8 @@ func HitFromRequest(r *http.Request, salt string, options *HitOptions) Hit {
 		Language:       sql.NullString{String: lang, Valid: lang != ""},
 		UserAgent:      sql.NullString{String: ua, Valid: ua != ""},
 		Referrer:       sql.NullString{String: referrer, Valid: referrer != ""},
+		ReferrerName:   sql.NullString{String: referrerName, Valid: referrerName != ""},
+		Channel:        sql.NullString{String: channel, Valid: channel != ""},
 		OS:             sql.NullString{String: uaInfo.OS, Valid: uaInfo.OS != ""},
 		OSVersion:      sql.NullString{String: uaInfo.OSVersion, Valid: uaInfo.OSVersion != ""},
 		Browser:        sql.NullString{String: uaInfo.Browser, Valid: uaInfo.Browser != ""},
@@ -141,8 +247,13 @@ func HitFromRequest(r *http.Request, salt string, options *HitOptions) Hit {
 		CountryCode:    sql.NullString{String: countryCode, Valid: countryCode != ""},
 		Desktop:        uaInfo.IsDesktop(),
 		Mobile:         uaInfo.IsMobile(),
-		ScreenWidth:    options.ScreenWidth,
-		ScreenHeight:   options.ScreenHeight,
+		ScreenWidth:    screenWidth,
+		ScreenHeight:   screenHeight,
+		UTMSource:      sql.NullString{String: utm.source, Valid: utm.source != ""},
+		UTMMedium:      sql.NullString{String: utm.medium, Valid: utm.medium != ""},
+		UTMCampaign:    sql.NullString{String: utm.campaign, Valid: utm.campaign != ""},
+		UTMTerm:        sql.NullString{String: utm.term, Valid: utm.term != ""},
+		UTMContent:     sql.NullString{String: utm.content, Valid: utm.content != ""},
 		Time:           now,
 	}
 }
@@ -150,11 +261,16 @@ func HitFromRequest(r *http.Request, salt string, options *HitOptions) Hit {
 // IgnoreHit returns true, if a hit should be ignored for given request, or false otherwise.
 // The easiest way to track visitors is to use the Tracker.
 func IgnoreHit(r *http.Request) bool {
+	return ignoreHitReason(r) != ""
+}
+
+// ignoreHitReason returns the reason why a hit should be ignored for given request, or an empty string otherwise.
+func ignoreHitReason(r *http.Request) string {
 	// empty User-Agents are usually bots
-	userAgent := strings.TrimSpace(strings.ToLower(r.Header.Get("User-Agent")))
+	userAgent := r.Header.Get("User-Agent")
 
-	if userAgent == "" {
-		return true
+	if strings.TrimSpace(userAgent) == "" {
+		return IgnoreReasonUserAgent
 	}
 
 	// ignore browsers pre-fetching data
@@ -166,22 +282,35 @@ func IgnoreHit(r *http.Request) bool {
 		xPurpose == "preview" ||
 		purpose == "prefetch" ||
 		purpose == "preview" {
-		return true
+		return IgnoreReasonPrefetch
+	}
+
+	return ignoreReason(userAgent, getReferrerFromHeaderOrQuery(r))
+}
+
+// ignoreReason returns the reason why a hit should be ignored for given User-Agent and referrer,
+// or an empty string otherwise.
+func ignoreReason(userAgent, referrer string) string {
+	// empty User-Agents are usually bots
+	userAgent = strings.TrimSpace(strings.ToLower(userAgent))
+
+	if userAgent == "" {
+		return IgnoreReasonUserAgent
 	}
 
 	// filter referrer spammers
-	if ignoreReferrer(r) {
-		return true
+	if ignoreReferrer(referrer) {
+		return IgnoreReasonReferrerSpam
 	}
 
 	// filter for bot keywords (most expensive operation last)
 	for _, botUserAgent := range userAgentBlacklist {
 		if strings.Contains(userAgent, botUserAgent) {
-			return true
+			return IgnoreReasonBot
 		}
 	}
 
-	return false
+	return ""
 }
 
 // HitOptionsFromRequest returns the HitOptions for given client request.
@@ -198,9 +327,7 @@ func HitOptionsFromRequest(r *http.Request) *HitOptions {
 	}
 }
 
-func ignoreReferrer(r *http.Request) bool {
-	referrer := getReferrerFromHeaderOrQuery(r)
-
+func ignoreReferrer(referrer string) bool {
 	if referrer == "" {
 		return false
 	}
@@ -221,22 +348,58 @@ func getRequestURI(r *http.Request, options *HitOptions) {
 		options.URL = r.URL.String()
 	}
 
-	u, err := url.ParseRequestURI(options.URL)
+	options.URL, options.Path = resolveURL(options.URL, options.Path)
+}
+
+// resolveURL returns the URL and path for given URL and (optional) path overwriting the path of the URL.
+func resolveURL(requestURL, path string) (string, string) {
+	u, err := url.ParseRequestURI(requestURL)
 
 	if err == nil {
-		if options.Path != "" {
+		if path != "" {
 			// change path and re-assemble URL
-			u.Path = options.Path
-			options.URL = u.String()
+			u.Path = path
+			requestURL = u.String()
 		} else {
-			options.Path = u.Path
+			path = u.Path
 		}
 	}
+
+	return requestURL, path
+}
+
+// utmParams are the campaign parameters of a URL.
+type utmParams struct {
+	source   string
+	medium   string
+	campaign string
+	term     string
+	content  string
+}
+
+// getUTMParams returns the utm_source, utm_medium, utm_campaign, utm_term, and utm_content query parameters of given URL.
+func getUTMParams(requestURL string) utmParams {
+	u, err := url.Parse(requestURL)
+
+	if err != nil {
+		return utmParams{}
+	}
+
+	query := u.Query()
+	return utmParams{
+		source:   shortenString(strings.TrimSpace(query.Get("utm_source")), 200),
+		medium:   shortenString(strings.TrimSpace(query.Get("utm_medium")), 200),
+		campaign: shortenString(strings.TrimSpace(query.Get("utm_campaign")), 200),
+		term:     shortenString(strings.TrimSpace(query.Get("utm_term")), 200),
+		content:  shortenString(strings.TrimSpace(query.Get("utm_content")), 200),
+	}
 }
 
 func getLanguage(r *http.Request) string {
-	lang := r.Header.Get("Accept-Language")
+	return parseLanguage(r.Header.Get("Accept-Language"))
+}
 
+func parseLanguage(lang string) string {
 	if lang != "" {
 		langs := strings.Split(lang, ";")
 		parts := strings.Split(langs[0], ",")
@@ -251,15 +414,9 @@ func getLanguage(r *http.Request) string {
 	return ""
 }
 
-func getReferrer(r *http.Request, ref string, domainBlacklist []string, ignoreSubdomain bool) string {
-	referrer := ""
-
-	if ref != "" {
-		referrer = ref
-	} else {
-		referrer = getReferrerFromHeaderOrQuery(r)
-	}
-
+// cleanReferrer returns the referrer without query parameters and anchor,
+// or an empty string if it's invalid or on the domain blacklist.
+func cleanReferrer(referrer string, domainBlacklist []string, ignoreSubdomain bool) string {
 	if referrer == "" {
 		return ""
 	}
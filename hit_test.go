@@ -5,6 +5,7 @@ import (
 	"net/http/httptest"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestHitFromRequest(t *testing.T) {
@@ -69,6 +70,85 @@ func TestHitFromRequestOverwritePathAndReferrer(t *testing.T) {
 	}
 }
 
+func TestHitFromRequestTime(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", "valid")
+	hitTime := time.Date(2020, 10, 3, 14, 25, 0, 0, time.UTC)
+	hit := HitFromRequest(req, "salt", &HitOptions{
+		Time: hitTime,
+	})
+
+	if !hit.Time.Equal(hitTime) {
+		t.Fatalf("Time must have been set, but was: %v", hit.Time)
+	}
+
+	if hit.Fingerprint != fingerprint(req, "salt", hitTime) ||
+		hit.Fingerprint == Fingerprint(req, "salt") {
+		t.Fatalf("Fingerprint must have been created for the day of the hit, but was: %v", hit.Fingerprint)
+	}
+}
+
+func TestHitFromInput(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/path?query=param", nil)
+	req.RemoteAddr = "81.2.69.142:5432"
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:84.0) Gecko/20100101 Firefox/84.0")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+	req.Header.Set("Referer", "https://ref.com/page?foo=bar")
+	hitTime := time.Date(2020, 10, 3, 14, 25, 0, 0, time.UTC)
+	expected := HitFromRequest(req, "salt", &HitOptions{Time: hitTime})
+	hit := hitFromInput(&TrackInput{
+		IP:             "81.2.69.142",
+		UserAgent:      "Mozilla/5.0 (X11; Linux x86_64; rv:84.0) Gecko/20100101 Firefox/84.0",
+		AcceptLanguage: "en-US,en;q=0.5",
+		URL:            "https://example.com/path?query=param",
+		Referrer:       "https://ref.com/page?foo=bar",
+		Time:           hitTime,
+	}, "salt", &HitOptions{})
+
+	if hit.String() != expected.String() {
+		t.Fatalf("Hit must be equal to the hit created from the request, but was: %v %v", hit, expected)
+	}
+}
+
+func TestHitFromRequestUTM(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/path?utm_source=newsletter&utm_medium=email&utm_campaign=+launch+&utm_term=analytics&utm_content=header", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:84.0) Gecko/20100101 Firefox/84.0")
+	hit := HitFromRequest(req, "salt", nil)
+
+	if hit.UTMSource.String != "newsletter" ||
+		hit.UTMMedium.String != "email" ||
+		hit.UTMCampaign.String != "launch" ||
+		hit.UTMTerm.String != "analytics" ||
+		hit.UTMContent.String != "header" {
+		t.Fatalf("Campaign parameters not as expected: %v", hit)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "https://example.com/path?utm_source=", nil)
+	hit = HitFromRequest(req, "salt", nil)
+
+	if hit.UTMSource.Valid || hit.UTMMedium.Valid || hit.UTMCampaign.Valid || hit.UTMTerm.Valid || hit.UTMContent.Valid {
+		t.Fatalf("Campaign parameters must not be set, but was: %v", hit)
+	}
+}
+
+func TestHitFromRequestReferrerSource(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/path", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:84.0) Gecko/20100101 Firefox/84.0")
+	req.Header.Set("Referer", "https://www.google.de/search?q=pirsch")
+	hit := HitFromRequest(req, "salt", nil)
+
+	if hit.ReferrerName.String != "Google" || hit.Channel.String != ChannelSearch {
+		t.Fatalf("Referrer source not as expected: %v", hit)
+	}
+
+	req.Header.Del("Referer")
+	hit = HitFromRequest(req, "salt", nil)
+
+	if hit.ReferrerName.Valid || hit.Channel.String != ChannelDirect {
+		t.Fatalf("Hit must be direct, but was: %v", hit)
+	}
+}
+
 func TestHitFromRequestScreenSize(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "http://foo.bar/test/path?query=param&foo=bar#anchor", nil)
 	hit := HitFromRequest(req, "salt", &HitOptions{
@@ -266,7 +346,7 @@ func TestHitOptionsFromRequest(t *testing.T) {
 	}
 }
 
-func TestGetReferrer(t *testing.T) {
+func TestCleanReferrer(t *testing.T) {
 	input := []struct {
 		referrer        string
 		blacklist       []string
@@ -291,10 +371,7 @@ func TestGetReferrer(t *testing.T) {
 	}
 
 	for i, in := range input {
-		r := httptest.NewRequest(http.MethodGet, "/", nil)
-		r.Header.Add("Referer", in.referrer)
-
-		if referrer := getReferrer(r, "", in.blacklist, in.ignoreSubdomain); referrer != expected[i] {
+		if referrer := cleanReferrer(in.referrer, in.blacklist, in.ignoreSubdomain); referrer != expected[i] {
 			t.Fatalf("Expected '%v', but was: %v", expected[i], referrer)
 		}
 	}
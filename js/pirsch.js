@@ -4,6 +4,7 @@
     var script = document.querySelector("#pirschjs");
     var endpoint = script.getAttribute("data-endpoint") || "/pirsch";
     var tenantID = script.getAttribute("data-tenant-id") || 0;
+    var token = script.getAttribute("data-token") || "";
     var trackLocalhost = script.hasAttribute("data-track-localhost");
 
     if(!trackLocalhost && (/^localhost(.*)$|^127(\.[0-9]{1,3}){3}$/is.test(location.hostname) || location.protocol === "file:")) {
@@ -29,6 +30,7 @@
         var url = endpoint+
             "?nocache="+ nocache+
             "&tenantid="+tenantID+
+            "&token="+encodeURIComponent(token)+
             "&location="+location+
             "&referrer="+referrer+
             "&width="+width+
@@ -40,6 +42,34 @@
         req.send();
     }
 
+    function event(name, options) {
+        options = options || {};
+        var body = JSON.stringify({
+            tenant_id: parseInt(tenantID, 10) || 0,
+            token: token,
+            events: [{
+                name: name,
+                meta: options.meta || {},
+                value: options.value || 0,
+                location: location.href,
+                referrer: document.referrer,
+                width: screen.width,
+                height: screen.height
+            }]
+        });
+
+        if(navigator.sendBeacon && navigator.sendBeacon(endpoint, body)) {
+            return;
+        }
+
+        var req = new XMLHttpRequest();
+        req.open("POST", endpoint);
+        req.setRequestHeader("Content-Type", "text/plain;charset=UTF-8");
+        req.send(body);
+    }
+
+    window.pirsch = event;
+
     if(history.pushState) {
         var pushState = history["pushState"];
 
@@ -89,4 +89,68 @@ func cleanupPostgresDB(t *testing.T) {
 	if _, err := postgresDB.Exec(`DELETE FROM "country_stats"`); err != nil {
 		t.Fatal(err)
 	}
+
+	if _, err := postgresDB.Exec(`DELETE FROM "event"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := postgresDB.Exec(`DELETE FROM "event_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := postgresDB.Exec(`DELETE FROM "event_metadata_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := postgresDB.Exec(`DELETE FROM "referrer_source_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := postgresDB.Exec(`DELETE FROM "utm_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := postgresDB.Exec(`DELETE FROM "goal"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := postgresDB.Exec(`DELETE FROM "goal_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := postgresDB.Exec(`DELETE FROM "funnel"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := postgresDB.Exec(`DELETE FROM "funnel_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := postgresDB.Exec(`DELETE FROM "content_group"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := postgresDB.Exec(`DELETE FROM "entry_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := postgresDB.Exec(`DELETE FROM "exit_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := postgresDB.Exec(`DELETE FROM "transition_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := postgresDB.Exec(`DELETE FROM "dimension_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := postgresDB.Exec(`DELETE FROM "hit_archive"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := postgresDB.Exec(`DELETE FROM "event_archive"`); err != nil {
+		t.Fatal(err)
+	}
 }
@@ -0,0 +1,228 @@
+package pirsch
+
+import (
+	"bufio"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// defaultStaticExtensions is the list of file extensions ignored by the Middleware if not configured otherwise.
+var defaultStaticExtensions = []string{
+	".css",
+	".js",
+	".mjs",
+	".map",
+	".json",
+	".xml",
+	".txt",
+	".ico",
+	".png",
+	".jpg",
+	".jpeg",
+	".gif",
+	".svg",
+	".webp",
+	".avif",
+	".bmp",
+	".woff",
+	".woff2",
+	".ttf",
+	".otf",
+	".eot",
+	".mp3",
+	".mp4",
+	".webm",
+	".ogg",
+	".wav",
+	".pdf",
+	".zip",
+	".gz",
+	".wasm",
+}
+
+// MiddlewareRules configures which requests are tracked by the Middleware.
+type MiddlewareRules struct {
+	// Include is a list of path patterns to track. If empty, all paths are tracked.
+	// A pattern is a regular expression if it starts with ^. Otherwise it's a glob,
+	// where * matches any characters within a path segment, ** matches any characters across segments,
+	// and ? matches a single character. Patterns are case-insensitive.
+	Include []string
+
+	// Exclude is a list of path patterns that are not tracked. It takes precedence over Include.
+	// See Include for the pattern syntax.
+	Exclude []string
+
+	// StaticExtensions is the list of file extensions (including the dot) that are never tracked.
+	// If not set, a default list of common extensions for scripts, styles, images, fonts, and media files is used.
+	StaticExtensions []string
+
+	// HitOptions optionally returns the HitOptions for a tracked request.
+	// If not set or nil is returned, the Tracker configuration is used.
+	HitOptions func(*http.Request) *HitOptions
+}
+
+// Middleware returns a http.Handler wrapper that tracks requests using given Tracker.
+// Only successful (2xx) GET requests returning HTML are tracked and the status code is stored with the hit. Requests for static files
+// and paths not matching the MiddlewareRules are skipped. Pass nil to use the default rules.
+// Middleware panics if one of the path patterns is invalid.
+func Middleware(tracker *Tracker, rules *MiddlewareRules) func(http.Handler) http.Handler {
+	if rules == nil {
+		rules = new(MiddlewareRules)
+	}
+
+	include, err := newPathPatterns(rules.Include)
+
+	if err != nil {
+		panic(fmt.Sprintf("pirsch: invalid include pattern: %s", err))
+	}
+
+	exclude, err := newPathPatterns(rules.Exclude)
+
+	if err != nil {
+		panic(fmt.Sprintf("pirsch: invalid exclude pattern: %s", err))
+	}
+
+	extensions := rules.StaticExtensions
+
+	if extensions == nil {
+		extensions = defaultStaticExtensions
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet ||
+				containsString(extensions, strings.ToLower(filepath.Ext(r.URL.Path))) ||
+				len(include) > 0 && !matchAny(include, r.URL.Path) ||
+				matchAny(exclude, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rw := &trackingResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(rw.wrap(), r)
+
+			if rw.status >= 200 && rw.status < 300 && isHTML(rw.contentType) {
+				var options HitOptions
+
+				if rules.HitOptions != nil {
+					if o := rules.HitOptions(r); o != nil {
+						options = *o
+					}
+				}
+
+				options.Status = rw.status
+				go tracker.Hit(r, &options)
+			}
+		})
+	}
+}
+
+// trackingResponseWriter records the status code and content type of a response.
+type trackingResponseWriter struct {
+	http.ResponseWriter
+
+	status      int
+	contentType string
+	sniff       bool
+}
+
+// wrap returns the writer passed to the next handler.
+// It implements http.Flusher and http.Hijacker only if the wrapped http.ResponseWriter does,
+// so that handlers checking for these interfaces (like streaming or websocket handlers) keep working.
+func (w *trackingResponseWriter) wrap() http.ResponseWriter {
+	_, flusher := w.ResponseWriter.(http.Flusher)
+	_, hijacker := w.ResponseWriter.(http.Hijacker)
+
+	switch {
+	case flusher && hijacker:
+		return &trackingFlushHijacker{w}
+	case flusher:
+		return &trackingFlusher{w}
+	case hijacker:
+		return &trackingHijacker{w}
+	}
+
+	return w
+}
+
+// WriteHeader implements the http.ResponseWriter interface.
+func (w *trackingResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.contentType = w.Header().Get("Content-Type")
+
+		// the http package detects the content type on the first write if it has not been set
+		w.sniff = w.contentType == ""
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements the http.ResponseWriter interface.
+func (w *trackingResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.sniff && len(b) > 0 {
+		w.contentType = http.DetectContentType(b)
+		w.sniff = false
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *trackingResponseWriter) flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	// headers are sent without body, so the content type can no longer be detected
+	w.sniff = false
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *trackingResponseWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+type trackingFlusher struct {
+	*trackingResponseWriter
+}
+
+// Flush implements the http.Flusher interface.
+func (w *trackingFlusher) Flush() {
+	w.flush()
+}
+
+type trackingHijacker struct {
+	*trackingResponseWriter
+}
+
+// Hijack implements the http.Hijacker interface.
+func (w *trackingHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+type trackingFlushHijacker struct {
+	*trackingResponseWriter
+}
+
+// Flush implements the http.Flusher interface.
+func (w *trackingFlushHijacker) Flush() {
+	w.flush()
+}
+
+// Hijack implements the http.Hijacker interface.
+func (w *trackingFlushHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+func isHTML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
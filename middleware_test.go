@@ -0,0 +1,151 @@
+package pirsch
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	store := newTestStore()
+	tracker := NewTracker(store, "salt", nil)
+	handler := Middleware(tracker, &MiddlewareRules{
+		Include: []string{"/", "/blog/**", "^/page-[0-9]+$"},
+		Exclude: []string{"/blog/drafts/*"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/not-found":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("<html><body>Not Found</body></html>"))
+		case "/api":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("{}"))
+		case "/empty":
+		case "/blog/status":
+			w.WriteHeader(http.StatusNonAuthoritativeInfo)
+			w.Write([]byte("<html><body>Sniffed</body></html>"))
+		default:
+			w.Write([]byte("<html><body>Hello World!</body></html>"))
+		}
+	}))
+	paths := []string{
+		"/",
+		"/blog/2020/post",
+		"/blog/drafts/post",
+		"/page-42",
+		"/page-x",
+		"/not-found",
+		"/api",
+		"/empty",
+		"/style.css",
+		"/blog/image.png",
+		"/blog/status",
+	}
+
+	for _, path := range paths {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newTrackerTestRequest(path))
+
+		if rec.Code == 0 {
+			t.Fatalf("Request must have been handled for path %v", path)
+		}
+	}
+
+	post := newTrackerTestRequest("/")
+	post.Method = http.MethodPost
+	handler.ServeHTTP(httptest.NewRecorder(), post)
+	waitForHits(tracker, 4)
+	tracker.Stop()
+
+	if len(store.hits) != 4 {
+		t.Fatalf("Four hits must have been tracked, but was: %v", len(store.hits))
+	}
+
+	for _, hit := range store.hits {
+		if hit.Path.String != "/" && hit.Path.String != "/blog/2020/post" && hit.Path.String != "/page-42" && hit.Path.String != "/blog/status" {
+			t.Fatalf("Hit not as expected: %v", hit)
+		}
+
+		status := http.StatusOK
+
+		if hit.Path.String == "/blog/status" {
+			status = http.StatusNonAuthoritativeInfo
+		}
+
+		if hit.Status != status {
+			t.Fatalf("Status for %v must be %v, but was: %v", hit.Path.String, status, hit.Status)
+		}
+	}
+}
+
+func TestMiddlewareDefaultRules(t *testing.T) {
+	store := newTestStore()
+	tracker := NewTracker(store, "salt", nil)
+	handler := Middleware(tracker, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), newTrackerTestRequest("/"))
+	handler.ServeHTTP(httptest.NewRecorder(), newTrackerTestRequest("/script.js"))
+	waitForHits(tracker, 1)
+	tracker.Stop()
+
+	if len(store.hits) != 1 || store.hits[0].Path.String != "/" {
+		t.Fatalf("One hit must have been tracked, but was: %v", store.hits)
+	}
+}
+
+func TestMiddlewareResponseWriterInterfaces(t *testing.T) {
+	tracker := NewTracker(newTestStore(), "salt", nil)
+	defer tracker.Stop()
+	var flusher, hijacker bool
+	handler := Middleware(tracker, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, flusher = w.(http.Flusher)
+		_, hijacker = w.(http.Hijacker)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), newTrackerTestRequest("/"))
+
+	if !flusher || hijacker {
+		t.Fatalf("Only the http.Flusher must have been forwarded, but was: %v %v", flusher, hijacker)
+	}
+
+	handler.ServeHTTP(hijackRecorder{httptest.NewRecorder()}, newTrackerTestRequest("/"))
+
+	if !flusher || !hijacker {
+		t.Fatalf("The http.Flusher and http.Hijacker must have been forwarded, but was: %v %v", flusher, hijacker)
+	}
+
+	handler.ServeHTTP(struct{ http.ResponseWriter }{httptest.NewRecorder()}, newTrackerTestRequest("/"))
+
+	if flusher || hijacker {
+		t.Fatalf("No interfaces must have been forwarded, but was: %v %v", flusher, hijacker)
+	}
+}
+
+func TestMiddlewareInvalidPattern(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("Middleware must panic for invalid patterns")
+		}
+	}()
+
+	Middleware(nil, &MiddlewareRules{Include: []string{"^/invalid["}})
+}
+
+// waitForHits waits for the hits tracked in their own goroutine to be accepted by the Tracker.
+func waitForHits(tracker *Tracker, n int64) {
+	for i := 0; i < 100 && tracker.Stats().Accepted < n; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (rec hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, http.ErrNotSupported
+}
@@ -18,10 +18,28 @@ type Stats struct {
 	Day              time.Time `db:"day" json:"day"`
 	Path             string    `db:"path" json:"path"`
 	Visitors         int       `db:"visitors" json:"visitors"`
+	PageViews        int       `db:"page_views" json:"page_views"`
 	Sessions         int       `db:"sessions" json:"sessions"`
-	Bounces          int       `db:"bounces" json:"bounces"`
 	RelativeVisitors float64   `db:"-" json:"relative_visitors"`
-	BounceRate       float64   `db:"-" json:"bounce_rate"`
+	ViewsPerVisit    float64   `db:"-" json:"views_per_visit"`
+
+	// Bounces is the number of sessions with a single page view and SessionsStarted the number of sessions started on the path.
+	// Like the session duration, bounces are attributed to the path the session started on, so the bounce rate is relative to SessionsStarted.
+	Bounces         int     `db:"bounces" json:"bounces"`
+	SessionsStarted int     `db:"sessions_started" json:"-"`
+	BounceRate      float64 `db:"-" json:"bounce_rate"`
+
+	// SessionDuration is the sum of the session durations in seconds and SessionDurationCount the number of sessions.
+	// Sessions are attributed to the path they started on.
+	SessionDuration        int     `db:"session_duration" json:"-"`
+	SessionDurationCount   int     `db:"session_duration_count" json:"-"`
+	AverageSessionDuration float64 `db:"-" json:"average_session_duration"`
+
+	// TimeOnPage is the sum of the time in seconds spent on the path and TimeOnPageCount the number of page views it was measured for.
+	// The time on page is the time until the next page view in the same session, so it is unknown for the last page of a session.
+	TimeOnPage        int     `db:"time_on_page" json:"-"`
+	TimeOnPageCount   int     `db:"time_on_page_count" json:"-"`
+	AverageTimeOnPage float64 `db:"-" json:"average_time_on_page"`
 }
 
 // GetID returns the ID.
@@ -34,6 +52,46 @@ func (stats *Stats) GetVisitors() int {
 	return stats.Visitors
 }
 
+// GetPageViews returns the page view count.
+func (stats *Stats) GetPageViews() int {
+	return stats.PageViews
+}
+
+// addSessionStats adds the bounces, sessions started, session durations, and times on page of given statistics.
+func (stats *Stats) addSessionStats(other *Stats) {
+	stats.Bounces += other.Bounces
+	stats.SessionsStarted += other.SessionsStarted
+	stats.SessionDuration += other.SessionDuration
+	stats.SessionDurationCount += other.SessionDurationCount
+	stats.TimeOnPage += other.TimeOnPage
+	stats.TimeOnPageCount += other.TimeOnPageCount
+}
+
+// calculateAverageDurations calculates the average session duration and time on page in seconds.
+func (stats *Stats) calculateAverageDurations() {
+	if stats.SessionDurationCount > 0 {
+		stats.AverageSessionDuration = float64(stats.SessionDuration) / float64(stats.SessionDurationCount)
+	}
+
+	if stats.TimeOnPageCount > 0 {
+		stats.AverageTimeOnPage = float64(stats.TimeOnPage) / float64(stats.TimeOnPageCount)
+	}
+}
+
+// calculateBounceRate calculates the bounce rate relative to the number of sessions started on the path.
+func (stats *Stats) calculateBounceRate() {
+	if stats.SessionsStarted > 0 {
+		stats.BounceRate = float64(stats.Bounces) / float64(stats.SessionsStarted)
+	}
+}
+
+// calculateViewsPerVisit calculates the average number of page views per session.
+func (stats *Stats) calculateViewsPerVisit() {
+	if stats.Sessions > 0 {
+		stats.ViewsPerVisit = float64(stats.PageViews) / float64(stats.Sessions)
+	}
+}
+
 // VisitorStats is the visitor count for each path on each day and platform
 // and it is used to calculate the total visitor count for each day.
 type VisitorStats struct {
@@ -68,6 +126,15 @@ type ReferrerStats struct {
 	Referrer sql.NullString `db:"referrer" json:"referrer"`
 }
 
+// ReferrerSourceStats is the visitor count for each referrer source and channel on each day.
+// The name is null for direct visitors.
+type ReferrerSourceStats struct {
+	Stats
+
+	ReferrerName sql.NullString `db:"referrer_name" json:"referrer_name"`
+	Channel      sql.NullString `db:"channel" json:"channel"`
+}
+
 // OSStats is the visitor count for each path on each day and operating system.
 type OSStats struct {
 	Stats
@@ -98,3 +165,115 @@ type CountryStats struct {
 
 	CountryCode sql.NullString `db:"country_code" json:"country_code"`
 }
+
+// EventStats is the visitor and event count and the sum of the values for each path and event on each day.
+type EventStats struct {
+	Stats
+
+	Name         string  `db:"name" json:"name"`
+	Events       int     `db:"events" json:"events"`
+	Value        float64 `db:"value" json:"value"`
+	AverageValue float64 `db:"-" json:"average_value"`
+}
+
+// EventMetadataStats is the visitor and event count for each path, event, and metadata key and value on each day.
+type EventMetadataStats struct {
+	Stats
+
+	Name      string `db:"name" json:"name"`
+	MetaKey   string `db:"meta_key" json:"meta_key"`
+	MetaValue string `db:"meta_value" json:"meta_value"`
+	Events    int    `db:"events" json:"events"`
+}
+
+// GoalStats is the converted visitor count for each goal on each day, grouped by the referrer,
+// country, and browser of the first page visit of the day.
+type GoalStats struct {
+	Stats
+
+	GoalID         int64          `db:"goal_id" json:"goal_id"`
+	Name           string         `db:"name" json:"name"`
+	Referrer       sql.NullString `db:"referrer" json:"referrer"`
+	CountryCode    sql.NullString `db:"country_code" json:"country_code"`
+	Browser        sql.NullString `db:"browser" json:"browser"`
+	ConversionRate float64        `db:"-" json:"conversion_rate"`
+}
+
+// FunnelStats is the visitor count for each step of a funnel on each day.
+// The relative visitors are relative to the first step and the drop off is relative to the previous step.
+type FunnelStats struct {
+	Stats
+
+	FunnelID int64   `db:"funnel_id" json:"funnel_id"`
+	Step     int     `db:"step" json:"step"`
+	Pattern  string  `db:"-" json:"pattern"`
+	DropOff  float64 `db:"-" json:"drop_off"`
+}
+
+// UTMStats is the visitor count for each combination of campaign parameters on each day.
+type UTMStats struct {
+	Stats
+
+	UTMSource   sql.NullString `db:"utm_source" json:"utm_source"`
+	UTMMedium   sql.NullString `db:"utm_medium" json:"utm_medium"`
+	UTMCampaign sql.NullString `db:"utm_campaign" json:"utm_campaign"`
+	UTMTerm     sql.NullString `db:"utm_term" json:"utm_term"`
+	UTMContent  sql.NullString `db:"utm_content" json:"utm_content"`
+}
+
+// EntryStats is the number of sessions started on each path on each day.
+// The visitors are the distinct visitors who started a session on the path.
+type EntryStats struct {
+	Stats
+
+	Entries int `db:"entries" json:"entries"`
+}
+
+// ExitStats is the number of sessions ended on each path on each day.
+// The visitors are the distinct visitors who ended a session on the path and the sessions are all sessions
+// that visited the path, which is used to calculate the exit rate.
+type ExitStats struct {
+	Stats
+
+	Exits    int     `db:"exits" json:"exits"`
+	ExitRate float64 `db:"-" json:"exit_rate"`
+}
+
+// TransitionStats is the number of times visitors navigated from a path to the next path within a session on each day.
+// The visitors are the distinct visitors who made the transition.
+type TransitionStats struct {
+	Stats
+
+	NextPath    string `db:"next_path" json:"next_path"`
+	Transitions int    `db:"transitions" json:"transitions"`
+}
+
+// DimensionStats is the visitor count for each value of a dimension on each day.
+type DimensionStats struct {
+	Stats
+
+	Dimension string         `db:"dimension" json:"dimension"`
+	Value     sql.NullString `db:"value" json:"value"`
+}
+
+// FilterStats is the visitor count for each path, hour, and combination of visitor attributes on each day.
+// It is counted from the hits when filtering the statistics by multiple attributes at once, like visitors from Germany using Firefox.
+type FilterStats struct {
+	Stats
+
+	Hour         int            `db:"hour" json:"hour"`
+	Language     sql.NullString `db:"language" json:"language"`
+	Referrer     sql.NullString `db:"referrer" json:"referrer"`
+	ReferrerName sql.NullString `db:"referrer_name" json:"referrer_name"`
+	Channel      sql.NullString `db:"channel" json:"channel"`
+	OS           sql.NullString `db:"os" json:"os"`
+	Browser      sql.NullString `db:"browser" json:"browser"`
+	CountryCode  sql.NullString `db:"country_code" json:"country_code"`
+	Desktop      bool           `db:"desktop" json:"desktop"`
+	Mobile       bool           `db:"mobile" json:"mobile"`
+	ScreenWidth  int            `db:"screen_width" json:"screen_width"`
+	ScreenHeight int            `db:"screen_height" json:"screen_height"`
+	UTMSource    sql.NullString `db:"utm_source" json:"utm_source"`
+	UTMMedium    sql.NullString `db:"utm_medium" json:"utm_medium"`
+	UTMCampaign  sql.NullString `db:"utm_campaign" json:"utm_campaign"`
+}
@@ -0,0 +1,93 @@
+package pirsch
+
+import (
+	"regexp"
+	"strings"
+)
+
+// pathPattern matches paths using a glob or a regular expression.
+// A pattern starting with ^ is a regular expression. Otherwise it's a glob, where * matches any characters
+// within a path segment, ** matches any characters across segments, and ? matches a single character.
+// Patterns always match the whole path and are case-insensitive.
+type pathPattern struct {
+	pattern string
+	regex   *regexp.Regexp
+}
+
+// newPathPattern compiles given glob or regular expression.
+func newPathPattern(pattern string) (*pathPattern, error) {
+	regex, err := regexp.Compile("(?i)" + pathPatternToRegex(pattern))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &pathPattern{
+		pattern: pattern,
+		regex:   regex,
+	}, nil
+}
+
+// newPathPatterns compiles a list of patterns and returns the first error.
+func newPathPatterns(patterns []string) ([]*pathPattern, error) {
+	compiled := make([]*pathPattern, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		p, err := newPathPattern(pattern)
+
+		if err != nil {
+			return nil, err
+		}
+
+		compiled = append(compiled, p)
+	}
+
+	return compiled, nil
+}
+
+// match returns true if the pattern matches given path.
+func (pattern *pathPattern) match(path string) bool {
+	return pattern.regex.MatchString(path)
+}
+
+// matchAny returns true if any of given patterns matches the path.
+func matchAny(patterns []*pathPattern, path string) bool {
+	for _, pattern := range patterns {
+		if pattern.match(path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pathPatternToRegex returns the regular expression for given pattern without flags.
+// Regular expressions (starting with ^) are anchored at the end, so that they match the whole path as well.
+func pathPatternToRegex(pattern string) string {
+	if strings.HasPrefix(pattern, "^") {
+		return "^(?:" + pattern[1:] + ")$"
+	}
+
+	var sb strings.Builder
+	sb.WriteRune('^')
+	runes := []rune(pattern)
+
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(runes[i])))
+		}
+	}
+
+	sb.WriteRune('$')
+	return sb.String()
+}
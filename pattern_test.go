@@ -0,0 +1,48 @@
+package pirsch
+
+import (
+	"testing"
+)
+
+func TestPathPattern(t *testing.T) {
+	input := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"/", "/", true},
+		{"/", "/foo", false},
+		{"/blog/*", "/blog/post", true},
+		{"/blog/*", "/BLOG/Post", true},
+		{"/blog/*", "/blog/post/comments", false},
+		{"/blog/*", "/blog", false},
+		{"/docs/**", "/docs/a/b/c", true},
+		{"/docs/**", "/documentation", false},
+		{"/page-?", "/page-1", true},
+		{"/page-?", "/page-10", false},
+		{"/file.html", "/fileXhtml", false},
+		{"^/blog/[0-9]+$", "/blog/42", true},
+		{"^/blog/[0-9]+$", "/blog/post", false},
+		{"^/blog", "/blog", true},
+		{"^/blog", "/blog-old/x", false},
+		{"^/blog/.*", "/blog/post", true},
+		{"^/blog|/news", "/archive/news", false},
+		{"^/blog|/news", "/news", true},
+	}
+
+	for _, in := range input {
+		pattern, err := newPathPattern(in.pattern)
+
+		if err != nil {
+			t.Fatalf("Pattern %v must have been compiled, but was: %v", in.pattern, err)
+		}
+
+		if pattern.match(in.path) != in.match {
+			t.Fatalf("Pattern %v must return %v for %v", in.pattern, in.match, in.path)
+		}
+	}
+
+	if _, err := newPathPattern("^/invalid["); err == nil {
+		t.Fatal("Invalid regular expression must return an error")
+	}
+}
@@ -2,8 +2,10 @@ package pirsch
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"log"
 	"os"
 	"strings"
@@ -12,8 +14,40 @@ import (
 
 const (
 	logPrefix = "[pirsch] "
+
+	// hitColumns is the number of columns inserted for each hit.
+	hitColumns = 26
+
+	// hitArchiveColumns are the columns copied to the "hit_archive" table and read from both, the "hit" and "hit_archive" table.
+	hitArchiveColumns = `id, tenant_id, fingerprint, session, path, url, language, user_agent, referrer, referrer_name, channel, os, os_version, browser, browser_version, country_code, desktop, mobile, screen_width, screen_height, utm_source, utm_medium, utm_campaign, utm_term, utm_content, status, time`
+
+	// eventArchiveColumns are the columns copied to the "event_archive" table and read from both, the "event" and "event_archive" table.
+	eventArchiveColumns = `id, tenant_id, fingerprint, session, path, name, meta_keys, meta_values, value, time`
 )
 
+var errFilterStatsColumn = errors.New("unknown column to group filter statistics by")
+
+// filterStatsColumns are the columns FilterStats can be grouped by and their expressions on the "hit" table.
+var filterStatsColumns = map[string]string{
+	"day":           `"time"::date`,
+	"hour":          `EXTRACT(HOUR FROM "time")::integer`,
+	"path":          `COALESCE("path", '')`,
+	"language":      `"language"`,
+	"referrer":      `"referrer"`,
+	"referrer_name": `"referrer_name"`,
+	"channel":       `"channel"`,
+	"os":            `"os"`,
+	"browser":       `"browser"`,
+	"country_code":  `"country_code"`,
+	"desktop":       `"desktop"`,
+	"mobile":        `"mobile"`,
+	"screen_width":  `"screen_width"`,
+	"screen_height": `"screen_height"`,
+	"utm_source":    `"utm_source"`,
+	"utm_medium":    `"utm_medium"`,
+	"utm_campaign":  `"utm_campaign"`,
+}
+
 // statsEntity is an interface for all statistics entities.
 // This is used to simplify saving entities in the database.
 type statsEntity interface {
@@ -22,6 +56,9 @@ type statsEntity interface {
 
 	// GetVisitors returns the visitor count.
 	GetVisitors() int
+
+	// GetPageViews returns the page view count.
+	GetPageViews() int
 }
 
 // PostgresConfig is the optional configuration for the PostgresStore.
@@ -78,9 +115,9 @@ func (store *PostgresStore) Rollback(tx *sqlx.Tx) {
 
 // SaveHits implements the Store interface.
 func (store *PostgresStore) SaveHits(hits []Hit) error {
-	args := make([]interface{}, 0, len(hits)*18)
+	args := make([]interface{}, 0, len(hits)*hitColumns)
 	var query strings.Builder
-	query.WriteString(`INSERT INTO "hit" (tenant_id, fingerprint, session, path, url, language, user_agent, referrer, os, os_version, browser, browser_version, country_code, desktop, mobile, screen_width, screen_height, time) VALUES `)
+	query.WriteString(`INSERT INTO "hit" (tenant_id, fingerprint, session, path, url, language, user_agent, referrer, referrer_name, channel, os, os_version, browser, browser_version, country_code, desktop, mobile, screen_width, screen_height, utm_source, utm_medium, utm_campaign, utm_term, utm_content, status, time) VALUES `)
 
 	for i, hit := range hits {
 		args = append(args, hit.TenantID)
@@ -91,6 +128,8 @@ func (store *PostgresStore) SaveHits(hits []Hit) error {
 		args = append(args, hit.Language)
 		args = append(args, hit.UserAgent)
 		args = append(args, hit.Referrer)
+		args = append(args, hit.ReferrerName)
+		args = append(args, hit.Channel)
 		args = append(args, hit.OS)
 		args = append(args, hit.OSVersion)
 		args = append(args, hit.Browser)
@@ -100,10 +139,14 @@ func (store *PostgresStore) SaveHits(hits []Hit) error {
 		args = append(args, hit.Mobile)
 		args = append(args, hit.ScreenWidth)
 		args = append(args, hit.ScreenHeight)
+		args = append(args, hit.UTMSource)
+		args = append(args, hit.UTMMedium)
+		args = append(args, hit.UTMCampaign)
+		args = append(args, hit.UTMTerm)
+		args = append(args, hit.UTMContent)
+		args = append(args, hit.Status)
 		args = append(args, hit.Time)
-		index := i * 18
-		query.WriteString(fmt.Sprintf(`($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d),`,
-			index+1, index+2, index+3, index+4, index+5, index+6, index+7, index+8, index+9, index+10, index+11, index+12, index+13, index+14, index+15, index+16, index+17, index+18))
+		writeValuePlaceholders(&query, i*hitColumns, hitColumns)
 	}
 
 	queryStr := query.String()
@@ -137,6 +180,309 @@ func (store *PostgresStore) DeleteHitsByDay(tx *sqlx.Tx, tenantID sql.NullInt64,
 	return nil
 }
 
+// ArchiveHitsByDay implements the Store interface.
+func (store *PostgresStore) ArchiveHitsByDay(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	query := fmt.Sprintf(`INSERT INTO "hit_archive" (%[1]s)
+		SELECT %[1]s FROM "hit"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND time >= $2
+		AND time < $2 + INTERVAL '1 day'`, hitArchiveColumns)
+
+	_, err := tx.Exec(query, tenantID, day)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteArchivedHitsBefore implements the Store interface.
+func (store *PostgresStore) DeleteArchivedHitsBefore(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	query := `DELETE FROM "hit_archive"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND time < $2`
+
+	_, err := tx.Exec(query, tenantID, day)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveEvents implements the Store interface.
+func (store *PostgresStore) SaveEvents(events []Event) error {
+	args := make([]interface{}, 0, len(events)*9)
+	var query strings.Builder
+	query.WriteString(`INSERT INTO "event" (tenant_id, fingerprint, session, path, name, meta_keys, meta_values, value, time) VALUES `)
+
+	for i, event := range events {
+		args = append(args, event.TenantID)
+		args = append(args, event.Fingerprint)
+		args = append(args, event.Session)
+		args = append(args, event.Path)
+		args = append(args, event.Name)
+		args = append(args, event.MetaKeys)
+		args = append(args, event.MetaValues)
+		args = append(args, event.Value)
+		args = append(args, event.Time)
+		index := i * 9
+		query.WriteString(fmt.Sprintf(`($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d),`,
+			index+1, index+2, index+3, index+4, index+5, index+6, index+7, index+8, index+9))
+	}
+
+	queryStr := query.String()
+	_, err := store.DB.Exec(queryStr[:len(queryStr)-1], args...)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteEventsByDay implements the Store interface.
+func (store *PostgresStore) DeleteEventsByDay(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	query := `DELETE FROM "event"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND time >= $2
+		AND time < $2 + INTERVAL '1 day'`
+
+	_, err := tx.Exec(query, tenantID, day)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ArchiveEventsByDay implements the Store interface.
+func (store *PostgresStore) ArchiveEventsByDay(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	query := fmt.Sprintf(`INSERT INTO "event_archive" (%[1]s)
+		SELECT %[1]s FROM "event"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND time >= $2
+		AND time < $2 + INTERVAL '1 day'`, eventArchiveColumns)
+
+	_, err := tx.Exec(query, tenantID, day)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteArchivedEventsBefore implements the Store interface.
+func (store *PostgresStore) DeleteArchivedEventsBefore(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	query := `DELETE FROM "event_archive"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND time < $2`
+
+	_, err := tx.Exec(query, tenantID, day)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveGoal implements the Store interface.
+// The goal is validated before it is saved. A new goal will have its ID set afterwards.
+func (store *PostgresStore) SaveGoal(goal *Goal) error {
+	if err := goal.Validate(); err != nil {
+		return err
+	}
+
+	if goal.ID == 0 {
+		query := `INSERT INTO "goal" ("tenant_id", "name", "path_pattern", "event_name") VALUES ($1, $2, $3, $4) RETURNING "id"`
+		return store.DB.Get(&goal.ID, query, goal.TenantID, goal.Name, goal.PathPattern, goal.EventName)
+	}
+
+	query := `UPDATE "goal" SET "name" = $3, "path_pattern" = $4, "event_name" = $5
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND id = $2`
+
+	if _, err := store.DB.Exec(query, goal.TenantID, goal.ID, goal.Name, goal.PathPattern, goal.EventName); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteGoal implements the Store interface.
+func (store *PostgresStore) DeleteGoal(tenantID sql.NullInt64, id int64) error {
+	tx := store.NewTx()
+	query := `DELETE FROM "goal_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND goal_id = $2`
+
+	if _, err := tx.Exec(query, tenantID, id); err != nil {
+		store.Rollback(tx)
+		return err
+	}
+
+	query = `DELETE FROM "goal"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND id = $2`
+
+	if _, err := tx.Exec(query, tenantID, id); err != nil {
+		store.Rollback(tx)
+		return err
+	}
+
+	store.Commit(tx)
+	return nil
+}
+
+// Goals implements the Store interface.
+func (store *PostgresStore) Goals(tenantID sql.NullInt64) ([]Goal, error) {
+	query := `SELECT * FROM "goal" WHERE ($1::bigint IS NULL OR tenant_id = $1) ORDER BY "name" ASC, "id" ASC`
+	var goals []Goal
+
+	if err := store.DB.Select(&goals, query, tenantID); err != nil {
+		return nil, err
+	}
+
+	return goals, nil
+}
+
+// SaveFunnel implements the Store interface.
+// The funnel is validated before it is saved. A new funnel will have its ID set afterwards.
+func (store *PostgresStore) SaveFunnel(funnel *Funnel) error {
+	if err := funnel.Validate(); err != nil {
+		return err
+	}
+
+	if funnel.ID == 0 {
+		query := `INSERT INTO "funnel" ("tenant_id", "name", "steps") VALUES ($1, $2, $3) RETURNING "id"`
+		return store.DB.Get(&funnel.ID, query, funnel.TenantID, funnel.Name, funnel.Steps)
+	}
+
+	query := `UPDATE "funnel" SET "name" = $3, "steps" = $4
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND id = $2`
+
+	if _, err := store.DB.Exec(query, funnel.TenantID, funnel.ID, funnel.Name, funnel.Steps); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteFunnel implements the Store interface.
+func (store *PostgresStore) DeleteFunnel(tenantID sql.NullInt64, id int64) error {
+	tx := store.NewTx()
+	query := `DELETE FROM "funnel_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND funnel_id = $2`
+
+	if _, err := tx.Exec(query, tenantID, id); err != nil {
+		store.Rollback(tx)
+		return err
+	}
+
+	query = `DELETE FROM "funnel"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND id = $2`
+
+	if _, err := tx.Exec(query, tenantID, id); err != nil {
+		store.Rollback(tx)
+		return err
+	}
+
+	store.Commit(tx)
+	return nil
+}
+
+// Funnels implements the Store interface.
+func (store *PostgresStore) Funnels(tenantID sql.NullInt64) ([]Funnel, error) {
+	query := `SELECT * FROM "funnel" WHERE ($1::bigint IS NULL OR tenant_id = $1) ORDER BY "name" ASC, "id" ASC`
+	var funnels []Funnel
+
+	if err := store.DB.Select(&funnels, query, tenantID); err != nil {
+		return nil, err
+	}
+
+	return funnels, nil
+}
+
+// SaveContentGroup implements the Store interface.
+func (store *PostgresStore) SaveContentGroup(group *ContentGroup) error {
+	if err := group.Validate(); err != nil {
+		return err
+	}
+
+	if group.ID == 0 {
+		query := `INSERT INTO "content_group" ("tenant_id", "name", "patterns") VALUES ($1, $2, $3) RETURNING "id"`
+		return store.DB.Get(&group.ID, query, group.TenantID, group.Name, group.Patterns)
+	}
+
+	query := `UPDATE "content_group" SET "name" = $3, "patterns" = $4
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND id = $2`
+
+	if _, err := store.DB.Exec(query, group.TenantID, group.ID, group.Name, group.Patterns); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteContentGroup implements the Store interface.
+func (store *PostgresStore) DeleteContentGroup(tenantID sql.NullInt64, id int64) error {
+	query := `DELETE FROM "content_group"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND id = $2`
+
+	if _, err := store.DB.Exec(query, tenantID, id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContentGroups implements the Store interface.
+func (store *PostgresStore) ContentGroups(tenantID sql.NullInt64) ([]ContentGroup, error) {
+	query := `SELECT * FROM "content_group" WHERE ($1::bigint IS NULL OR tenant_id = $1) ORDER BY "name" ASC, "id" ASC`
+	var groups []ContentGroup
+
+	if err := store.DB.Select(&groups, query, tenantID); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
 // SaveVisitorStats implements the Store interface.
 func (store *PostgresStore) SaveVisitorStats(tx *sqlx.Tx, entity *VisitorStats) error {
 	if tx == nil {
@@ -145,31 +491,38 @@ func (store *PostgresStore) SaveVisitorStats(tx *sqlx.Tx, entity *VisitorStats)
 	}
 
 	existing := new(VisitorStats)
-	err := tx.Get(existing, `SELECT id, visitors, sessions, bounces, platform_desktop, platform_mobile, platform_unknown FROM "visitor_stats"
+	err := tx.Get(existing, `SELECT id, visitors, page_views, sessions, bounces, sessions_started, platform_desktop, platform_mobile, platform_unknown, session_duration, session_duration_count, time_on_page, time_on_page_count FROM "visitor_stats"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
 		AND "day" = $2
 		AND LOWER("path") = LOWER($3)`, entity.TenantID, entity.Day, entity.Path)
 
 	if err == nil {
 		existing.Visitors += entity.Visitors
+		existing.PageViews += entity.PageViews
 		existing.Sessions += entity.Sessions
-		existing.Bounces += entity.Bounces
 		existing.PlatformDesktop += entity.PlatformDesktop
 		existing.PlatformMobile += entity.PlatformMobile
 		existing.PlatformUnknown += entity.PlatformUnknown
+		existing.addSessionStats(&entity.Stats)
 
-		if _, err := tx.Exec(`UPDATE "visitor_stats" SET "visitors" = $1, "sessions" = $2, "bounces" = $3, "platform_desktop" = $4, "platform_mobile" = $5, "platform_unknown" = $6 WHERE id = $7`,
+		if _, err := tx.Exec(`UPDATE "visitor_stats" SET "visitors" = $1, "page_views" = $2, "sessions" = $3, "bounces" = $4, "sessions_started" = $5, "platform_desktop" = $6, "platform_mobile" = $7, "platform_unknown" = $8, "session_duration" = $9, "session_duration_count" = $10, "time_on_page" = $11, "time_on_page_count" = $12 WHERE id = $13`,
 			existing.Visitors,
+			existing.PageViews,
 			existing.Sessions,
 			existing.Bounces,
+			existing.SessionsStarted,
 			existing.PlatformDesktop,
 			existing.PlatformMobile,
 			existing.PlatformUnknown,
+			existing.SessionDuration,
+			existing.SessionDurationCount,
+			existing.TimeOnPage,
+			existing.TimeOnPageCount,
 			existing.ID); err != nil {
 			return err
 		}
 	} else {
-		rows, err := tx.NamedQuery(`INSERT INTO "visitor_stats" ("tenant_id", "day", "path", "visitors", "sessions", "bounces", "platform_desktop", "platform_mobile", "platform_unknown") VALUES (:tenant_id, :day, :path, :visitors, :sessions, :bounces, :platform_desktop, :platform_mobile, :platform_unknown)`, entity)
+		rows, err := tx.NamedQuery(`INSERT INTO "visitor_stats" ("tenant_id", "day", "path", "visitors", "page_views", "sessions", "bounces", "sessions_started", "platform_desktop", "platform_mobile", "platform_unknown", "session_duration", "session_duration_count", "time_on_page", "time_on_page_count") VALUES (:tenant_id, :day, :path, :visitors, :page_views, :sessions, :bounces, :sessions_started, :platform_desktop, :platform_mobile, :platform_unknown, :session_duration, :session_duration_count, :time_on_page, :time_on_page_count)`, entity)
 
 		if err != nil {
 			return err
@@ -189,7 +542,7 @@ func (store *PostgresStore) SaveVisitorTimeStats(tx *sqlx.Tx, entity *VisitorTim
 	}
 
 	existing := new(VisitorTimeStats)
-	err := tx.Get(existing, `SELECT id, visitors, sessions FROM "visitor_time_stats"
+	err := tx.Get(existing, `SELECT id, visitors, page_views, sessions FROM "visitor_time_stats"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
 		AND "day" = $2
 		AND LOWER("path") = LOWER($3)
@@ -197,16 +550,18 @@ func (store *PostgresStore) SaveVisitorTimeStats(tx *sqlx.Tx, entity *VisitorTim
 
 	if err == nil {
 		existing.Visitors += entity.Visitors
+		existing.PageViews += entity.PageViews
 		existing.Sessions += entity.Sessions
 
-		if _, err := tx.Exec(`UPDATE "visitor_time_stats" SET "visitors" = $1, sessions = $2 WHERE id = $3`,
+		if _, err := tx.Exec(`UPDATE "visitor_time_stats" SET "visitors" = $1, "page_views" = $2, sessions = $3 WHERE id = $4`,
 			existing.Visitors,
+			existing.PageViews,
 			existing.Sessions,
 			existing.ID); err != nil {
 			return err
 		}
 	} else {
-		rows, err := tx.NamedQuery(`INSERT INTO "visitor_time_stats" ("tenant_id", "day", "path", "hour", "visitors", "sessions") VALUES (:tenant_id, :day, :path, :hour, :visitors, :sessions)`, entity)
+		rows, err := tx.NamedQuery(`INSERT INTO "visitor_time_stats" ("tenant_id", "day", "path", "hour", "visitors", "page_views", "sessions") VALUES (:tenant_id, :day, :path, :hour, :visitors, :page_views, :sessions)`, entity)
 
 		if err != nil {
 			return err
@@ -226,15 +581,15 @@ func (store *PostgresStore) SaveLanguageStats(tx *sqlx.Tx, entity *LanguageStats
 	}
 
 	existing := new(LanguageStats)
-	err := tx.Get(existing, `SELECT id, visitors FROM "language_stats"
+	err := tx.Get(existing, `SELECT id, visitors, page_views FROM "language_stats"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
 		AND "day" = $2
 		AND LOWER("path") = LOWER($3)
 		AND LOWER("language") = LOWER($4)`, entity.TenantID, entity.Day, entity.Path, entity.Language)
 
 	if err := store.createUpdateEntity(tx, entity, existing, err == nil,
-		`INSERT INTO "language_stats" ("tenant_id", "day", "path", "language", "visitors") VALUES (:tenant_id, :day, :path, :language, :visitors)`,
-		`UPDATE "language_stats" SET "visitors" = $1 WHERE id = $2`); err != nil {
+		`INSERT INTO "language_stats" ("tenant_id", "day", "path", "language", "visitors", "page_views") VALUES (:tenant_id, :day, :path, :language, :visitors, :page_views)`,
+		`UPDATE "language_stats" SET "visitors" = $1, "page_views" = $2 WHERE id = $3`); err != nil {
 		return err
 	}
 
@@ -249,15 +604,38 @@ func (store *PostgresStore) SaveReferrerStats(tx *sqlx.Tx, entity *ReferrerStats
 	}
 
 	existing := new(ReferrerStats)
-	err := tx.Get(existing, `SELECT id, visitors FROM "referrer_stats"
+	err := tx.Get(existing, `SELECT id, visitors, page_views FROM "referrer_stats"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
 		AND "day" = $2
 		AND LOWER("path") = LOWER($3)
 		AND LOWER("referrer") = LOWER($4)`, entity.TenantID, entity.Day, entity.Path, entity.Referrer)
 
 	if err := store.createUpdateEntity(tx, entity, existing, err == nil,
-		`INSERT INTO "referrer_stats" ("tenant_id", "day", "path", "referrer", "visitors") VALUES (:tenant_id, :day, :path, :referrer, :visitors)`,
-		`UPDATE "referrer_stats" SET "visitors" = $1 WHERE id = $2`); err != nil {
+		`INSERT INTO "referrer_stats" ("tenant_id", "day", "path", "referrer", "visitors", "page_views") VALUES (:tenant_id, :day, :path, :referrer, :visitors, :page_views)`,
+		`UPDATE "referrer_stats" SET "visitors" = $1, "page_views" = $2 WHERE id = $3`); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveReferrerSourceStats implements the Store interface.
+func (store *PostgresStore) SaveReferrerSourceStats(tx *sqlx.Tx, entity *ReferrerSourceStats) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	existing := new(ReferrerSourceStats)
+	err := tx.Get(existing, `SELECT id, visitors, page_views FROM "referrer_source_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" = $2
+		AND "referrer_name" IS NOT DISTINCT FROM $3
+		AND "channel" IS NOT DISTINCT FROM $4`, entity.TenantID, entity.Day, entity.ReferrerName, entity.Channel)
+
+	if err := store.createUpdateEntity(tx, entity, existing, err == nil,
+		`INSERT INTO "referrer_source_stats" ("tenant_id", "day", "referrer_name", "channel", "visitors", "page_views") VALUES (:tenant_id, :day, :referrer_name, :channel, :visitors, :page_views)`,
+		`UPDATE "referrer_source_stats" SET "visitors" = $1, "page_views" = $2 WHERE id = $3`); err != nil {
 		return err
 	}
 
@@ -272,7 +650,7 @@ func (store *PostgresStore) SaveOSStats(tx *sqlx.Tx, entity *OSStats) error {
 	}
 
 	existing := new(OSStats)
-	err := tx.Get(existing, `SELECT id, visitors FROM "os_stats"
+	err := tx.Get(existing, `SELECT id, visitors, page_views FROM "os_stats"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
 		AND "day" = $2
 		AND LOWER("path") = LOWER($3)
@@ -280,8 +658,8 @@ func (store *PostgresStore) SaveOSStats(tx *sqlx.Tx, entity *OSStats) error {
 		AND "os_version" = $5`, entity.TenantID, entity.Day, entity.Path, entity.OS, entity.OSVersion)
 
 	if err := store.createUpdateEntity(tx, entity, existing, err == nil,
-		`INSERT INTO "os_stats" ("tenant_id", "day", "path", "os", "os_version", "visitors") VALUES (:tenant_id, :day, :path, :os, :os_version, :visitors)`,
-		`UPDATE "os_stats" SET "visitors" = $1 WHERE id = $2`); err != nil {
+		`INSERT INTO "os_stats" ("tenant_id", "day", "path", "os", "os_version", "visitors", "page_views") VALUES (:tenant_id, :day, :path, :os, :os_version, :visitors, :page_views)`,
+		`UPDATE "os_stats" SET "visitors" = $1, "page_views" = $2 WHERE id = $3`); err != nil {
 		return err
 	}
 
@@ -296,7 +674,7 @@ func (store *PostgresStore) SaveBrowserStats(tx *sqlx.Tx, entity *BrowserStats)
 	}
 
 	existing := new(BrowserStats)
-	err := tx.Get(existing, `SELECT id, visitors FROM "browser_stats"
+	err := tx.Get(existing, `SELECT id, visitors, page_views FROM "browser_stats"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
 		AND "day" = $2
 		AND LOWER("path") = LOWER($3)
@@ -304,8 +682,8 @@ func (store *PostgresStore) SaveBrowserStats(tx *sqlx.Tx, entity *BrowserStats)
 		AND "browser_version" = $5`, entity.TenantID, entity.Day, entity.Path, entity.Browser, entity.BrowserVersion)
 
 	if err := store.createUpdateEntity(tx, entity, existing, err == nil,
-		`INSERT INTO "browser_stats" ("tenant_id", "day", "path", "browser", "browser_version", "visitors") VALUES (:tenant_id, :day, :path, :browser, :browser_version, :visitors)`,
-		`UPDATE "browser_stats" SET "visitors" = $1 WHERE id = $2`); err != nil {
+		`INSERT INTO "browser_stats" ("tenant_id", "day", "path", "browser", "browser_version", "visitors", "page_views") VALUES (:tenant_id, :day, :path, :browser, :browser_version, :visitors, :page_views)`,
+		`UPDATE "browser_stats" SET "visitors" = $1, "page_views" = $2 WHERE id = $3`); err != nil {
 		return err
 	}
 
@@ -320,15 +698,15 @@ func (store *PostgresStore) SaveScreenStats(tx *sqlx.Tx, entity *ScreenStats) er
 	}
 
 	existing := new(ScreenStats)
-	err := tx.Get(existing, `SELECT id, visitors FROM "screen_stats"
+	err := tx.Get(existing, `SELECT id, visitors, page_views FROM "screen_stats"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
 		AND "day" = $2
 		AND "width" = $3
 		AND "height" = $4`, entity.TenantID, entity.Day, entity.Width, entity.Height)
 
 	if err := store.createUpdateEntity(tx, entity, existing, err == nil,
-		`INSERT INTO "screen_stats" ("tenant_id", "day", "width", "height", "visitors") VALUES (:tenant_id, :day, :width, :height, :visitors)`,
-		`UPDATE "screen_stats" SET "visitors" = $1 WHERE id = $2`); err != nil {
+		`INSERT INTO "screen_stats" ("tenant_id", "day", "width", "height", "visitors", "page_views") VALUES (:tenant_id, :day, :width, :height, :visitors, :page_views)`,
+		`UPDATE "screen_stats" SET "visitors" = $1, "page_views" = $2 WHERE id = $3`); err != nil {
 		return err
 	}
 
@@ -343,62 +721,389 @@ func (store *PostgresStore) SaveCountryStats(tx *sqlx.Tx, entity *CountryStats)
 	}
 
 	existing := new(CountryStats)
-	err := tx.Get(existing, `SELECT id, visitors FROM "country_stats"
+	err := tx.Get(existing, `SELECT id, visitors, page_views FROM "country_stats"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
 		AND "day" = $2
 		AND "country_code" = $3`, entity.TenantID, entity.Day, entity.CountryCode)
 
 	if err := store.createUpdateEntity(tx, entity, existing, err == nil,
-		`INSERT INTO "country_stats" ("tenant_id", "day", "country_code", "visitors") VALUES (:tenant_id, :day, :country_code, :visitors)`,
-		`UPDATE "country_stats" SET "visitors" = $1 WHERE id = $2`); err != nil {
+		`INSERT INTO "country_stats" ("tenant_id", "day", "country_code", "visitors", "page_views") VALUES (:tenant_id, :day, :country_code, :visitors, :page_views)`,
+		`UPDATE "country_stats" SET "visitors" = $1, "page_views" = $2 WHERE id = $3`); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// Session implements the Store interface.
-func (store *PostgresStore) Session(tenantID sql.NullInt64, fingerprint string, maxAge time.Time) time.Time {
-	query := `SELECT "session"
-		FROM "hit"
-		WHERE ($1::bigint IS NULL OR tenant_id = $1)
-		AND fingerprint = $2
-	    AND "time" > $3 LIMIT 1`
-	var session time.Time
-
-	if err := store.DB.Get(&session, query, tenantID, fingerprint, maxAge); err != nil && err != sql.ErrNoRows {
-		store.logger.Printf("error reading session timestamp: %s", err)
+// SaveEventStats implements the Store interface.
+func (store *PostgresStore) SaveEventStats(tx *sqlx.Tx, entity *EventStats) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
 	}
 
-	return session
-}
-
-// HitDays implements the Store interface.
-func (store *PostgresStore) HitDays(tenantID sql.NullInt64) ([]time.Time, error) {
-	query := `SELECT DISTINCT date("time") AS "day"
-		FROM "hit"
+	existing := new(EventStats)
+	err := tx.Get(existing, `SELECT id, visitors, events, value FROM "event_stats"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
-		AND date("time") < current_date AT TIME ZONE 'UTC'
-		ORDER BY "day" ASC`
-	var days []time.Time
+		AND "day" = $2
+		AND LOWER("path") = LOWER($3)
+		AND "name" = $4`, entity.TenantID, entity.Day, entity.Path, entity.Name)
 
-	if err := store.DB.Select(&days, query, tenantID); err != nil {
-		return nil, err
-	}
+	if err == nil {
+		existing.Visitors += entity.Visitors
+		existing.Events += entity.Events
+		existing.Value += entity.Value
 
-	return days, nil
-}
+		if _, err := tx.Exec(`UPDATE "event_stats" SET "visitors" = $1, "events" = $2, "value" = $3 WHERE id = $4`,
+			existing.Visitors,
+			existing.Events,
+			existing.Value,
+			existing.ID); err != nil {
+			return err
+		}
+	} else {
+		rows, err := tx.NamedQuery(`INSERT INTO "event_stats" ("tenant_id", "day", "path", "name", "visitors", "events", "value") VALUES (:tenant_id, :day, :path, :name, :visitors, :events, :value)`, entity)
 
-// HitPaths implements the Store interface.
-func (store *PostgresStore) HitPaths(tenantID sql.NullInt64, day time.Time) ([]string, error) {
-	query := `SELECT DISTINCT "path" FROM "hit" WHERE ($1::bigint IS NULL OR tenant_id = $1) AND date("time") = $2 ORDER BY "path" ASC`
-	var paths []string
+		if err != nil {
+			return err
+		}
 
-	if err := store.DB.Select(&paths, query, tenantID, day); err != nil {
-		return nil, err
+		store.closeRows(rows)
 	}
 
-	return paths, nil
+	return nil
+}
+
+// SaveEventMetadataStats implements the Store interface.
+func (store *PostgresStore) SaveEventMetadataStats(tx *sqlx.Tx, entity *EventMetadataStats) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	existing := new(EventMetadataStats)
+	err := tx.Get(existing, `SELECT id, visitors, events FROM "event_metadata_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" = $2
+		AND LOWER("path") = LOWER($3)
+		AND "name" = $4
+		AND "meta_key" = $5
+		AND "meta_value" = $6`, entity.TenantID, entity.Day, entity.Path, entity.Name, entity.MetaKey, entity.MetaValue)
+
+	if err == nil {
+		existing.Visitors += entity.Visitors
+		existing.Events += entity.Events
+
+		if _, err := tx.Exec(`UPDATE "event_metadata_stats" SET "visitors" = $1, "events" = $2 WHERE id = $3`,
+			existing.Visitors,
+			existing.Events,
+			existing.ID); err != nil {
+			return err
+		}
+	} else {
+		rows, err := tx.NamedQuery(`INSERT INTO "event_metadata_stats" ("tenant_id", "day", "path", "name", "meta_key", "meta_value", "visitors", "events") VALUES (:tenant_id, :day, :path, :name, :meta_key, :meta_value, :visitors, :events)`, entity)
+
+		if err != nil {
+			return err
+		}
+
+		store.closeRows(rows)
+	}
+
+	return nil
+}
+
+// SaveUTMStats implements the Store interface.
+func (store *PostgresStore) SaveUTMStats(tx *sqlx.Tx, entity *UTMStats) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	existing := new(UTMStats)
+	err := tx.Get(existing, `SELECT id, visitors, page_views FROM "utm_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" = $2
+		AND "utm_source" IS NOT DISTINCT FROM $3
+		AND "utm_medium" IS NOT DISTINCT FROM $4
+		AND "utm_campaign" IS NOT DISTINCT FROM $5
+		AND "utm_term" IS NOT DISTINCT FROM $6
+		AND "utm_content" IS NOT DISTINCT FROM $7`, entity.TenantID, entity.Day, entity.UTMSource, entity.UTMMedium, entity.UTMCampaign, entity.UTMTerm, entity.UTMContent)
+
+	if err := store.createUpdateEntity(tx, entity, existing, err == nil,
+		`INSERT INTO "utm_stats" ("tenant_id", "day", "utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content", "visitors", "page_views") VALUES (:tenant_id, :day, :utm_source, :utm_medium, :utm_campaign, :utm_term, :utm_content, :visitors, :page_views)`,
+		`UPDATE "utm_stats" SET "visitors" = $1, "page_views" = $2 WHERE id = $3`); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveGoalStats implements the Store interface.
+func (store *PostgresStore) SaveGoalStats(tx *sqlx.Tx, entity *GoalStats) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	existing := new(GoalStats)
+	err := tx.Get(existing, `SELECT id, visitors FROM "goal_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" = $2
+		AND "goal_id" = $3
+		AND "referrer" IS NOT DISTINCT FROM $4
+		AND "country_code" IS NOT DISTINCT FROM $5
+		AND "browser" IS NOT DISTINCT FROM $6`, entity.TenantID, entity.Day, entity.GoalID, entity.Referrer, entity.CountryCode, entity.Browser)
+
+	if err := store.createUpdateEntity(tx, entity, existing, err == nil,
+		`INSERT INTO "goal_stats" ("tenant_id", "day", "goal_id", "referrer", "country_code", "browser", "visitors") VALUES (:tenant_id, :day, :goal_id, :referrer, :country_code, :browser, :visitors)`,
+		`UPDATE "goal_stats" SET "visitors" = $1 WHERE id = $2`); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveFunnelStats implements the Store interface.
+func (store *PostgresStore) SaveFunnelStats(tx *sqlx.Tx, entity *FunnelStats) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	existing := new(FunnelStats)
+	err := tx.Get(existing, `SELECT id, visitors FROM "funnel_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" = $2
+		AND "funnel_id" = $3
+		AND "step" = $4`, entity.TenantID, entity.Day, entity.FunnelID, entity.Step)
+
+	if err := store.createUpdateEntity(tx, entity, existing, err == nil,
+		`INSERT INTO "funnel_stats" ("tenant_id", "day", "funnel_id", "step", "visitors") VALUES (:tenant_id, :day, :funnel_id, :step, :visitors)`,
+		`UPDATE "funnel_stats" SET "visitors" = $1 WHERE id = $2`); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveEntryStats implements the Store interface.
+func (store *PostgresStore) SaveEntryStats(tx *sqlx.Tx, entity *EntryStats) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	existing := new(EntryStats)
+	err := tx.Get(existing, `SELECT id, visitors, entries FROM "entry_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" = $2
+		AND LOWER("path") = LOWER($3)`, entity.TenantID, entity.Day, entity.Path)
+
+	if err == nil {
+		existing.Visitors += entity.Visitors
+		existing.Entries += entity.Entries
+
+		if _, err := tx.Exec(`UPDATE "entry_stats" SET "visitors" = $1, "entries" = $2 WHERE id = $3`,
+			existing.Visitors,
+			existing.Entries,
+			existing.ID); err != nil {
+			return err
+		}
+	} else {
+		rows, err := tx.NamedQuery(`INSERT INTO "entry_stats" ("tenant_id", "day", "path", "visitors", "entries") VALUES (:tenant_id, :day, :path, :visitors, :entries)`, entity)
+
+		if err != nil {
+			return err
+		}
+
+		store.closeRows(rows)
+	}
+
+	return nil
+}
+
+// SaveExitStats implements the Store interface.
+func (store *PostgresStore) SaveExitStats(tx *sqlx.Tx, entity *ExitStats) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	existing := new(ExitStats)
+	err := tx.Get(existing, `SELECT id, visitors, sessions, exits FROM "exit_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" = $2
+		AND LOWER("path") = LOWER($3)`, entity.TenantID, entity.Day, entity.Path)
+
+	if err == nil {
+		existing.Visitors += entity.Visitors
+		existing.Sessions += entity.Sessions
+		existing.Exits += entity.Exits
+
+		if _, err := tx.Exec(`UPDATE "exit_stats" SET "visitors" = $1, "sessions" = $2, "exits" = $3 WHERE id = $4`,
+			existing.Visitors,
+			existing.Sessions,
+			existing.Exits,
+			existing.ID); err != nil {
+			return err
+		}
+	} else {
+		rows, err := tx.NamedQuery(`INSERT INTO "exit_stats" ("tenant_id", "day", "path", "visitors", "sessions", "exits") VALUES (:tenant_id, :day, :path, :visitors, :sessions, :exits)`, entity)
+
+		if err != nil {
+			return err
+		}
+
+		store.closeRows(rows)
+	}
+
+	return nil
+}
+
+// SaveTransitionStats implements the Store interface.
+func (store *PostgresStore) SaveTransitionStats(tx *sqlx.Tx, entity *TransitionStats) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	existing := new(TransitionStats)
+	err := tx.Get(existing, `SELECT id, visitors, transitions FROM "transition_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" = $2
+		AND LOWER("path") = LOWER($3)
+		AND LOWER("next_path") = LOWER($4)`, entity.TenantID, entity.Day, entity.Path, entity.NextPath)
+
+	if err == nil {
+		existing.Visitors += entity.Visitors
+		existing.Transitions += entity.Transitions
+
+		if _, err := tx.Exec(`UPDATE "transition_stats" SET "visitors" = $1, "transitions" = $2 WHERE id = $3`,
+			existing.Visitors,
+			existing.Transitions,
+			existing.ID); err != nil {
+			return err
+		}
+	} else {
+		rows, err := tx.NamedQuery(`INSERT INTO "transition_stats" ("tenant_id", "day", "path", "next_path", "visitors", "transitions") VALUES (:tenant_id, :day, :path, :next_path, :visitors, :transitions)`, entity)
+
+		if err != nil {
+			return err
+		}
+
+		store.closeRows(rows)
+	}
+
+	return nil
+}
+
+// SaveDimensionStats implements the Store interface.
+func (store *PostgresStore) SaveDimensionStats(tx *sqlx.Tx, entity *DimensionStats) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	existing := new(DimensionStats)
+	err := tx.Get(existing, `SELECT id, visitors, page_views FROM "dimension_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" = $2
+		AND "dimension" = $3
+		AND "value" IS NOT DISTINCT FROM $4`, entity.TenantID, entity.Day, entity.Dimension, entity.Value)
+
+	if err := store.createUpdateEntity(tx, entity, existing, err == nil,
+		`INSERT INTO "dimension_stats" ("tenant_id", "day", "dimension", "value", "visitors", "page_views") VALUES (:tenant_id, :day, :dimension, :value, :visitors, :page_views)`,
+		`UPDATE "dimension_stats" SET "visitors" = $1, "page_views" = $2 WHERE id = $3`); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Session implements the Store interface.
+func (store *PostgresStore) Session(tenantID sql.NullInt64, fingerprint string, maxAge, now time.Time) time.Time {
+	query := `SELECT "session"
+		FROM "hit"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND fingerprint = $2
+		AND "time" > $3
+		AND "time" <= $4
+		AND "session" <= $4
+		ORDER BY "time" DESC
+		LIMIT 1`
+	var session time.Time
+
+	if err := store.DB.Get(&session, query, tenantID, fingerprint, maxAge, now); err != nil && err != sql.ErrNoRows {
+		store.logger.Printf("error reading session timestamp: %s", err)
+	}
+
+	return session
+}
+
+// HitDays implements the Store interface.
+func (store *PostgresStore) HitDays(tenantID sql.NullInt64) ([]time.Time, error) {
+	query := `SELECT DISTINCT date("time") AS "day"
+		FROM "hit"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND date("time") < current_date AT TIME ZONE 'UTC'
+		ORDER BY "day" ASC`
+	var days []time.Time
+
+	if err := store.DB.Select(&days, query, tenantID); err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
+
+// EventDays implements the Store interface.
+func (store *PostgresStore) EventDays(tenantID sql.NullInt64) ([]time.Time, error) {
+	query := `SELECT DISTINCT date("time") AS "day"
+		FROM "event"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND date("time") < current_date AT TIME ZONE 'UTC'
+		ORDER BY "day" ASC`
+	var days []time.Time
+
+	if err := store.DB.Select(&days, query, tenantID); err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
+
+// SessionHits implements the Store interface.
+func (store *PostgresStore) SessionHits(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]Hit, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	query := `SELECT * FROM "hit"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "time" >= $2::date
+		AND "time" < $2::date + INTERVAL '1 day'
+		ORDER BY tenant_id ASC, "fingerprint" ASC, "session" ASC, "time" ASC, id ASC`
+	var hits []Hit
+
+	if err := tx.Select(&hits, query, tenantID, day); err != nil {
+		return nil, err
+	}
+
+	return hits, nil
+}
+
+// HitPaths implements the Store interface.
+func (store *PostgresStore) HitPaths(tenantID sql.NullInt64, day time.Time) ([]string, error) {
+	query := `SELECT DISTINCT "path" FROM "hit" WHERE ($1::bigint IS NULL OR tenant_id = $1) AND date("time") = $2 ORDER BY "path" ASC`
+	var paths []string
+
+	if err := store.DB.Select(&paths, query, tenantID, day); err != nil {
+		return nil, err
+	}
+
+	return paths, nil
 }
 
 // Paths implements the Store interface.
@@ -435,6 +1140,7 @@ func (store *PostgresStore) CountVisitors(tx *sqlx.Tx, tenantID sql.NullInt64, d
 
 	query := `SELECT date("time") "day",
         count(DISTINCT "fingerprint") "visitors",
+        count(1) "page_views",
         count(DISTINCT("fingerprint", "session")) "sessions"
 		FROM "hit"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
@@ -462,6 +1168,7 @@ func (store *PostgresStore) CountVisitorsByPath(tx *sqlx.Tx, tenantID sql.NullIn
 		$2::date "day",
 	    $3::varchar "path",
 	    count(DISTINCT "fingerprint") "visitors",
+	    count(1) "page_views",
 		count(DISTINCT("fingerprint", "session")) "sessions" `
 
 	if includePlatform {
@@ -528,6 +1235,13 @@ func (store *PostgresStore) CountVisitorsByPathAndHour(tx *sqlx.Tx, tenantID sql
 			AND "time" < "day_and_hour" + INTERVAL '1 hour'
 			AND LOWER("path") = LOWER($3)
 		) "visitors",
+		(
+			SELECT count(1) FROM "hit"
+			WHERE ($1::bigint IS NULL OR tenant_id = $1)
+			AND "time" >= "day_and_hour"
+			AND "time" < "day_and_hour" + INTERVAL '1 hour'
+			AND LOWER("path") = LOWER($3)
+		) "page_views",
        (
 			SELECT count(DISTINCT("fingerprint", "session")) FROM "hit"
 			WHERE ($1::bigint IS NULL OR tenant_id = $1)
@@ -559,7 +1273,7 @@ func (store *PostgresStore) CountVisitorsByPathAndLanguage(tx *sqlx.Tx, tenantID
 	}
 
 	query := `SELECT * FROM (
-			SELECT "tenant_id", $2::date "day", $3::varchar "path", "language", count(DISTINCT fingerprint) "visitors"
+			SELECT "tenant_id", $2::date "day", $3::varchar "path", "language", count(DISTINCT fingerprint) "visitors", count(1) "page_views"
 			FROM "hit"
 			WHERE ($1::bigint IS NULL OR tenant_id = $1)
 			AND "time" >= $2::date
@@ -585,7 +1299,7 @@ func (store *PostgresStore) CountVisitorsByPathAndReferrer(tx *sqlx.Tx, tenantID
 	}
 
 	query := `SELECT * FROM (
-			SELECT "tenant_id", $2::date "day", $3::varchar "path", "referrer", count(DISTINCT fingerprint) "visitors"
+			SELECT "tenant_id", $2::date "day", $3::varchar "path", "referrer", count(DISTINCT fingerprint) "visitors", count(1) "page_views"
 			FROM "hit"
 			WHERE ($1::bigint IS NULL OR tenant_id = $1)
 			AND "time" >= $2::date
@@ -610,7 +1324,7 @@ func (store *PostgresStore) CountVisitorsByPathAndOS(tx *sqlx.Tx, tenantID sql.N
 	}
 
 	query := `SELECT * FROM (
-			SELECT "tenant_id", $2::date "day", $3::varchar "path", "os", "os_version", count(DISTINCT fingerprint) "visitors"
+			SELECT "tenant_id", $2::date "day", $3::varchar "path", "os", "os_version", count(DISTINCT fingerprint) "visitors", count(1) "page_views"
 			FROM "hit"
 			WHERE ($1::bigint IS NULL OR tenant_id = $1)
 			AND "time" >= $2::date
@@ -635,7 +1349,7 @@ func (store *PostgresStore) CountVisitorsByPathAndBrowser(tx *sqlx.Tx, tenantID
 	}
 
 	query := `SELECT * FROM (
-			SELECT "tenant_id", $2::date "day", $3::varchar "path", "browser", "browser_version", count(DISTINCT fingerprint) "visitors"
+			SELECT "tenant_id", $2::date "day", $3::varchar "path", "browser", "browser_version", count(DISTINCT fingerprint) "visitors", count(1) "page_views"
 			FROM "hit"
 			WHERE ($1::bigint IS NULL OR tenant_id = $1)
 			AND "time" >= $2::date
@@ -652,19 +1366,19 @@ func (store *PostgresStore) CountVisitorsByPathAndBrowser(tx *sqlx.Tx, tenantID
 	return visitors, nil
 }
 
-// CountVisitorsByLanguage implements the Store interface.
-func (store *PostgresStore) CountVisitorsByLanguage(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]LanguageStats, error) {
+// CountVisitorsByReferrerSource implements the Store interface.
+func (store *PostgresStore) CountVisitorsByReferrerSource(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]ReferrerSourceStats, error) {
 	if tx == nil {
 		tx = store.NewTx()
 		defer store.Commit(tx)
 	}
 
-	query := `SELECT "language", count(DISTINCT fingerprint) "visitors"
+	query := `SELECT "tenant_id", $2::date "day", "referrer_name", "channel", count(DISTINCT fingerprint) "visitors", count(1) "page_views"
 		FROM "hit"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
 		AND date("time") = $2::date
-		GROUP BY "language"`
-	var visitors []LanguageStats
+		GROUP BY "tenant_id", "referrer_name", "channel"`
+	var visitors []ReferrerSourceStats
 
 	if err := tx.Select(&visitors, query, tenantID, day); err != nil {
 		return nil, err
@@ -673,19 +1387,19 @@ func (store *PostgresStore) CountVisitorsByLanguage(tx *sqlx.Tx, tenantID sql.Nu
 	return visitors, nil
 }
 
-// CountVisitorsByReferrer implements the Store interface.
-func (store *PostgresStore) CountVisitorsByReferrer(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]ReferrerStats, error) {
+// CountVisitorsByScreenSize implements the Store interface.
+func (store *PostgresStore) CountVisitorsByScreenSize(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]ScreenStats, error) {
 	if tx == nil {
 		tx = store.NewTx()
 		defer store.Commit(tx)
 	}
 
-	query := `SELECT "referrer", count(DISTINCT fingerprint) "visitors"
+	query := `SELECT "tenant_id", $2::date "day", "screen_width" "width", "screen_height" "height", count(DISTINCT fingerprint) "visitors", count(1) "page_views"
 		FROM "hit"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
 		AND date("time") = $2::date
-		GROUP BY "referrer"`
-	var visitors []ReferrerStats
+		GROUP BY "tenant_id", "width", "height"`
+	var visitors []ScreenStats
 
 	if err := tx.Select(&visitors, query, tenantID, day); err != nil {
 		return nil, err
@@ -694,19 +1408,19 @@ func (store *PostgresStore) CountVisitorsByReferrer(tx *sqlx.Tx, tenantID sql.Nu
 	return visitors, nil
 }
 
-// CountVisitorsByOS implements the Store interface.
-func (store *PostgresStore) CountVisitorsByOS(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]OSStats, error) {
+// CountVisitorsByCountryCode implements the Store interface.
+func (store *PostgresStore) CountVisitorsByCountryCode(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]CountryStats, error) {
 	if tx == nil {
 		tx = store.NewTx()
 		defer store.Commit(tx)
 	}
 
-	query := `SELECT "os", count(DISTINCT fingerprint) "visitors"
+	query := `SELECT "tenant_id", $2::date "day", "country_code", count(DISTINCT fingerprint) "visitors", count(1) "page_views"
 		FROM "hit"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
 		AND date("time") = $2::date
-		GROUP BY "os"`
-	var visitors []OSStats
+		GROUP BY "tenant_id", "country_code"`
+	var visitors []CountryStats
 
 	if err := tx.Select(&visitors, query, tenantID, day); err != nil {
 		return nil, err
@@ -715,61 +1429,99 @@ func (store *PostgresStore) CountVisitorsByOS(tx *sqlx.Tx, tenantID sql.NullInt6
 	return visitors, nil
 }
 
-// CountVisitorsByBrowser implements the Store interface.
-func (store *PostgresStore) CountVisitorsByBrowser(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]BrowserStats, error) {
+// CountVisitorsByDimension implements the Store interface.
+func (store *PostgresStore) CountVisitorsByDimension(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time, dimension Dimension) ([]DimensionStats, error) {
+	column, err := dimension.column()
+
+	if err != nil {
+		return nil, err
+	}
+
 	if tx == nil {
 		tx = store.NewTx()
 		defer store.Commit(tx)
 	}
 
-	query := `SELECT "browser", count(DISTINCT fingerprint) "visitors"
+	query := fmt.Sprintf(`SELECT "tenant_id", $2::date "day", $3::varchar "dimension", (%s)::varchar "value", count(DISTINCT fingerprint) "visitors", count(1) "page_views"
 		FROM "hit"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
 		AND date("time") = $2::date
-		GROUP BY "browser"`
-	var visitors []BrowserStats
+		GROUP BY "tenant_id", "value"`, column)
+	var visitors []DimensionStats
 
-	if err := tx.Select(&visitors, query, tenantID, day); err != nil {
+	if err := tx.Select(&visitors, query, tenantID, day, dimension.Name); err != nil {
 		return nil, err
 	}
 
 	return visitors, nil
 }
 
-// CountVisitorsByScreenSize implements the Store interface.
-func (store *PostgresStore) CountVisitorsByScreenSize(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]ScreenStats, error) {
-	if tx == nil {
-		tx = store.NewTx()
-		defer store.Commit(tx)
+// CountVisitorsByLanguage implements the Store interface.
+//
+// Deprecated: use CountVisitorsByDimension with DimensionLanguage instead.
+func (store *PostgresStore) CountVisitorsByLanguage(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]LanguageStats, error) {
+	visitors, err := store.CountVisitorsByDimension(tx, tenantID, day, builtinDimensions[DimensionLanguage])
+
+	if err != nil {
+		return nil, err
 	}
 
-	query := `SELECT "tenant_id", $2::date "day", "screen_width" "width", "screen_height" "height", count(DISTINCT fingerprint) "visitors"
-		FROM "hit"
-		WHERE ($1::bigint IS NULL OR tenant_id = $1)
-		AND date("time") = $2::date
-		GROUP BY "tenant_id", "width", "height"`
-	var visitors []ScreenStats
+	return languageStats(visitors), nil
+}
 
-	if err := tx.Select(&visitors, query, tenantID, day); err != nil {
+// CountVisitorsByReferrer implements the Store interface.
+//
+// Deprecated: use CountVisitorsByDimension with DimensionReferrer instead.
+func (store *PostgresStore) CountVisitorsByReferrer(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]ReferrerStats, error) {
+	visitors, err := store.CountVisitorsByDimension(tx, tenantID, day, builtinDimensions[DimensionReferrer])
+
+	if err != nil {
 		return nil, err
 	}
 
-	return visitors, nil
+	return referrerStats(visitors), nil
 }
 
-// CountVisitorsByCountryCode implements the Store interface.
-func (store *PostgresStore) CountVisitorsByCountryCode(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]CountryStats, error) {
+// CountVisitorsByOS implements the Store interface.
+//
+// Deprecated: use CountVisitorsByDimension with DimensionOS instead.
+func (store *PostgresStore) CountVisitorsByOS(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]OSStats, error) {
+	visitors, err := store.CountVisitorsByDimension(tx, tenantID, day, builtinDimensions[DimensionOS])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return osStats(visitors), nil
+}
+
+// CountVisitorsByBrowser implements the Store interface.
+//
+// Deprecated: use CountVisitorsByDimension with DimensionBrowser instead.
+func (store *PostgresStore) CountVisitorsByBrowser(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]BrowserStats, error) {
+	visitors, err := store.CountVisitorsByDimension(tx, tenantID, day, builtinDimensions[DimensionBrowser])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return browserStats(visitors), nil
+}
+
+// CountVisitorsByUTM implements the Store interface.
+func (store *PostgresStore) CountVisitorsByUTM(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]UTMStats, error) {
 	if tx == nil {
 		tx = store.NewTx()
 		defer store.Commit(tx)
 	}
 
-	query := `SELECT "tenant_id", $2::date "day", "country_code", count(DISTINCT fingerprint) "visitors"
+	query := `SELECT "tenant_id", $2::date "day", "utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content", count(DISTINCT fingerprint) "visitors", count(1) "page_views"
 		FROM "hit"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
 		AND date("time") = $2::date
-		GROUP BY "tenant_id", "country_code"`
-	var visitors []CountryStats
+		AND COALESCE("utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content") IS NOT NULL
+		GROUP BY "tenant_id", "utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content"`
+	var visitors []UTMStats
 
 	if err := tx.Select(&visitors, query, tenantID, day); err != nil {
 		return nil, err
@@ -817,6 +1569,8 @@ func (store *PostgresStore) CountVisitorsByPlatform(tx *sqlx.Tx, tenantID sql.Nu
 }
 
 // CountVisitorsByPathAndMaxOneHit implements the Store interface.
+//
+// Deprecated: bounces are counted per session by the Processor now, see Stats.Bounces.
 func (store *PostgresStore) CountVisitorsByPathAndMaxOneHit(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time, path string) int {
 	if tx == nil {
 		tx = store.NewTx()
@@ -850,15 +1604,208 @@ func (store *PostgresStore) CountVisitorsByPathAndMaxOneHit(tx *sqlx.Tx, tenantI
 	return visitors
 }
 
+// CountEvents implements the Store interface.
+func (store *PostgresStore) CountEvents(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]EventStats, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	query := `SELECT tenant_id,
+		$2::date "day",
+		COALESCE("path", '') "path",
+		"name",
+		count(DISTINCT "fingerprint") "visitors",
+		count(1) "events",
+		COALESCE(SUM("value"), 0) "value"
+		FROM "event"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "time" >= $2::date
+		AND "time" < $2::date + INTERVAL '1 day'
+		GROUP BY tenant_id, "path", "name"
+		ORDER BY "path" ASC, "name" ASC`
+	var events []EventStats
+
+	if err := tx.Select(&events, query, tenantID, day); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// CountEventMetadata implements the Store interface.
+func (store *PostgresStore) CountEventMetadata(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) ([]EventMetadataStats, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	query := `SELECT tenant_id,
+		$2::date "day",
+		COALESCE("path", '') "path",
+		"name",
+		"meta"."meta_key",
+		COALESCE("meta"."meta_value", '') "meta_value",
+		count(DISTINCT "fingerprint") "visitors",
+		count(1) "events"
+		FROM "event", unnest("meta_keys", "meta_values") AS "meta"("meta_key", "meta_value")
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "time" >= $2::date
+		AND "time" < $2::date + INTERVAL '1 day'
+		AND "meta"."meta_key" IS NOT NULL
+		GROUP BY tenant_id, "path", "name", "meta"."meta_key", "meta"."meta_value"
+		ORDER BY "path" ASC, "name" ASC, "meta_key" ASC, "meta_value" ASC`
+	var metadata []EventMetadataStats
+
+	if err := tx.Select(&metadata, query, tenantID, day); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+// CountEventsByName implements the Store interface.
+func (store *PostgresStore) CountEventsByName(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time, path string) ([]EventStats, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	query := `SELECT $2::date "day",
+		"name",
+		count(DISTINCT "fingerprint") "visitors",
+		count(1) "events",
+		COALESCE(SUM("value"), 0) "value"
+		FROM "event"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "time" >= $2::date
+		AND "time" < $2::date + INTERVAL '1 day'
+		AND ($3 = '' OR LOWER("path") = LOWER($3))
+		GROUP BY "name"
+		ORDER BY "visitors" DESC, "name" ASC`
+	var events []EventStats
+
+	if err := tx.Select(&events, query, tenantID, day, path); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// CountEventMetadataByName implements the Store interface.
+func (store *PostgresStore) CountEventMetadataByName(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time, name, path string) ([]EventMetadataStats, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	query := `SELECT $2::date "day",
+		"name",
+		"meta"."meta_key",
+		COALESCE("meta"."meta_value", '') "meta_value",
+		count(DISTINCT "fingerprint") "visitors",
+		count(1) "events"
+		FROM "event", unnest("meta_keys", "meta_values") AS "meta"("meta_key", "meta_value")
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "time" >= $2::date
+		AND "time" < $2::date + INTERVAL '1 day'
+		AND "name" = $3
+		AND ($4 = '' OR LOWER("path") = LOWER($4))
+		AND "meta"."meta_key" IS NOT NULL
+		GROUP BY "name", "meta"."meta_key", COALESCE("meta"."meta_value", '')
+		ORDER BY "visitors" DESC, "meta_key" ASC, "meta_value" ASC`
+	var metadata []EventMetadataStats
+
+	if err := tx.Select(&metadata, query, tenantID, day, name, path); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+// CountGoalConversions implements the Store interface.
+// Goals with a path pattern are matched against the hit paths using a case-insensitive regular expression.
+// The tenant of the goal is used if set.
+func (store *PostgresStore) CountGoalConversions(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time, goal *Goal) ([]GoalStats, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+
+	if goal.TenantID.Valid {
+		tenantID = goal.TenantID
+	}
+
+	var converted string
+	var target string
+
+	if goal.EventName.Valid {
+		converted = `SELECT DISTINCT tenant_id, "fingerprint"
+			FROM "event"
+			WHERE ($1::bigint IS NULL OR tenant_id = $1)
+			AND "time" >= $2::date
+			AND "time" < $2::date + INTERVAL '1 day'
+			AND "name" = $4`
+		target = goal.EventName.String
+	} else {
+		converted = `SELECT DISTINCT tenant_id, "fingerprint"
+			FROM "hit"
+			WHERE ($1::bigint IS NULL OR tenant_id = $1)
+			AND "time" >= $2::date
+			AND "time" < $2::date + INTERVAL '1 day'
+			AND "path" ~* $4`
+		target = goal.pathRegex()
+	}
+
+	query := `WITH "converted" AS (` + converted + `),
+		"first_hit" AS (
+			SELECT DISTINCT ON (tenant_id, "fingerprint") tenant_id, "fingerprint", "referrer", "country_code", "browser"
+			FROM "hit"
+			WHERE ($1::bigint IS NULL OR tenant_id = $1)
+			AND "time" >= $2::date
+			AND "time" < $2::date + INTERVAL '1 day'
+			ORDER BY tenant_id, "fingerprint", "time" ASC
+		)
+		SELECT "converted".tenant_id,
+		$2::date "day",
+		$3::bigint "goal_id",
+		"first_hit"."referrer",
+		"first_hit"."country_code",
+		"first_hit"."browser",
+		count(1) "visitors"
+		FROM "converted"
+		LEFT JOIN "first_hit" ON "first_hit".tenant_id IS NOT DISTINCT FROM "converted".tenant_id AND "first_hit"."fingerprint" = "converted"."fingerprint"
+		GROUP BY "converted".tenant_id, "first_hit"."referrer", "first_hit"."country_code", "first_hit"."browser"
+		ORDER BY "visitors" DESC`
+	var stats []GoalStats
+
+	if err := tx.Select(&stats, query, tenantID, day, goal.ID, target); err != nil {
+		return nil, err
+	}
+
+	for i := range stats {
+		stats[i].Name = goal.Name
+	}
+
+	return stats, nil
+}
+
 // ActiveVisitors implements the Store interface.
 func (store *PostgresStore) ActiveVisitors(tenantID sql.NullInt64, from time.Time) int {
-	query := `SELECT count(DISTINCT fingerprint) "visitors"
+	return store.ActiveVisitorsFilter(&Filter{TenantID: tenantID}, from)
+}
+
+// ActiveVisitorsFilter implements the Store interface.
+func (store *PostgresStore) ActiveVisitorsFilter(filter *Filter, from time.Time) int {
+	conditions, args := store.filterConditions(filter, []interface{}{filter.TenantID, from})
+	query := fmt.Sprintf(`SELECT count(DISTINCT fingerprint) "visitors"
 		FROM "hit"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
-		AND "time" > $2`
+		AND "time" > $2
+		%s`, conditions)
 	visitors := 0
 
-	if err := store.DB.Get(&visitors, query, tenantID, from); err != nil {
+	if err := store.DB.Get(&visitors, query, args...); err != nil {
 		store.logger.Printf("error counting active visitors: %s", err)
 		return 0
 	}
@@ -868,17 +1815,24 @@ func (store *PostgresStore) ActiveVisitors(tenantID sql.NullInt64, from time.Tim
 
 // ActivePageVisitors implements the Store interface.
 func (store *PostgresStore) ActivePageVisitors(tenantID sql.NullInt64, from time.Time) ([]Stats, error) {
-	query := `SELECT * FROM (
-			SELECT "tenant_id", "path", count(DISTINCT fingerprint) "visitors"
+	return store.ActivePageVisitorsFilter(&Filter{TenantID: tenantID}, from)
+}
+
+// ActivePageVisitorsFilter implements the Store interface.
+func (store *PostgresStore) ActivePageVisitorsFilter(filter *Filter, from time.Time) ([]Stats, error) {
+	conditions, args := store.filterConditions(filter, []interface{}{filter.TenantID, from})
+	query := fmt.Sprintf(`SELECT * FROM (
+			SELECT "tenant_id", "path", count(DISTINCT fingerprint) "visitors", count(1) "page_views"
 			FROM "hit"
 			WHERE ($1::bigint IS NULL OR tenant_id = $1)
 			AND "time" > $2
+			%s
 			GROUP BY tenant_id, "path"
 		) AS results
-		ORDER BY "visitors" DESC, "path" ASC`
+		ORDER BY "visitors" DESC, "path" ASC`, conditions)
 	var visitors []Stats
 
-	if err := store.DB.Select(&visitors, query, tenantID, from); err != nil {
+	if err := store.DB.Select(&visitors, query, args...); err != nil {
 		return nil, err
 	}
 
@@ -889,8 +1843,14 @@ func (store *PostgresStore) ActivePageVisitors(tenantID sql.NullInt64, from time
 func (store *PostgresStore) Visitors(tenantID sql.NullInt64, from, to time.Time) ([]Stats, error) {
 	query := `SELECT "d" AS "day",
 		COALESCE(SUM("visitor_stats".visitors), 0) "visitors",
+        COALESCE(SUM("visitor_stats".page_views), 0) "page_views",
         COALESCE(SUM("visitor_stats".sessions), 0) "sessions",
-        COALESCE(SUM("visitor_stats".bounces), 0) "bounces"
+        COALESCE(SUM("visitor_stats".bounces), 0) "bounces",
+        COALESCE(SUM("visitor_stats".sessions_started), 0) "sessions_started",
+        COALESCE(SUM("visitor_stats".session_duration), 0) "session_duration",
+        COALESCE(SUM("visitor_stats".session_duration_count), 0) "session_duration_count",
+        COALESCE(SUM("visitor_stats".time_on_page), 0) "time_on_page",
+        COALESCE(SUM("visitor_stats".time_on_page_count), 0) "time_on_page_count"
 		FROM (
 			SELECT * FROM generate_series(
 				$2::date,
@@ -903,172 +1863,596 @@ func (store *PostgresStore) Visitors(tenantID sql.NullInt64, from, to time.Time)
 		ORDER BY "d" ASC`
 	var visitors []Stats
 
-	if err := store.DB.Select(&visitors, query, tenantID, from, to); err != nil {
+	if err := store.DB.Select(&visitors, query, tenantID, from, to); err != nil {
+		return nil, err
+	}
+
+	return visitors, nil
+}
+
+// VisitorHours implements the Store interface.
+func (store *PostgresStore) VisitorHours(tenantID sql.NullInt64, from time.Time, to time.Time) ([]VisitorTimeStats, error) {
+	query := `SELECT "day_and_hour" "hour",
+        COALESCE(sum("visitors"), 0) "visitors",
+		COALESCE(sum("page_views"), 0) "page_views",
+		COALESCE(sum("sessions"), 0) "sessions"
+		FROM generate_series(0, 23, 1) "day_and_hour"
+		LEFT JOIN (
+			SELECT "hour", sum("visitors") "visitors", sum("page_views") "page_views", sum("sessions") "sessions"
+			FROM "visitor_time_stats"
+			WHERE ($1::bigint IS NULL OR tenant_id = $1)
+			AND "day" >= date($2::timestamp)
+			AND "day" <= date($3::timestamp)
+			GROUP BY "hour"
+			UNION
+			SELECT EXTRACT(HOUR FROM "time") "hour",
+			count(DISTINCT "fingerprint") "visitors",
+			count(1) "page_views",
+			count(DISTINCT("fingerprint", "session")) "sessions"
+			FROM "hit"
+			WHERE ($1::bigint IS NULL OR tenant_id = $1)
+			AND date("time") >= date($2::timestamp)
+			AND date("time") <= date($3::timestamp)
+			GROUP BY "hour"
+		) AS results ON "hour" = "day_and_hour"
+		GROUP BY "day_and_hour"
+		ORDER BY "day_and_hour" ASC`
+	var visitors []VisitorTimeStats
+
+	if err := store.DB.Select(&visitors, query, tenantID, from, to); err != nil {
+		return nil, err
+	}
+
+	return visitors, nil
+}
+
+// VisitorReferrerSource implements the Store interface.
+func (store *PostgresStore) VisitorReferrerSource(tenantID sql.NullInt64, from, to time.Time) ([]ReferrerSourceStats, error) {
+	query := `SELECT "referrer_name", "channel", COALESCE(SUM("visitors"), 0) "visitors", COALESCE(SUM("page_views"), 0) "page_views"
+		FROM "referrer_source_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" >= $2::date
+		AND "day" <= $3::date
+		GROUP BY "referrer_name", "channel"
+		ORDER BY "visitors" DESC`
+	var visitors []ReferrerSourceStats
+
+	if err := store.DB.Select(&visitors, query, tenantID, from, to); err != nil {
+		return nil, err
+	}
+
+	return visitors, nil
+}
+
+// VisitorChannel implements the Store interface.
+func (store *PostgresStore) VisitorChannel(tenantID sql.NullInt64, from, to time.Time) ([]ReferrerSourceStats, error) {
+	query := `SELECT "channel", COALESCE(SUM("visitors"), 0) "visitors", COALESCE(SUM("page_views"), 0) "page_views"
+		FROM "referrer_source_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" >= $2::date
+		AND "day" <= $3::date
+		GROUP BY "channel"
+		ORDER BY "visitors" DESC`
+	var visitors []ReferrerSourceStats
+
+	if err := store.DB.Select(&visitors, query, tenantID, from, to); err != nil {
+		return nil, err
+	}
+
+	return visitors, nil
+}
+
+// VisitorPlatform implements the Store interface.
+func (store *PostgresStore) VisitorPlatform(tenantID sql.NullInt64, from, to time.Time) *VisitorStats {
+	query := `SELECT COALESCE(SUM("platform_desktop"), 0) "platform_desktop",
+		COALESCE(SUM("platform_mobile"), 0) "platform_mobile",
+		COALESCE(SUM("platform_unknown"), 0) "platform_unknown"
+		FROM "visitor_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" >= $2::date
+		AND "day" <= $3::date`
+	visitors := new(VisitorStats)
+
+	if err := store.DB.Get(visitors, query, tenantID, from, to); err != nil && err != sql.ErrNoRows {
+		store.logger.Printf("error reading visitor platforms: %s", err)
+		return nil
+	}
+
+	return visitors
+}
+
+// VisitorDimension implements the Store interface.
+func (store *PostgresStore) VisitorDimension(tenantID sql.NullInt64, from, to time.Time, dimension Dimension) ([]DimensionStats, error) {
+	table, column := dimension.table, dimension.statsColumn
+	args := []interface{}{tenantID, from, to}
+	filterDimension := ""
+
+	if table == "" {
+		table, column = "dimension_stats", `"value"`
+		args = append(args, dimension.Name)
+		filterDimension = `AND "dimension" = $4`
+	}
+
+	query := fmt.Sprintf(`SELECT (%s)::varchar "value", COALESCE(SUM("visitors"), 0) "visitors", COALESCE(SUM("page_views"), 0) "page_views"
+		FROM "%s"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" >= $2::date
+		AND "day" <= $3::date
+		%s
+		GROUP BY 1
+		ORDER BY "visitors" DESC`, column, table, filterDimension)
+	var visitors []DimensionStats
+
+	if err := store.DB.Select(&visitors, query, args...); err != nil {
+		return nil, err
+	}
+
+	for i := range visitors {
+		visitors[i].Dimension = dimension.Name
+	}
+
+	return visitors, nil
+}
+
+// VisitorLanguages implements the Store interface.
+//
+// Deprecated: use VisitorDimension with DimensionLanguage instead.
+func (store *PostgresStore) VisitorLanguages(tenantID sql.NullInt64, from, to time.Time) ([]LanguageStats, error) {
+	visitors, err := store.VisitorDimension(tenantID, from, to, builtinDimensions[DimensionLanguage])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return languageStats(visitors), nil
+}
+
+// VisitorReferrer implements the Store interface.
+//
+// Deprecated: use VisitorDimension with DimensionReferrer instead.
+func (store *PostgresStore) VisitorReferrer(tenantID sql.NullInt64, from, to time.Time) ([]ReferrerStats, error) {
+	visitors, err := store.VisitorDimension(tenantID, from, to, builtinDimensions[DimensionReferrer])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return referrerStats(visitors), nil
+}
+
+// VisitorOS implements the Store interface.
+//
+// Deprecated: use VisitorDimension with DimensionOS instead.
+func (store *PostgresStore) VisitorOS(tenantID sql.NullInt64, from, to time.Time) ([]OSStats, error) {
+	visitors, err := store.VisitorDimension(tenantID, from, to, builtinDimensions[DimensionOS])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return osStats(visitors), nil
+}
+
+// VisitorBrowser implements the Store interface.
+//
+// Deprecated: use VisitorDimension with DimensionBrowser instead.
+func (store *PostgresStore) VisitorBrowser(tenantID sql.NullInt64, from, to time.Time) ([]BrowserStats, error) {
+	visitors, err := store.VisitorDimension(tenantID, from, to, builtinDimensions[DimensionBrowser])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return browserStats(visitors), nil
+}
+
+// VisitorScreenSize implements the Store interface.
+//
+// Deprecated: use VisitorDimension with DimensionScreen instead.
+func (store *PostgresStore) VisitorScreenSize(tenantID sql.NullInt64, from, to time.Time) ([]ScreenStats, error) {
+	visitors, err := store.VisitorDimension(tenantID, from, to, builtinDimensions[DimensionScreen])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return screenStats(visitors)
+}
+
+// VisitorCountry implements the Store interface.
+//
+// Deprecated: use VisitorDimension with DimensionCountry instead.
+func (store *PostgresStore) VisitorCountry(tenantID sql.NullInt64, from, to time.Time) ([]CountryStats, error) {
+	visitors, err := store.VisitorDimension(tenantID, from, to, builtinDimensions[DimensionCountry])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return countryStats(visitors), nil
+}
+
+// FilterStats implements the Store interface.
+// Bounces and the duration of a session are attributed to the group of its first page view, the time on page to the group of each page view but the last one.
+// Visitors are counted once per group and tenant, sessions once per group. The statistics are ordered by the columns grouped by.
+// If the filter groups by interval, the day is the first day of the week, month, or year in the time zone of the filter.
+func (store *PostgresStore) FilterStats(filter *Filter, groupBy []string) ([]FilterStats, error) {
+	sessions, args := store.filterSessions(filter)
+	var columns strings.Builder
+	positions := make([]string, 0, len(groupBy))
+
+	for i, column := range groupBy {
+		expression, found := filterStatsColumns[column]
+
+		if !found {
+			return nil, errFilterStatsColumn
+		}
+
+		if column == "day" && filter.groupsByInterval() {
+			// the interval is one of the constants and can therefore be used in the query directly
+			args = append(args, filter.location().String())
+			expression = fmt.Sprintf(`date_trunc('%s', "time" AT TIME ZONE 'UTC' AT TIME ZONE $%d)::date`, filter.Interval, len(args))
+		}
+
+		columns.WriteString(fmt.Sprintf(`%s "%s", `, expression, column))
+		positions = append(positions, fmt.Sprintf("%d", i+1))
+	}
+
+	group, order := "", ""
+
+	if len(positions) > 0 {
+		group = "GROUP BY " + strings.Join(positions, ", ")
+		order = "ORDER BY " + strings.Join(positions, ", ")
+	}
+
+	conditions, args := store.pathConditions(filter, args)
+	query := sessions + fmt.Sprintf(`, "session_hit" AS (
+			SELECT *,
+			DENSE_RANK() OVER (ORDER BY tenant_id, "fingerprint") "visitor",
+			DENSE_RANK() OVER (ORDER BY tenant_id, "fingerprint", "session") "visit",
+			ROW_NUMBER() OVER ("ordered_visit") = 1 "entry",
+			LEAD("time") OVER ("ordered_visit") "next_time",
+			MAX("time") OVER ("visit") "last_time",
+			COUNT(1) OVER ("visit") "visit_page_views"
+			FROM "filtered_hit"
+			WINDOW "visit" AS (PARTITION BY tenant_id, "fingerprint", "session"),
+			"ordered_visit" AS (PARTITION BY tenant_id, "fingerprint", "session" ORDER BY "time", id)
+		)
+		SELECT %s
+		COUNT(DISTINCT "visitor") "visitors",
+		COUNT(1) "page_views",
+		COUNT(DISTINCT "visit") "sessions",
+		COALESCE(SUM(CASE WHEN "entry" AND "visit_page_views" = 1 THEN 1 ELSE 0 END), 0) "bounces",
+		COALESCE(SUM(CASE WHEN "entry" THEN 1 ELSE 0 END), 0) "sessions_started",
+		COALESCE(SUM(CASE WHEN "entry" THEN FLOOR(EXTRACT(EPOCH FROM "last_time" - "time")) ELSE 0 END), 0)::bigint "session_duration",
+		COALESCE(SUM(CASE WHEN "entry" THEN 1 ELSE 0 END), 0) "session_duration_count",
+		COALESCE(SUM(FLOOR(EXTRACT(EPOCH FROM "next_time" - "time"))), 0)::bigint "time_on_page",
+		COUNT("next_time") "time_on_page_count"
+		FROM "session_hit"
+		WHERE TRUE
+		%s
+		%s
+		%s`, columns.String(), conditions, group, order)
+	var stats []FilterStats
+
+	if err := store.DB.Select(&stats, query, args...); err != nil {
 		return nil, err
 	}
 
-	return visitors, nil
+	return stats, nil
 }
 
-// VisitorHours implements the Store interface.
-func (store *PostgresStore) VisitorHours(tenantID sql.NullInt64, from time.Time, to time.Time) ([]VisitorTimeStats, error) {
-	query := `SELECT "day_and_hour" "hour",
-        COALESCE(sum("visitors"), 0) "visitors",
-		COALESCE(sum("sessions"), 0) "sessions"
-		FROM generate_series(0, 23, 1) "day_and_hour"
-		LEFT JOIN (
-			SELECT "hour", sum("visitors") "visitors", sum("sessions") "sessions"
-			FROM "visitor_time_stats"
+// FilterEvents implements the Store interface.
+func (store *PostgresStore) FilterEvents(filter *Filter) ([]Event, error) {
+	sessions, args := store.filterSessions(filter)
+	query := sessions + fmt.Sprintf(`SELECT "events".* FROM (
+			SELECT %[1]s FROM "event"
 			WHERE ($1::bigint IS NULL OR tenant_id = $1)
-			AND "day" >= date($2::timestamp)
-			AND "day" <= date($3::timestamp)
-			GROUP BY "hour"
-			UNION
-			SELECT EXTRACT(HOUR FROM "time") "hour",
-			count(DISTINCT "fingerprint") "visitors",
-			count(DISTINCT("fingerprint", "session")) "sessions"
-			FROM "hit"
+			AND "time" >= $2
+			AND "time" < $3
+			UNION ALL
+			SELECT %[1]s FROM "event_archive"
 			WHERE ($1::bigint IS NULL OR tenant_id = $1)
-			AND date("time") >= date($2::timestamp)
-			AND date("time") <= date($3::timestamp)
-			GROUP BY "hour"
-		) AS results ON "hour" = "day_and_hour"
-		GROUP BY "day_and_hour"
-		ORDER BY "day_and_hour" ASC`
-	var visitors []VisitorTimeStats
-
-	if err := store.DB.Select(&visitors, query, tenantID, from, to); err != nil {
+			AND "time" >= $2
+			AND "time" < $3
+		) AS "events"
+		JOIN "filtered_session" ON "filtered_session".tenant_id IS NOT DISTINCT FROM "events".tenant_id
+		AND "filtered_session"."fingerprint" = "events"."fingerprint"
+		AND "filtered_session"."session" IS NOT DISTINCT FROM "events"."session"
+		ORDER BY "events"."time" ASC, "events".id ASC`, eventArchiveColumns)
+	var events []Event
+
+	if err := store.DB.Select(&events, query, args...); err != nil {
 		return nil, err
 	}
 
-	return visitors, nil
+	return events, nil
 }
 
-// VisitorLanguages implements the Store interface.
-func (store *PostgresStore) VisitorLanguages(tenantID sql.NullInt64, from, to time.Time) ([]LanguageStats, error) {
-	query := `SELECT "language", COALESCE(SUM("visitors"), 0) "visitors"
-		FROM "language_stats"
-		WHERE ($1::bigint IS NULL OR tenant_id = $1)
-		AND "day" >= $2::date
-		AND "day" <= $3::date
-		GROUP BY "language"
-		ORDER BY "visitors" DESC`
-	var visitors []LanguageStats
+// FilterDimension implements the Store interface.
+func (store *PostgresStore) FilterDimension(filter *Filter, dimension Dimension) ([]DimensionStats, error) {
+	column, err := dimension.column()
 
-	if err := store.DB.Select(&visitors, query, tenantID, from, to); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
-	return visitors, nil
-}
-
-// VisitorReferrer implements the Store interface.
-func (store *PostgresStore) VisitorReferrer(tenantID sql.NullInt64, from, to time.Time) ([]ReferrerStats, error) {
-	query := `SELECT "referrer", COALESCE(SUM("visitors"), 0) "visitors"
-		FROM "referrer_stats"
-		WHERE ($1::bigint IS NULL OR tenant_id = $1)
-		AND "day" >= $2::date
-		AND "day" <= $3::date
-		GROUP BY "referrer"
-		ORDER BY "visitors" DESC`
-	var visitors []ReferrerStats
+	sessions, args := store.filterSessions(filter)
+	conditions, args := store.pathConditions(filter, args)
+	query := sessions + fmt.Sprintf(`SELECT (%s)::varchar "value", count(DISTINCT "fingerprint") "visitors", count(1) "page_views"
+		FROM "filtered_hit"
+		WHERE TRUE
+		%s
+		GROUP BY 1
+		ORDER BY "visitors" DESC`, column, conditions)
+	var visitors []DimensionStats
 
-	if err := store.DB.Select(&visitors, query, tenantID, from, to); err != nil {
+	if err := store.DB.Select(&visitors, query, args...); err != nil {
 		return nil, err
 	}
 
+	for i := range visitors {
+		visitors[i].Dimension = dimension.Name
+	}
+
 	return visitors, nil
 }
 
-// VisitorOS implements the Store interface.
-func (store *PostgresStore) VisitorOS(tenantID sql.NullInt64, from, to time.Time) ([]OSStats, error) {
-	query := `SELECT "os", COALESCE(SUM("visitors"), 0) "visitors"
-		FROM "os_stats"
-		WHERE ($1::bigint IS NULL OR tenant_id = $1)
-		AND "day" >= $2::date
-		AND "day" <= $3::date
-		GROUP BY "os"
-		ORDER BY "visitors" DESC`
-	var visitors []OSStats
+// FilterEntryPages implements the Store interface.
+// Paths are grouped case-insensitively. Visitors are counted once per path and tenant.
+func (store *PostgresStore) FilterEntryPages(filter *Filter) ([]EntryStats, error) {
+	sessions, args := store.filterSessions(filter)
+	conditions, args := store.pathConditions(filter, args)
+	query := sessions + fmt.Sprintf(`, "session_hit" AS (
+			SELECT "path",
+			DENSE_RANK() OVER (ORDER BY tenant_id, "fingerprint") "visitor",
+			ROW_NUMBER() OVER (PARTITION BY tenant_id, "fingerprint", "session" ORDER BY "time", id) = 1 "entry"
+			FROM "filtered_hit"
+		)
+		SELECT MIN("path") "path",
+		COUNT(DISTINCT "visitor") "visitors",
+		COUNT(1) "entries"
+		FROM "session_hit"
+		WHERE "entry"
+		%s
+		GROUP BY LOWER("path")
+		ORDER BY "entries" DESC, 1 ASC`, conditions)
+	var stats []EntryStats
+
+	if err := store.DB.Select(&stats, query, args...); err != nil {
+		return nil, err
+	}
 
-	if err := store.DB.Select(&visitors, query, tenantID, from, to); err != nil {
+	return stats, nil
+}
+
+// FilterExitPages implements the Store interface.
+// Paths are grouped case-insensitively. Visitors are counted once per path and tenant, sessions once per path.
+func (store *PostgresStore) FilterExitPages(filter *Filter) ([]ExitStats, error) {
+	sessions, args := store.filterSessions(filter)
+	conditions, args := store.pathConditions(filter, args)
+	query := sessions + fmt.Sprintf(`, "session_hit" AS (
+			SELECT "path",
+			DENSE_RANK() OVER (ORDER BY tenant_id, "fingerprint") "visitor",
+			DENSE_RANK() OVER (ORDER BY tenant_id, "fingerprint", "session") "visit",
+			ROW_NUMBER() OVER (PARTITION BY tenant_id, "fingerprint", "session" ORDER BY "time" DESC, id DESC) = 1 "exit"
+			FROM "filtered_hit"
+		)
+		SELECT MIN("path") "path",
+		COUNT(DISTINCT CASE WHEN "exit" THEN "visitor" END) "visitors",
+		COUNT(DISTINCT "visit") "sessions",
+		COUNT(CASE WHEN "exit" THEN 1 END) "exits"
+		FROM "session_hit"
+		WHERE TRUE
+		%s
+		GROUP BY LOWER("path")
+		ORDER BY "exits" DESC, 1 ASC`, conditions)
+	var stats []ExitStats
+
+	if err := store.DB.Select(&stats, query, args...); err != nil {
 		return nil, err
 	}
 
-	return visitors, nil
+	return stats, nil
 }
 
-// VisitorBrowser implements the Store interface.
-func (store *PostgresStore) VisitorBrowser(tenantID sql.NullInt64, from, to time.Time) ([]BrowserStats, error) {
-	query := `SELECT "browser", COALESCE(SUM("visitors"), 0) "visitors"
-		FROM "browser_stats"
-		WHERE ($1::bigint IS NULL OR tenant_id = $1)
-		AND "day" >= $2::date
-		AND "day" <= $3::date
-		GROUP BY "browser"
-		ORDER BY "visitors" DESC`
-	var visitors []BrowserStats
+// FilterNextPages implements the Store interface.
+func (store *PostgresStore) FilterNextPages(filter *Filter) ([]TransitionStats, error) {
+	return store.filterTransitions(filter, "path", "next_path")
+}
 
-	if err := store.DB.Select(&visitors, query, tenantID, from, to); err != nil {
+// FilterPreviousPages implements the Store interface.
+func (store *PostgresStore) FilterPreviousPages(filter *Filter) ([]TransitionStats, error) {
+	return store.filterTransitions(filter, "next_path", "path")
+}
+
+// FilterGoalConversions implements the Store interface.
+// Goals with a path pattern are matched against the hit paths using a case-insensitive regular expression.
+// The tenant of the goal is used if set. The referrer, country code, and browser are taken from the first page view of the visitor.
+func (store *PostgresStore) FilterGoalConversions(filter *Filter, goal *Goal) ([]GoalStats, error) {
+	sessions, args := store.filterSessions(filter)
+	args = append(args, goal.TenantID)
+	tenant := len(args)
+	var converted, conditions string
+
+	if goal.EventName.Valid {
+		args = append(args, goal.EventName.String)
+		name := len(args)
+		conditions, args = store.pathConditions(filter, args)
+		converted = fmt.Sprintf(`SELECT DISTINCT "events".tenant_id, "events"."fingerprint" FROM (
+				SELECT %[1]s FROM "event"
+				WHERE ($1::bigint IS NULL OR tenant_id = $1)
+				AND "time" >= $2
+				AND "time" < $3
+				UNION ALL
+				SELECT %[1]s FROM "event_archive"
+				WHERE ($1::bigint IS NULL OR tenant_id = $1)
+				AND "time" >= $2
+				AND "time" < $3
+			) AS "events"
+			JOIN "filtered_session" ON "filtered_session".tenant_id IS NOT DISTINCT FROM "events".tenant_id
+			AND "filtered_session"."fingerprint" = "events"."fingerprint"
+			AND "filtered_session"."session" IS NOT DISTINCT FROM "events"."session"
+			WHERE ($%[2]d::bigint IS NULL OR "events".tenant_id = $%[2]d)
+			AND "name" = $%[3]d
+			%[4]s`, eventArchiveColumns, tenant, name, conditions)
+	} else {
+		args = append(args, goal.pathRegex())
+		target := len(args)
+		conditions, args = store.pathConditions(filter, args)
+		converted = fmt.Sprintf(`SELECT DISTINCT tenant_id, "fingerprint"
+			FROM "filtered_hit"
+			WHERE ($%[1]d::bigint IS NULL OR tenant_id = $%[1]d)
+			AND "path" ~* $%[2]d
+			%[3]s`, tenant, target, conditions)
+	}
+
+	query := sessions + fmt.Sprintf(`, "converted" AS (%s),
+		"first_hit" AS (
+			SELECT DISTINCT ON (tenant_id, "fingerprint") tenant_id, "fingerprint", "referrer", "country_code", "browser"
+			FROM "filtered_hit"
+			ORDER BY tenant_id, "fingerprint", "time", id
+		)
+		SELECT "converted".tenant_id,
+		"first_hit"."referrer",
+		"first_hit"."country_code",
+		"first_hit"."browser",
+		count(1) "visitors"
+		FROM "converted"
+		LEFT JOIN "first_hit" ON "first_hit".tenant_id IS NOT DISTINCT FROM "converted".tenant_id AND "first_hit"."fingerprint" = "converted"."fingerprint"
+		GROUP BY "converted".tenant_id, "first_hit"."referrer", "first_hit"."country_code", "first_hit"."browser"
+		ORDER BY "visitors" DESC`, converted)
+	var stats []GoalStats
+
+	if err := store.DB.Select(&stats, query, args...); err != nil {
 		return nil, err
 	}
 
-	return visitors, nil
+	for i := range stats {
+		stats[i].GoalID = goal.ID
+		stats[i].Name = goal.Name
+	}
+
+	return stats, nil
 }
 
-// VisitorPlatform implements the Store interface.
-func (store *PostgresStore) VisitorPlatform(tenantID sql.NullInt64, from, to time.Time) *VisitorStats {
-	query := `SELECT COALESCE(SUM("platform_desktop"), 0) "platform_desktop",
-		COALESCE(SUM("platform_mobile"), 0) "platform_mobile",
-		COALESCE(SUM("platform_unknown"), 0) "platform_unknown"
-		FROM "visitor_stats"
-		WHERE ($1::bigint IS NULL OR tenant_id = $1)
-		AND "day" >= $2::date
-		AND "day" <= $3::date`
-	visitors := new(VisitorStats)
+// FilterFunnelSteps implements the Store interface.
+// A session reaches a step if it visits a page matching the step after the page view that reached the previous step.
+// The steps are matched using case-insensitive regular expressions. The tenant of the funnel is used if set.
+func (store *PostgresStore) FilterFunnelSteps(filter *Filter, funnel *Funnel) ([]FunnelStats, error) {
+	steps := make(pq.StringArray, len(funnel.Steps))
+
+	for i, step := range funnel.Steps {
+		steps[i] = pathPatternToRegex(step)
+	}
+
+	sessions, args := store.filterSessions(filter)
+	args = append(args, funnel.TenantID, steps)
+	tenant, step := len(args)-1, len(args)
+	conditions, args := store.pathConditions(filter, args)
+	query := sessions + fmt.Sprintf(`, "step_hit" AS (
+			SELECT "path",
+			DENSE_RANK() OVER (ORDER BY tenant_id, "fingerprint") "visitor",
+			DENSE_RANK() OVER (ORDER BY tenant_id, "fingerprint", "session") "visit",
+			ROW_NUMBER() OVER (PARTITION BY tenant_id, "fingerprint", "session" ORDER BY "time", id) "n"
+			FROM "filtered_hit"
+			WHERE ($%[1]d::bigint IS NULL OR tenant_id = $%[1]d)
+			%[3]s
+		)
+		SELECT "step" - 1 "step", COUNT(DISTINCT "visitor") "visitors"
+		FROM (
+			WITH RECURSIVE "reached" AS (
+				SELECT "visitor", "visit", MIN("n") "n", 1 "step"
+				FROM "step_hit"
+				WHERE "path" ~* ($%[2]d::varchar[])[1]
+				GROUP BY "visitor", "visit"
+				UNION ALL
+				SELECT "reached"."visitor", "reached"."visit", "next"."n", "reached"."step" + 1
+				FROM "reached"
+				JOIN LATERAL (
+					SELECT "step_hit"."n" FROM "step_hit"
+					WHERE "step_hit"."visit" = "reached"."visit"
+					AND "step_hit"."n" > "reached"."n"
+					AND "step_hit"."path" ~* ($%[2]d::varchar[])["reached"."step" + 1]
+					ORDER BY "step_hit"."n"
+					LIMIT 1
+				) AS "next" ON TRUE
+				WHERE "reached"."step" < array_length($%[2]d::varchar[], 1)
+			)
+			SELECT "visitor", "step" FROM "reached"
+		) AS "funnel_step"
+		GROUP BY "step"
+		ORDER BY "step"`, tenant, step, conditions)
+	var stats []FunnelStats
+
+	if err := store.DB.Select(&stats, query, args...); err != nil {
+		return nil, err
+	}
 
-	if err := store.DB.Get(visitors, query, tenantID, from, to); err != nil && err != sql.ErrNoRows {
-		store.logger.Printf("error reading visitor platforms: %s", err)
-		return nil
+	for i := range stats {
+		stats[i].TenantID = funnel.TenantID
+		stats[i].FunnelID = funnel.ID
 	}
 
-	return visitors
+	return stats, nil
 }
 
-// VisitorScreenSize implements the Store interface.
-func (store *PostgresStore) VisitorScreenSize(tenantID sql.NullInt64, from, to time.Time) ([]ScreenStats, error) {
-	query := `SELECT "width", "height", COALESCE(SUM("visitors"), 0) "visitors"
-		FROM "screen_stats"
-		WHERE ($1::bigint IS NULL OR tenant_id = $1)
-		AND "day" >= $2::date
-		AND "day" <= $3::date
-		GROUP BY "width", "height"
-		ORDER BY "visitors" DESC`
-	var visitors []ScreenStats
-
-	if err := store.DB.Select(&visitors, query, tenantID, from, to); err != nil {
+// FilterContentGroups implements the Store interface.
+// A page belongs to a group if it matches any of its patterns, using a case-insensitive regular expression.
+// Visitors are counted once per group and tenant. Groups without visitors are left out.
+func (store *PostgresStore) FilterContentGroups(filter *Filter, groups []ContentGroup) ([]DimensionStats, error) {
+	names := make(pq.StringArray, len(groups))
+	patterns := make(pq.StringArray, len(groups))
+
+	for i := range groups {
+		names[i] = groups[i].Name
+		patterns[i] = groups[i].pathRegex()
+	}
+
+	sessions, args := store.filterSessions(filter)
+	args = append(args, names, patterns)
+	name, pattern := len(args)-1, len(args)
+	conditions, args := store.pathConditions(filter, args)
+	query := sessions + fmt.Sprintf(`, "group_pattern" AS (
+			SELECT * FROM unnest($%d::varchar[], $%d::varchar[]) AS "g"("name", "pattern")
+		),
+		"group_hit" AS (
+			SELECT "path", DENSE_RANK() OVER (ORDER BY tenant_id, "fingerprint") "visitor"
+			FROM "filtered_hit"
+			WHERE TRUE
+			%s
+		)
+		SELECT "group_pattern"."name" "value", COUNT(DISTINCT "visitor") "visitors", COUNT(1) "page_views"
+		FROM "group_hit"
+		JOIN "group_pattern" ON "group_hit"."path" ~* "group_pattern"."pattern"
+		GROUP BY 1
+		ORDER BY "visitors" DESC, 1 ASC`, name, pattern, conditions)
+	var stats []DimensionStats
+
+	if err := store.DB.Select(&stats, query, args...); err != nil {
 		return nil, err
 	}
 
-	return visitors, nil
+	for i := range stats {
+		stats[i].Dimension = DimensionContentGroup
+	}
+
+	return stats, nil
 }
 
-// VisitorCountry implements the Store interface.
-func (store *PostgresStore) VisitorCountry(tenantID sql.NullInt64, from, to time.Time) ([]CountryStats, error) {
-	query := `SELECT "country_code", COALESCE(SUM("visitors"), 0) "visitors"
-		FROM "country_stats"
-		WHERE ($1::bigint IS NULL OR tenant_id = $1)
-		AND "day" >= $2::date
-		AND "day" <= $3::date
-		GROUP BY "country_code"
-		ORDER BY "visitors" DESC`
-	var visitors []CountryStats
+// VisitorUTMSource implements the Store interface.
+func (store *PostgresStore) VisitorUTMSource(tenantID sql.NullInt64, from, to time.Time) ([]UTMStats, error) {
+	return store.visitorUTM(tenantID, from, to, "utm_source")
+}
 
-	if err := store.DB.Select(&visitors, query, tenantID, from, to); err != nil {
-		return nil, err
-	}
+// VisitorUTMMedium implements the Store interface.
+func (store *PostgresStore) VisitorUTMMedium(tenantID sql.NullInt64, from, to time.Time) ([]UTMStats, error) {
+	return store.visitorUTM(tenantID, from, to, "utm_medium")
+}
 
-	return visitors, nil
+// VisitorUTMCampaign implements the Store interface.
+func (store *PostgresStore) VisitorUTMCampaign(tenantID sql.NullInt64, from, to time.Time) ([]UTMStats, error) {
+	return store.visitorUTM(tenantID, from, to, "utm_campaign")
 }
 
 // PageVisitors implements the Store interface.
@@ -1076,8 +2460,14 @@ func (store *PostgresStore) PageVisitors(tenantID sql.NullInt64, path string, fr
 	query := `SELECT "d" AS "day",
 		COALESCE("path", '') "path",
 		COALESCE("visitor_stats".visitors, 0) "visitors",
+		COALESCE("visitor_stats".page_views, 0) "page_views",
 		COALESCE("visitor_stats".sessions, 0) "sessions",
-        COALESCE("visitor_stats".bounces, 0) "bounces"
+        COALESCE("visitor_stats".bounces, 0) "bounces",
+        COALESCE("visitor_stats".sessions_started, 0) "sessions_started",
+        COALESCE("visitor_stats".session_duration, 0) "session_duration",
+        COALESCE("visitor_stats".session_duration_count, 0) "session_duration_count",
+        COALESCE("visitor_stats".time_on_page, 0) "time_on_page",
+        COALESCE("visitor_stats".time_on_page_count, 0) "time_on_page_count"
 		FROM (
 			SELECT * FROM generate_series(
 				$2::date,
@@ -1101,8 +2491,8 @@ func (store *PostgresStore) PageVisitors(tenantID sql.NullInt64, path string, fr
 // PageLanguages implements the Store interface.
 func (store *PostgresStore) PageLanguages(tenantID sql.NullInt64, path string, from time.Time, to time.Time) ([]LanguageStats, error) {
 	query := `SELECT * FROM (
-			SELECT "language", sum("visitors") "visitors" FROM (
-				SELECT "language", sum("visitors") "visitors"
+			SELECT "language", sum("visitors") "visitors", sum("page_views") "page_views" FROM (
+				SELECT "language", sum("visitors") "visitors", sum("page_views") "page_views"
 				FROM "language_stats"
 				WHERE ($1::bigint IS NULL OR tenant_id = $1)
 				AND "day" >= date($2::timestamp)
@@ -1110,7 +2500,7 @@ func (store *PostgresStore) PageLanguages(tenantID sql.NullInt64, path string, f
 				AND LOWER("path") = LOWER($4)
 				GROUP BY "language"
 				UNION
-				SELECT "language", count(DISTINCT fingerprint) "visitors"
+				SELECT "language", count(DISTINCT fingerprint) "visitors", count(1) "page_views"
 				FROM "hit"
 				WHERE ($1::bigint IS NULL OR tenant_id = $1)
 				AND date("time") >= date($2::timestamp)
@@ -1133,8 +2523,8 @@ func (store *PostgresStore) PageLanguages(tenantID sql.NullInt64, path string, f
 // PageReferrer implements the Store interface.
 func (store *PostgresStore) PageReferrer(tenantID sql.NullInt64, path string, from time.Time, to time.Time) ([]ReferrerStats, error) {
 	query := `SELECT * FROM (
-			SELECT "referrer", sum("visitors") "visitors" FROM (
-				SELECT "referrer", sum("visitors") "visitors"
+			SELECT "referrer", sum("visitors") "visitors", sum("page_views") "page_views" FROM (
+				SELECT "referrer", sum("visitors") "visitors", sum("page_views") "page_views"
 				FROM "referrer_stats"
 				WHERE ($1::bigint IS NULL OR tenant_id = $1)
 				AND "day" >= date($2::timestamp)
@@ -1142,7 +2532,7 @@ func (store *PostgresStore) PageReferrer(tenantID sql.NullInt64, path string, fr
 				AND LOWER("path") = LOWER($4)
 				GROUP BY "referrer"
 				UNION
-				SELECT "referrer", count(DISTINCT fingerprint) "visitors"
+				SELECT "referrer", count(DISTINCT fingerprint) "visitors", count(1) "page_views"
 				FROM "hit"
 				WHERE ($1::bigint IS NULL OR tenant_id = $1)
 				AND date("time") >= date($2::timestamp)
@@ -1165,8 +2555,8 @@ func (store *PostgresStore) PageReferrer(tenantID sql.NullInt64, path string, fr
 // PageOS implements the Store interface.
 func (store *PostgresStore) PageOS(tenantID sql.NullInt64, path string, from time.Time, to time.Time) ([]OSStats, error) {
 	query := `SELECT * FROM (
-			SELECT "os", sum("visitors") "visitors" FROM (
-				SELECT "os", sum("visitors") "visitors"
+			SELECT "os", sum("visitors") "visitors", sum("page_views") "page_views" FROM (
+				SELECT "os", sum("visitors") "visitors", sum("page_views") "page_views"
 				FROM "os_stats"
 				WHERE ($1::bigint IS NULL OR tenant_id = $1)
 				AND "day" >= date($2::timestamp)
@@ -1174,7 +2564,7 @@ func (store *PostgresStore) PageOS(tenantID sql.NullInt64, path string, from tim
 				AND LOWER("path") = LOWER($4)
 				GROUP BY "os"
 				UNION
-				SELECT "os", count(DISTINCT fingerprint) "visitors"
+				SELECT "os", count(DISTINCT fingerprint) "visitors", count(1) "page_views"
 				FROM "hit"
 				WHERE ($1::bigint IS NULL OR tenant_id = $1)
 				AND date("time") >= date($2::timestamp)
@@ -1197,8 +2587,8 @@ func (store *PostgresStore) PageOS(tenantID sql.NullInt64, path string, from tim
 // PageBrowser implements the Store interface.
 func (store *PostgresStore) PageBrowser(tenantID sql.NullInt64, path string, from time.Time, to time.Time) ([]BrowserStats, error) {
 	query := `SELECT * FROM (
-			SELECT "browser", sum("visitors") "visitors" FROM (
-				SELECT "browser", sum("visitors") "visitors"
+			SELECT "browser", sum("visitors") "visitors", sum("page_views") "page_views" FROM (
+				SELECT "browser", sum("visitors") "visitors", sum("page_views") "page_views"
 				FROM "browser_stats"
 				WHERE ($1::bigint IS NULL OR tenant_id = $1)
 				AND "day" >= date($2::timestamp)
@@ -1206,7 +2596,7 @@ func (store *PostgresStore) PageBrowser(tenantID sql.NullInt64, path string, fro
 				AND LOWER("path") = LOWER($4)
 				GROUP BY "browser"
 				UNION
-				SELECT "browser", count(DISTINCT fingerprint) "visitors"
+				SELECT "browser", count(DISTINCT fingerprint) "visitors", count(1) "page_views"
 				FROM "hit"
 				WHERE ($1::bigint IS NULL OR tenant_id = $1)
 				AND date("time") >= date($2::timestamp)
@@ -1279,6 +2669,158 @@ func (store *PostgresStore) PagePlatform(tenantID sql.NullInt64, path string, fr
 	return visitors
 }
 
+// Events implements the Store interface.
+func (store *PostgresStore) Events(tenantID sql.NullInt64, from, to time.Time, path string) ([]EventStats, error) {
+	query := `SELECT "name",
+		COALESCE(SUM("visitors"), 0) "visitors",
+		COALESCE(SUM("events"), 0) "events",
+		COALESCE(SUM("value"), 0) "value"
+		FROM "event_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" >= $2::date
+		AND "day" <= $3::date
+		AND ($4 = '' OR LOWER("path") = LOWER($4))
+		GROUP BY "name"
+		ORDER BY "visitors" DESC, "name" ASC`
+	var events []EventStats
+
+	if err := store.DB.Select(&events, query, tenantID, from, to, path); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// EventMetadata implements the Store interface.
+func (store *PostgresStore) EventMetadata(tenantID sql.NullInt64, name string, from, to time.Time, path string) ([]EventMetadataStats, error) {
+	query := `SELECT "name", "meta_key", "meta_value",
+		COALESCE(SUM("visitors"), 0) "visitors",
+		COALESCE(SUM("events"), 0) "events"
+		FROM "event_metadata_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "name" = $2
+		AND "day" >= $3::date
+		AND "day" <= $4::date
+		AND ($5 = '' OR LOWER("path") = LOWER($5))
+		GROUP BY "name", "meta_key", "meta_value"
+		ORDER BY "visitors" DESC, "meta_key" ASC, "meta_value" ASC`
+	var metadata []EventMetadataStats
+
+	if err := store.DB.Select(&metadata, query, tenantID, name, from, to, path); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+// GoalConversions implements the Store interface.
+func (store *PostgresStore) GoalConversions(tenantID sql.NullInt64, from, to time.Time) ([]GoalStats, error) {
+	query := `SELECT "goal_id", "goal"."name",
+		COALESCE(SUM("visitors"), 0) "visitors"
+		FROM "goal_stats"
+		JOIN "goal" ON "goal".id = "goal_stats".goal_id
+		WHERE ($1::bigint IS NULL OR "goal_stats".tenant_id = $1)
+		AND "day" >= $2::date
+		AND "day" <= $3::date
+		GROUP BY "goal_id", "goal"."name"
+		ORDER BY "visitors" DESC, "goal"."name" ASC`
+	var stats []GoalStats
+
+	if err := store.DB.Select(&stats, query, tenantID, from, to); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// GoalReferrer implements the Store interface.
+func (store *PostgresStore) GoalReferrer(tenantID sql.NullInt64, goalID int64, from, to time.Time) ([]GoalStats, error) {
+	return store.goalConversionsBy(tenantID, goalID, from, to, "referrer")
+}
+
+// GoalCountry implements the Store interface.
+func (store *PostgresStore) GoalCountry(tenantID sql.NullInt64, goalID int64, from, to time.Time) ([]GoalStats, error) {
+	return store.goalConversionsBy(tenantID, goalID, from, to, "country_code")
+}
+
+// GoalBrowser implements the Store interface.
+func (store *PostgresStore) GoalBrowser(tenantID sql.NullInt64, goalID int64, from, to time.Time) ([]GoalStats, error) {
+	return store.goalConversionsBy(tenantID, goalID, from, to, "browser")
+}
+
+// FunnelSteps implements the Store interface.
+func (store *PostgresStore) FunnelSteps(tenantID sql.NullInt64, funnelID int64, from, to time.Time) ([]FunnelStats, error) {
+	query := `SELECT "funnel_id", "step",
+		COALESCE(SUM("visitors"), 0) "visitors"
+		FROM "funnel_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "funnel_id" = $2
+		AND "day" >= $3::date
+		AND "day" <= $4::date
+		GROUP BY "funnel_id", "step"
+		ORDER BY "step" ASC`
+	var stats []FunnelStats
+
+	if err := store.DB.Select(&stats, query, tenantID, funnelID, from, to); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// EntryPages implements the Store interface.
+func (store *PostgresStore) EntryPages(tenantID sql.NullInt64, from, to time.Time, path string) ([]EntryStats, error) {
+	query := `SELECT "path",
+		COALESCE(SUM("visitors"), 0) "visitors",
+		COALESCE(SUM("entries"), 0) "entries"
+		FROM "entry_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" >= $2::date
+		AND "day" <= $3::date
+		AND ($4 = '' OR LOWER("path") = LOWER($4))
+		GROUP BY "path"
+		ORDER BY "entries" DESC, "path" ASC`
+	var stats []EntryStats
+
+	if err := store.DB.Select(&stats, query, tenantID, from, to, path); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// ExitPages implements the Store interface.
+func (store *PostgresStore) ExitPages(tenantID sql.NullInt64, from, to time.Time, path string) ([]ExitStats, error) {
+	query := `SELECT "path",
+		COALESCE(SUM("visitors"), 0) "visitors",
+		COALESCE(SUM("sessions"), 0) "sessions",
+		COALESCE(SUM("exits"), 0) "exits"
+		FROM "exit_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" >= $2::date
+		AND "day" <= $3::date
+		AND ($4 = '' OR LOWER("path") = LOWER($4))
+		GROUP BY "path"
+		ORDER BY "exits" DESC, "path" ASC`
+	var stats []ExitStats
+
+	if err := store.DB.Select(&stats, query, tenantID, from, to, path); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// NextPages implements the Store interface.
+func (store *PostgresStore) NextPages(tenantID sql.NullInt64, from, to time.Time, path string) ([]TransitionStats, error) {
+	return store.transitions(tenantID, from, to, path, "path", "next_path")
+}
+
+// PreviousPages implements the Store interface.
+func (store *PostgresStore) PreviousPages(tenantID sql.NullInt64, from, to time.Time, path string) ([]TransitionStats, error) {
+	return store.transitions(tenantID, from, to, path, "next_path", "path")
+}
+
 // VisitorsSum implements the Store interface.
 func (store *PostgresStore) VisitorsSum(tenantID sql.NullInt64, from, to time.Time, path string) (*Stats, error) {
 	args := make([]interface{}, 0, 4)
@@ -1286,8 +2828,14 @@ func (store *PostgresStore) VisitorsSum(tenantID sql.NullInt64, from, to time.Ti
 	args = append(args, from)
 	args = append(args, to)
 	query := `SELECT COALESCE(SUM("visitors"), 0) "visitors",
+        COALESCE(SUM("page_views"), 0) "page_views",
         COALESCE(SUM("sessions"), 0) "sessions",
-        COALESCE(SUM("bounces"), 0) "bounces"
+        COALESCE(SUM("bounces"), 0) "bounces",
+        COALESCE(SUM("sessions_started"), 0) "sessions_started",
+        COALESCE(SUM("session_duration"), 0) "session_duration",
+        COALESCE(SUM("session_duration_count"), 0) "session_duration_count",
+        COALESCE(SUM("time_on_page"), 0) "time_on_page",
+        COALESCE(SUM("time_on_page_count"), 0) "time_on_page_count"
 		FROM "visitor_stats"
 		WHERE ($1::bigint IS NULL OR tenant_id = $1)
 		AND "day" >= $2::date
@@ -1310,8 +2858,9 @@ func (store *PostgresStore) VisitorsSum(tenantID sql.NullInt64, from, to time.Ti
 func (store *PostgresStore) createUpdateEntity(tx *sqlx.Tx, entity, existing statsEntity, found bool, insertQuery, updateQuery string) error {
 	if found {
 		visitors := existing.GetVisitors() + entity.GetVisitors()
+		pageViews := existing.GetPageViews() + entity.GetPageViews()
 
-		if _, err := tx.Exec(updateQuery, visitors, existing.GetID()); err != nil {
+		if _, err := tx.Exec(updateQuery, visitors, pageViews, existing.GetID()); err != nil {
 			return err
 		}
 	} else {
@@ -1327,6 +2876,250 @@ func (store *PostgresStore) createUpdateEntity(tx *sqlx.Tx, entity, existing sta
 	return nil
 }
 
+// goalConversionsBy returns the converted visitors for given goal grouped by given column.
+// The column must not be user input.
+func (store *PostgresStore) goalConversionsBy(tenantID sql.NullInt64, goalID int64, from, to time.Time, column string) ([]GoalStats, error) {
+	query := fmt.Sprintf(`SELECT "goal_id", "%[1]s",
+		COALESCE(SUM("visitors"), 0) "visitors"
+		FROM "goal_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "goal_id" = $2
+		AND "day" >= $3::date
+		AND "day" <= $4::date
+		GROUP BY "goal_id", "%[1]s"
+		ORDER BY "visitors" DESC, "%[1]s" ASC`, column)
+	var stats []GoalStats
+
+	if err := store.DB.Select(&stats, query, tenantID, goalID, from, to); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// transitions returns the visitor and transition count for given time frame filtered by the path in given column
+// and grouped by the path in the other column. The columns must not be user input.
+func (store *PostgresStore) transitions(tenantID sql.NullInt64, from, to time.Time, path, filterColumn, groupColumn string) ([]TransitionStats, error) {
+	query := fmt.Sprintf(`SELECT $4::varchar "%[1]s", "%[2]s",
+		COALESCE(SUM("visitors"), 0) "visitors",
+		COALESCE(SUM("transitions"), 0) "transitions"
+		FROM "transition_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" >= $2::date
+		AND "day" <= $3::date
+		AND LOWER("%[1]s") = LOWER($4)
+		GROUP BY "%[2]s"
+		ORDER BY "transitions" DESC, "%[2]s" ASC`, filterColumn, groupColumn)
+	var stats []TransitionStats
+
+	if err := store.DB.Select(&stats, query, tenantID, from, to, path); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// filterTransitions returns the transitions within the sessions matching given filter from or to its path.
+// The filter column is compared to the path of the filter and the group column must match its path pattern.
+// Paths are grouped case-insensitively and page views repeating the previous path (like reloads) are not counted as a transition.
+// The columns must not be user input.
+func (store *PostgresStore) filterTransitions(filter *Filter, filterColumn, groupColumn string) ([]TransitionStats, error) {
+	sessions, args := store.filterSessions(filter)
+	args = append(args, filter.Path)
+	path := len(args)
+	conditions := ""
+
+	if filter.hasPathPattern() {
+		args = append(args, lowerPaths(filter.paths))
+		conditions = fmt.Sprintf(`AND LOWER("%s") = ANY($%d)`, groupColumn, len(args))
+	}
+
+	query := sessions + fmt.Sprintf(`, "transition" AS (
+			SELECT "path",
+			LEAD("path") OVER (PARTITION BY tenant_id, "fingerprint", "session" ORDER BY "time", id) "next_path",
+			DENSE_RANK() OVER (ORDER BY tenant_id, "fingerprint") "visitor"
+			FROM "filtered_hit"
+		)
+		SELECT $%[3]d::varchar "%[1]s", MIN("%[2]s") "%[2]s",
+		COUNT(DISTINCT "visitor") "visitors",
+		COUNT(1) "transitions"
+		FROM "transition"
+		WHERE "next_path" IS NOT NULL
+		AND "path" <> "next_path"
+		AND LOWER("%[1]s") = LOWER($%[3]d)
+		%[4]s
+		GROUP BY LOWER("%[2]s")
+		ORDER BY "transitions" DESC, 2 ASC`, filterColumn, groupColumn, path, conditions)
+	var stats []TransitionStats
+
+	if err := store.DB.Select(&stats, query, args...); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// visitorUTM returns the visitors for given time frame grouped by given campaign parameter column.
+// The column must not be user input.
+func (store *PostgresStore) visitorUTM(tenantID sql.NullInt64, from, to time.Time, column string) ([]UTMStats, error) {
+	query := fmt.Sprintf(`SELECT "%[1]s", COALESCE(SUM("visitors"), 0) "visitors", COALESCE(SUM("page_views"), 0) "page_views"
+		FROM "utm_stats"
+		WHERE ($1::bigint IS NULL OR tenant_id = $1)
+		AND "day" >= $2::date
+		AND "day" <= $3::date
+		AND "%[1]s" IS NOT NULL
+		GROUP BY "%[1]s"
+		ORDER BY "visitors" DESC`, column)
+	var visitors []UTMStats
+
+	if err := store.DB.Select(&visitors, query, tenantID, from, to); err != nil {
+		return nil, err
+	}
+
+	return visitors, nil
+}
+
+// lowerPaths returns given paths in lowercase, to be compared to the lowercase path of hits.
+func lowerPaths(paths []string) pq.StringArray {
+	lower := make(pq.StringArray, len(paths))
+
+	for i, path := range paths {
+		lower[i] = strings.ToLower(path)
+	}
+
+	return lower
+}
+
+// writeValuePlaceholders writes a list of n numbered placeholders starting after offset, like ($1, $2, $3),
+// followed by a comma.
+func writeValuePlaceholders(query *strings.Builder, offset, n int) {
+	query.WriteRune('(')
+
+	for i := 1; i <= n; i++ {
+		query.WriteString(fmt.Sprintf("$%d", offset+i))
+
+		if i < n {
+			query.WriteString(", ")
+		}
+	}
+
+	query.WriteString("),")
+}
+
+// filterSessions returns the common table expressions to filter hits by the time frame and visitor attributes of given filter and the arguments for them.
+// The hits are read from the "hit" and "hit_archive" table. "filtered_session" contains the sessions whose first page view matches the visitor attributes
+// and "filtered_hit" all hits of these sessions. The path is not filtered. The days of the time frame are those in the time zone of the filter.
+func (store *PostgresStore) filterSessions(filter *Filter) (string, []interface{}) {
+	from, to := filter.timeRange()
+	conditions, args := store.visitorConditions(filter, []interface{}{filter.TenantID, from, to})
+	return fmt.Sprintf(`WITH "all_hit" AS (
+			SELECT %[2]s FROM "hit"
+			WHERE ($1::bigint IS NULL OR tenant_id = $1)
+			AND "time" >= $2
+			AND "time" < $3
+			UNION ALL
+			SELECT %[2]s FROM "hit_archive"
+			WHERE ($1::bigint IS NULL OR tenant_id = $1)
+			AND "time" >= $2
+			AND "time" < $3
+		),
+		"filtered_session" AS (
+			SELECT tenant_id, "fingerprint", "session" FROM (
+				SELECT DISTINCT ON (tenant_id, "fingerprint", "session") *
+				FROM "all_hit"
+				ORDER BY tenant_id, "fingerprint", "session", "time", id
+			) AS "entry"
+			WHERE TRUE
+			%[1]s
+		),
+		"filtered_hit" AS (
+			SELECT "all_hit".* FROM "all_hit"
+			JOIN "filtered_session" ON "filtered_session".tenant_id IS NOT DISTINCT FROM "all_hit".tenant_id
+			AND "filtered_session"."fingerprint" = "all_hit"."fingerprint"
+			AND "filtered_session"."session" IS NOT DISTINCT FROM "all_hit"."session"
+		)
+		`, conditions, hitArchiveColumns), args
+}
+
+// filterConditions returns the conditions and arguments to filter for the path, resolved path pattern, and visitor attributes of given filter.
+// The arguments are appended to the ones passed in.
+func (store *PostgresStore) filterConditions(filter *Filter, args []interface{}) (string, []interface{}) {
+	paths, args := store.pathConditions(filter, args)
+	visitor, args := store.visitorConditions(filter, args)
+	return paths + visitor, args
+}
+
+// pathConditions returns the conditions and arguments to filter for the path and resolved path pattern of given filter.
+// Paths are compared case-insensitively. The arguments are appended to the ones passed in.
+func (store *PostgresStore) pathConditions(filter *Filter, args []interface{}) (string, []interface{}) {
+	var conditions strings.Builder
+
+	if filter.Path != "" {
+		args = append(args, filter.Path)
+		conditions.WriteString(fmt.Sprintf(` AND LOWER("path") = LOWER($%d)`, len(args)))
+	}
+
+	if filter.hasPathPattern() {
+		args = append(args, lowerPaths(filter.paths))
+		conditions.WriteString(fmt.Sprintf(` AND LOWER("path") = ANY($%d)`, len(args)))
+	}
+
+	return conditions.String(), args
+}
+
+// visitorConditions returns the conditions and arguments to filter for the visitor attributes of given filter.
+// Attributes are compared case-insensitively. The arguments are appended to the ones passed in.
+func (store *PostgresStore) visitorConditions(filter *Filter, args []interface{}) (string, []interface{}) {
+	var conditions strings.Builder
+	equals := func(column string, value interface{}) {
+		args = append(args, value)
+		conditions.WriteString(fmt.Sprintf(` AND "%s" = $%d`, column, len(args)))
+	}
+	equalsIgnoreCase := func(column, value string) {
+		args = append(args, value)
+		conditions.WriteString(fmt.Sprintf(` AND LOWER("%s") = LOWER($%d)`, column, len(args)))
+	}
+
+	if filter.Referrer != "" {
+		equalsIgnoreCase("referrer", filter.Referrer)
+	}
+
+	if filter.Country != "" {
+		equalsIgnoreCase("country_code", filter.Country)
+	}
+
+	if filter.Language != "" {
+		equalsIgnoreCase("language", filter.Language)
+	}
+
+	if filter.OS != "" {
+		equalsIgnoreCase("os", filter.OS)
+	}
+
+	if filter.Browser != "" {
+		equalsIgnoreCase("browser", filter.Browser)
+	}
+
+	if filter.ScreenWidth != 0 {
+		equals("screen_width", filter.ScreenWidth)
+	}
+
+	if filter.ScreenHeight != 0 {
+		equals("screen_height", filter.ScreenHeight)
+	}
+
+	switch filter.Platform {
+	case PlatformDesktop:
+		conditions.WriteString(` AND "desktop" IS TRUE`)
+	case PlatformMobile:
+		conditions.WriteString(` AND "mobile" IS TRUE`)
+	case PlatformUnknown:
+		conditions.WriteString(` AND "desktop" IS FALSE AND "mobile" IS FALSE`)
+	}
+
+	return conditions.String(), args
+}
+
 func (store *PostgresStore) closeRows(rows *sqlx.Rows) {
 	if err := rows.Close(); err != nil {
 		store.logger.Printf("error closing rows: %s", err)
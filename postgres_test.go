@@ -346,21 +346,661 @@ func TestPostgresStore_SaveCountryStats(t *testing.T) {
 	}
 }
 
+func TestPostgresStore_SaveEventStats(t *testing.T) {
+	cleanupDB(t)
+	db := sqlx.NewDb(postgresDB, "postgres")
+	store := NewPostgresStore(postgresDB, nil)
+	err := store.SaveEventStats(nil, &EventStats{
+		Stats: Stats{
+			Day:      day(2020, 9, 3, 0),
+			Path:     "/path",
+			Visitors: 42,
+		},
+		Name:   "signup",
+		Events: 50,
+		Value:  2.5,
+	})
+
+	if err != nil {
+		t.Fatalf("Entity must have been saved, but was: %v", err)
+	}
+
+	stats := new(EventStats)
+
+	if err := db.Get(stats, `SELECT * FROM "event_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	stats.Visitors = 11
+	stats.Events = 12
+	stats.Value = 1.5
+	err = store.SaveEventStats(nil, stats)
+
+	if err != nil {
+		t.Fatalf("Entity must have been updated, but was: %v", err)
+	}
+
+	if err := db.Get(stats, `SELECT * FROM "event_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Visitors != 42+11 ||
+		stats.Events != 50+12 ||
+		stats.Value != 4 ||
+		stats.Path != "/path" ||
+		stats.Name != "signup" {
+		t.Fatalf("Entity not as expected: %v", stats)
+	}
+}
+
+func TestPostgresStore_SaveReferrerSourceStats(t *testing.T) {
+	cleanupDB(t)
+	db := sqlx.NewDb(postgresDB, "postgres")
+	store := NewPostgresStore(postgresDB, nil)
+	err := store.SaveReferrerSourceStats(nil, &ReferrerSourceStats{
+		Stats: Stats{
+			Day:      day(2020, 9, 3, 0),
+			Visitors: 42,
+		},
+		Channel: sql.NullString{String: ChannelDirect, Valid: true},
+	})
+
+	if err != nil {
+		t.Fatalf("Entity must have been saved, but was: %v", err)
+	}
+
+	stats := new(ReferrerSourceStats)
+
+	if err := db.Get(stats, `SELECT * FROM "referrer_source_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	stats.Visitors = 11
+	err = store.SaveReferrerSourceStats(nil, stats)
+
+	if err != nil {
+		t.Fatalf("Entity must have been updated, but was: %v", err)
+	}
+
+	if err := db.Get(stats, `SELECT * FROM "referrer_source_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Visitors != 42+11 ||
+		stats.ReferrerName.Valid ||
+		stats.Channel.String != ChannelDirect {
+		t.Fatalf("Entity not as expected: %v", stats)
+	}
+}
+
+func TestPostgresStore_SaveUTMStats(t *testing.T) {
+	cleanupDB(t)
+	db := sqlx.NewDb(postgresDB, "postgres")
+	store := NewPostgresStore(postgresDB, nil)
+	err := store.SaveUTMStats(nil, &UTMStats{
+		Stats: Stats{
+			Day:      day(2020, 9, 3, 0),
+			Visitors: 42,
+		},
+		UTMSource: sql.NullString{String: "google", Valid: true},
+		UTMMedium: sql.NullString{String: "cpc", Valid: true},
+	})
+
+	if err != nil {
+		t.Fatalf("Entity must have been saved, but was: %v", err)
+	}
+
+	stats := new(UTMStats)
+
+	if err := db.Get(stats, `SELECT * FROM "utm_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	stats.Visitors = 11
+	err = store.SaveUTMStats(nil, stats)
+
+	if err != nil {
+		t.Fatalf("Entity must have been updated, but was: %v", err)
+	}
+
+	if err := db.Get(stats, `SELECT * FROM "utm_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Visitors != 42+11 ||
+		stats.UTMSource.String != "google" ||
+		stats.UTMMedium.String != "cpc" ||
+		stats.UTMCampaign.Valid {
+		t.Fatalf("Entity not as expected: %v", stats)
+	}
+}
+
+func TestPostgresStore_SaveGoalStats(t *testing.T) {
+	cleanupDB(t)
+	db := sqlx.NewDb(postgresDB, "postgres")
+	store := NewPostgresStore(postgresDB, nil)
+	err := store.SaveGoalStats(nil, &GoalStats{
+		Stats: Stats{
+			Day:      day(2020, 9, 3, 0),
+			Visitors: 42,
+		},
+		GoalID:      1,
+		CountryCode: sql.NullString{String: "de", Valid: true},
+	})
+
+	if err != nil {
+		t.Fatalf("Entity must have been saved, but was: %v", err)
+	}
+
+	stats := new(GoalStats)
+
+	if err := db.Get(stats, `SELECT * FROM "goal_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	stats.Visitors = 11
+	err = store.SaveGoalStats(nil, stats)
+
+	if err != nil {
+		t.Fatalf("Entity must have been updated, but was: %v", err)
+	}
+
+	if err := db.Get(stats, `SELECT * FROM "goal_stats"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Visitors != 42+11 ||
+		stats.GoalID != 1 ||
+		stats.Referrer.Valid ||
+		stats.CountryCode.String != "de" {
+		t.Fatalf("Entity not as expected: %v", stats)
+	}
+}
+
+func TestPostgresStore_Goals(t *testing.T) {
+	cleanupDB(t)
+	store := NewPostgresStore(postgresDB, nil)
+	goal := NewPathGoal(NullTenant, "Signup", "/signup")
+
+	if err := store.SaveGoal(goal); err != nil {
+		t.Fatalf("Goal must have been saved, but was: %v", err)
+	}
+
+	if goal.ID == 0 {
+		t.Fatal("Goal ID must have been set")
+	}
+
+	goal.Name = "Sign up"
+
+	if err := store.SaveGoal(goal); err != nil {
+		t.Fatalf("Goal must have been updated, but was: %v", err)
+	}
+
+	if err := store.SaveGoal(NewPathGoal(NullTenant, "", "/")); err != errGoalName {
+		t.Fatalf("Invalid goal must not have been saved, but was: %v", err)
+	}
+
+	goals, err := store.Goals(NullTenant)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(goals) != 1 || goals[0].ID != goal.ID || goals[0].Name != "Sign up" || goals[0].PathPattern.String != "/signup" {
+		t.Fatalf("Goals not as expected: %v", goals)
+	}
+
+	if err := store.DeleteGoal(NullTenant, goal.ID); err != nil {
+		t.Fatalf("Goal must have been deleted, but was: %v", err)
+	}
+
+	goals, err = store.Goals(NullTenant)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(goals) != 0 {
+		t.Fatalf("Goal must have been deleted, but was: %v", goals)
+	}
+}
+
+func TestPostgresStore_SaveEntryExitStats(t *testing.T) {
+	cleanupDB(t)
+	store := NewPostgresStore(postgresDB, nil)
+
+	for i := 0; i < 2; i++ {
+		if err := store.SaveEntryStats(nil, &EntryStats{Stats: Stats{Day: day(2020, 9, 3, 0), Path: "/", Visitors: 3}, Entries: 4}); err != nil {
+			t.Fatalf("Entry stats must have been saved, but was: %v", err)
+		}
+
+		if err := store.SaveExitStats(nil, &ExitStats{Stats: Stats{Day: day(2020, 9, 3, 0), Path: "/", Visitors: 2, Sessions: 5}, Exits: 3}); err != nil {
+			t.Fatalf("Exit stats must have been saved, but was: %v", err)
+		}
+	}
+
+	entries, err := store.EntryPages(NullTenant, day(2020, 9, 1, 0), day(2020, 9, 3, 0), "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || entries[0].Path != "/" || entries[0].Visitors != 6 || entries[0].Entries != 8 {
+		t.Fatalf("Entry stats not as expected: %v", entries)
+	}
+
+	exits, err := store.ExitPages(NullTenant, day(2020, 9, 1, 0), day(2020, 9, 3, 0), "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(exits) != 1 || exits[0].Path != "/" || exits[0].Visitors != 4 || exits[0].Sessions != 10 || exits[0].Exits != 6 {
+		t.Fatalf("Exit stats not as expected: %v", exits)
+	}
+}
+
+func TestPostgresStore_SaveTransitionStats(t *testing.T) {
+	cleanupDB(t)
+	store := NewPostgresStore(postgresDB, nil)
+
+	for i := 0; i < 2; i++ {
+		if err := store.SaveTransitionStats(nil, &TransitionStats{Stats: Stats{Day: day(2020, 9, 3, 0), Path: "/", Visitors: 3}, NextPath: "/pricing", Transitions: 4}); err != nil {
+			t.Fatalf("Transition stats must have been saved, but was: %v", err)
+		}
+	}
+
+	if err := store.SaveTransitionStats(nil, &TransitionStats{Stats: Stats{Day: day(2020, 9, 3, 0), Path: "/about", Visitors: 1}, NextPath: "/pricing", Transitions: 1}); err != nil {
+		t.Fatalf("Transition stats must have been saved, but was: %v", err)
+	}
+
+	next, err := store.NextPages(NullTenant, day(2020, 9, 1, 0), day(2020, 9, 3, 0), "/")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(next) != 1 || next[0].Path != "/" || next[0].NextPath != "/pricing" || next[0].Visitors != 6 || next[0].Transitions != 8 {
+		t.Fatalf("Next pages not as expected: %v", next)
+	}
+
+	previous, err := store.PreviousPages(NullTenant, day(2020, 9, 1, 0), day(2020, 9, 3, 0), "/pricing")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(previous) != 2 ||
+		previous[0].Path != "/" || previous[0].NextPath != "/pricing" || previous[0].Transitions != 8 ||
+		previous[1].Path != "/about" || previous[1].NextPath != "/pricing" || previous[1].Transitions != 1 {
+		t.Fatalf("Previous pages not as expected: %v", previous)
+	}
+}
+
+func TestPostgresStore_SaveDimensionStats(t *testing.T) {
+	cleanupDB(t)
+	store := NewPostgresStore(postgresDB, nil)
+	dimension := Dimension{Name: "utm_source", Column: "utm_source"}
+
+	for i := 0; i < 2; i++ {
+		if err := store.SaveDimensionStats(nil, &DimensionStats{Stats: Stats{Day: day(2020, 9, 3, 0), Visitors: 3, PageViews: 5}, Dimension: "utm_source", Value: sql.NullString{String: "Newsletter", Valid: true}}); err != nil {
+			t.Fatalf("Dimension stats must have been saved, but was: %v", err)
+		}
+	}
+
+	if err := store.SaveDimensionStats(nil, &DimensionStats{Stats: Stats{Day: day(2020, 9, 3, 0), Visitors: 1, PageViews: 1}, Dimension: "utm_source"}); err != nil {
+		t.Fatalf("Dimension stats must have been saved, but was: %v", err)
+	}
+
+	if err := store.SaveDimensionStats(nil, &DimensionStats{Stats: Stats{Day: day(2020, 9, 3, 0), Visitors: 9, PageViews: 9}, Dimension: "utm_medium", Value: sql.NullString{String: "email", Valid: true}}); err != nil {
+		t.Fatalf("Dimension stats must have been saved, but was: %v", err)
+	}
+
+	visitors, err := store.VisitorDimension(NullTenant, day(2020, 9, 1, 0), day(2020, 9, 3, 0), dimension)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(visitors) != 2 ||
+		visitors[0].Dimension != "utm_source" || visitors[0].Value.String != "Newsletter" || visitors[0].Visitors != 6 || visitors[0].PageViews != 10 ||
+		visitors[1].Dimension != "utm_source" || visitors[1].Value.Valid || visitors[1].Visitors != 1 || visitors[1].PageViews != 1 {
+		t.Fatalf("Dimension stats not as expected: %v", visitors)
+	}
+}
+
+func TestPostgresStore_FilterSessions(t *testing.T) {
+	cleanupDB(t)
+	store := NewPostgresStore(postgresDB, nil)
+	session := pastDay(1)
+	createHit(t, store, 0, "fp1", "/", "en", "ua1", "", session, session, OSWindows, "10", BrowserFirefox, "80.0", "de", true, false, 1920, 1080)
+	createHit(t, store, 0, "fp1", "/pricing", "en", "ua1", "", session.Add(time.Second*30), session, OSWindows, "10", BrowserFirefox, "80.0", "gb", true, false, 1920, 1080)
+	createHit(t, store, 0, "fp2", "/pricing", "en", "ua2", "", session, session, OSMac, "10", BrowserChrome, "84.0", "gb", true, false, 1920, 1080)
+
+	if err := store.ArchiveHitsByDay(nil, NullTenant, pastDay(1)); err != nil {
+		t.Fatalf("Hits must have been archived, but was: %v", err)
+	}
+
+	if err := store.DeleteHitsByDay(nil, NullTenant, pastDay(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	createHit(t, store, 0, "fp3", "/", "de", "ua3", "", today(), today(), OSAndroid, "10", BrowserFirefox, "80.0", "de", false, true, 400, 800)
+	event, _ := newEvent(Hit{Fingerprint: "fp1", Session: sql.NullTime{Time: session, Valid: true}, Path: sql.NullString{String: "/pricing", Valid: true}, Time: session.Add(time.Minute)}, &EventOptions{Name: "signup"})
+
+	if err := store.SaveEvents([]Event{event}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the country of the first page view of the session is used, so that /pricing is included for fp1
+	filter := &Filter{From: pastDay(1), To: today(), Country: "DE"}
+	entries, err := store.FilterEntryPages(filter)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || entries[0].Path != "/" || entries[0].Visitors != 2 || entries[0].Entries != 2 {
+		t.Fatalf("Entry pages not as expected: %v", entries)
+	}
+
+	exits, err := store.FilterExitPages(filter)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(exits) != 2 || exits[0].Path != "/" || exits[0].Visitors != 1 || exits[0].Sessions != 2 || exits[0].Exits != 1 ||
+		exits[1].Path != "/pricing" || exits[1].Visitors != 1 || exits[1].Sessions != 1 || exits[1].Exits != 1 {
+		t.Fatalf("Exit pages not as expected: %v", exits)
+	}
+
+	events, err := store.FilterEvents(filter)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 || events[0].Name != "signup" {
+		t.Fatalf("Events not as expected: %v", events)
+	}
+
+	conversions, err := store.FilterGoalConversions(filter, NewPathGoal(NullTenant, "Pricing", "/Pricing"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(conversions) != 1 || conversions[0].Name != "Pricing" || conversions[0].Visitors != 1 ||
+		conversions[0].CountryCode.String != "de" || conversions[0].Browser.String != BrowserFirefox {
+		t.Fatalf("Path conversions not as expected: %v", conversions)
+	}
+
+	conversions, err = store.FilterGoalConversions(filter, NewEventGoal(NullTenant, "Signup", "signup"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(conversions) != 1 || conversions[0].Visitors != 1 || conversions[0].CountryCode.String != "de" {
+		t.Fatalf("Event conversions not as expected: %v", conversions)
+	}
+
+	steps, err := store.FilterFunnelSteps(filter, NewFunnel(NullTenant, "Pricing", "/", "/pricing"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(steps) != 2 || steps[0].Step != 0 || steps[0].Visitors != 2 || steps[1].Step != 1 || steps[1].Visitors != 1 {
+		t.Fatalf("Funnel steps not as expected: %v", steps)
+	}
+
+	groups, err := store.FilterContentGroups(filter, []ContentGroup{
+		*NewContentGroup(NullTenant, "Pricing", "/pricing", "/plans"),
+		*NewContentGroup(NullTenant, "Home", "/"),
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(groups) != 2 || groups[0].Dimension != DimensionContentGroup || groups[0].Value.String != "Home" || groups[0].Visitors != 2 || groups[0].PageViews != 2 ||
+		groups[1].Value.String != "Pricing" || groups[1].Visitors != 1 || groups[1].PageViews != 1 {
+		t.Fatalf("Content groups not as expected: %v", groups)
+	}
+
+	filter.Path = "/"
+	transitions, err := store.FilterNextPages(filter)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(transitions) != 1 || transitions[0].Path != "/" || transitions[0].NextPath != "/pricing" || transitions[0].Visitors != 1 || transitions[0].Transitions != 1 {
+		t.Fatalf("Next pages not as expected: %v", transitions)
+	}
+
+	filter.Path = "/Pricing"
+	transitions, err = store.FilterPreviousPages(filter)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(transitions) != 1 || transitions[0].Path != "/" || transitions[0].NextPath != "/Pricing" || transitions[0].Transitions != 1 {
+		t.Fatalf("Previous pages not as expected: %v", transitions)
+	}
+
+	filter.Path = "/Pricing"
+	visitors, err := store.FilterDimension(filter, builtinDimensions[DimensionBrowser])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(visitors) != 1 || visitors[0].Dimension != DimensionBrowser || visitors[0].Value.String != BrowserFirefox || visitors[0].Visitors != 1 || visitors[0].PageViews != 1 {
+		t.Fatalf("Dimension stats not as expected: %v", visitors)
+	}
+
+	filter = &Filter{From: pastDay(1), To: today(), Browser: BrowserChrome}
+	visitors, err = store.FilterDimension(filter, builtinDimensions[DimensionScreen])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(visitors) != 1 || visitors[0].Value.String != "1920x1080" || visitors[0].Visitors != 1 {
+		t.Fatalf("Dimension stats not as expected: %v", visitors)
+	}
+}
+
+func TestPostgresStore_FilterStats(t *testing.T) {
+	cleanupDB(t)
+	store := NewPostgresStore(postgresDB, nil)
+	session := day(2020, 9, 7, 4)
+	hits := []Hit{
+		funnelHit(0, "fp1", session, "/"),
+		funnelHit(0, "fp1", session, "/pricing"),
+		funnelHit(0, "fp1", session, "/"),
+		funnelHit(0, "fp2", session, "/"),
+		funnelHit(0, "fp3", session, "/"),
+	}
+	hits[0].Time = session
+	hits[1].Time = session.Add(time.Second * 30)
+	hits[2].Time = session.Add(time.Second * 90)
+	hits[3].Time = session.Add(time.Hour)
+	hits[4].Time = session.Add(time.Hour)
+	hits[4].CountryCode = sql.NullString{String: "de", Valid: true}
+
+	if err := store.SaveHits(hits); err != nil {
+		t.Fatal(err)
+	}
+
+	filter := &Filter{From: day(2020, 9, 7, 0), To: day(2020, 9, 7, 0)}
+	stats, err := store.FilterStats(filter, []string{"hour", "path"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats) != 3 {
+		t.Fatalf("Three groups must have been returned, but was: %v", len(stats))
+	}
+
+	if stats[0].Path != "/" || stats[0].Hour != 4 || stats[0].Visitors != 1 || stats[0].PageViews != 2 || stats[0].Sessions != 1 ||
+		stats[0].Bounces != 0 || stats[0].SessionDuration != 90 || stats[0].SessionDurationCount != 1 || stats[0].TimeOnPage != 30 || stats[0].TimeOnPageCount != 1 ||
+		stats[1].Path != "/pricing" || stats[1].Hour != 4 || stats[1].Visitors != 1 || stats[1].PageViews != 1 || stats[1].SessionDurationCount != 0 || stats[1].TimeOnPage != 60 ||
+		stats[2].Path != "/" || stats[2].Hour != 5 || stats[2].CountryCode.Valid || stats[2].Visitors != 2 || stats[2].Sessions != 2 || stats[2].Bounces != 2 || stats[2].SessionsStarted != 2 {
+		t.Fatalf("Statistics not as expected: %v", stats)
+	}
+
+	// visitors are counted once, even if they visited multiple pages
+	stats, err = store.FilterStats(filter, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats) != 1 || stats[0].Visitors != 3 || stats[0].PageViews != 5 || stats[0].Sessions != 3 || stats[0].Bounces != 2 {
+		t.Fatalf("Statistics not as expected: %v", stats)
+	}
+
+	filter.Path = "/Pricing"
+	stats, err = store.FilterStats(filter, []string{"country_code"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats) != 1 || stats[0].Visitors != 1 || stats[0].PageViews != 1 || stats[0].SessionDurationCount != 0 || stats[0].TimeOnPage != 60 {
+		t.Fatalf("Statistics not as expected: %v", stats)
+	}
+
+	if _, err := store.FilterStats(filter, []string{"fingerprint"}); err != errFilterStatsColumn {
+		t.Fatalf("Unknown column must return an error, but was: %v", err)
+	}
+}
+
+func TestPostgresStore_Funnels(t *testing.T) {
+	cleanupDB(t)
+	store := NewPostgresStore(postgresDB, nil)
+	funnel := NewFunnel(NullTenant, "Signup", "/pricing", "/signup")
+
+	if err := store.SaveFunnel(funnel); err != nil {
+		t.Fatalf("Funnel must have been saved, but was: %v", err)
+	}
+
+	funnel.Steps = append(funnel.Steps, "/welcome")
+
+	if err := store.SaveFunnel(funnel); err != nil {
+		t.Fatalf("Funnel must have been updated, but was: %v", err)
+	}
+
+	if err := store.SaveFunnelStats(nil, &FunnelStats{Stats: Stats{Day: day(2020, 9, 3, 0), Visitors: 42}, FunnelID: funnel.ID, Step: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.SaveFunnelStats(nil, &FunnelStats{Stats: Stats{Day: day(2020, 9, 3, 0), Visitors: 11}, FunnelID: funnel.ID, Step: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	funnels, err := store.Funnels(NullTenant)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(funnels) != 1 || len(funnels[0].Steps) != 3 || funnels[0].Steps[2] != "/welcome" {
+		t.Fatalf("Funnels not as expected: %v", funnels)
+	}
+
+	steps, err := store.FunnelSteps(NullTenant, funnel.ID, day(2020, 9, 1, 0), day(2020, 9, 5, 0))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(steps) != 1 || steps[0].Step != 1 || steps[0].Visitors != 42+11 {
+		t.Fatalf("Funnel steps not as expected: %v", steps)
+	}
+
+	if err := store.DeleteFunnel(NullTenant, funnel.ID); err != nil {
+		t.Fatalf("Funnel must have been deleted, but was: %v", err)
+	}
+
+	steps, err = store.FunnelSteps(NullTenant, funnel.ID, day(2020, 9, 1, 0), day(2020, 9, 5, 0))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(steps) != 0 {
+		t.Fatalf("Funnel stats must have been deleted, but was: %v", steps)
+	}
+}
+
+func TestPostgresStore_ContentGroups(t *testing.T) {
+	cleanupDB(t)
+	store := NewPostgresStore(postgresDB, nil)
+	group := NewContentGroup(NullTenant, "Docs", "/docs/**")
+
+	if err := store.SaveContentGroup(group); err != nil {
+		t.Fatalf("Content group must have been saved, but was: %v", err)
+	}
+
+	group.Patterns = append(group.Patterns, "/guide")
+
+	if err := store.SaveContentGroup(group); err != nil {
+		t.Fatalf("Content group must have been updated, but was: %v", err)
+	}
+
+	groups, err := store.ContentGroups(NullTenant)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(groups) != 1 || groups[0].Name != "Docs" || len(groups[0].Patterns) != 2 || groups[0].Patterns[1] != "/guide" {
+		t.Fatalf("Content groups not as expected: %v", groups)
+	}
+
+	if err := store.DeleteContentGroup(NullTenant, group.ID); err != nil {
+		t.Fatalf("Content group must have been deleted, but was: %v", err)
+	}
+
+	groups, err = store.ContentGroups(NullTenant)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(groups) != 0 {
+		t.Fatalf("Content group must have been deleted, but was: %v", groups)
+	}
+}
+
 func TestPostgresStore_Session(t *testing.T) {
 	cleanupDB(t)
 	store := NewPostgresStore(postgresDB, nil)
 	createHit(t, store, 0, "fp", "/", "en", "ua", "", pastDay(2), time.Now(), "", "", "", "", "", false, false, 0, 0)
-	session := store.Session(NullTenant, "fp", pastDay(1))
+	session := store.Session(NullTenant, "fp", pastDay(1), time.Now())
 
 	if !session.IsZero() {
 		t.Fatal("No session timestamp must have been found")
 	}
 
-	session = store.Session(NullTenant, "fp", pastDay(3))
+	session = store.Session(NullTenant, "fp", pastDay(3), time.Now())
 
 	if session.IsZero() {
 		t.Fatal("Session timestamp must have been found")
 	}
+
+	session = store.Session(NullTenant, "fp", pastDay(4), pastDay(3))
+
+	if !session.IsZero() {
+		t.Fatal("No session timestamp must have been found before the hit")
+	}
 }
 
 func TestPostgresStore_HitDays(t *testing.T) {
@@ -509,6 +1149,92 @@ func TestPostgresStore_CountVisitorsByPathAndMaxOneHit(t *testing.T) {
 	}
 }
 
+func TestPostgresStore_CountEventsByName(t *testing.T) {
+	cleanupDB(t)
+	store := NewPostgresStore(postgresDB, nil)
+	createEvent(t, store, 0, "fp1", "/", "signup", map[string]string{"plan": "pro"}, 10, pastDay(5))
+	createEvent(t, store, 0, "fp1", "/Pricing", "signup", map[string]string{"plan": "pro"}, 5, pastDay(5))
+	createEvent(t, store, 0, "fp2", "/pricing", "signup", map[string]string{"plan": "free"}, 0, pastDay(5))
+	events, err := store.CountEventsByName(nil, NullTenant, pastDay(5), "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 || events[0].Name != "signup" || events[0].Visitors != 2 || events[0].Events != 3 || events[0].Value != 15 {
+		t.Fatalf("Visitors must have been counted once per event, but was: %v", events)
+	}
+
+	events, err = store.CountEventsByName(nil, NullTenant, pastDay(5), "/pricing")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 || events[0].Visitors != 2 || events[0].Events != 2 || events[0].Value != 5 {
+		t.Fatalf("Events not as expected: %v", events)
+	}
+
+	metadata, err := store.CountEventMetadataByName(nil, NullTenant, pastDay(5), "signup", "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(metadata) != 2 ||
+		metadata[0].MetaValue != "free" || metadata[0].Visitors != 1 || metadata[0].Events != 1 ||
+		metadata[1].MetaValue != "pro" || metadata[1].Visitors != 1 || metadata[1].Events != 2 {
+		t.Fatalf("Visitors must have been counted once per metadata key and value, but was: %v", metadata)
+	}
+}
+
+func TestPostgresStore_CountVisitorsByLanguage(t *testing.T) {
+	cleanupDB(t)
+	store := NewPostgresStore(postgresDB, nil)
+	createHit(t, store, 0, "fp1", "/", "en", "ua", "ref", pastDay(5), time.Time{}, OSWindows, "10", BrowserChrome, "84.0", "gb", true, false, 1920, 1080)
+	createHit(t, store, 0, "fp1", "/page", "en", "ua", "ref", pastDay(5), time.Time{}, OSWindows, "10", BrowserChrome, "84.0", "gb", true, false, 1920, 1080)
+	createHit(t, store, 0, "fp2", "/", "de", "ua", "", pastDay(5), time.Time{}, OSMac, "10.15", BrowserSafari, "14.0", "de", true, false, 1280, 720)
+	languages, err := store.CountVisitorsByLanguage(nil, NullTenant, pastDay(5))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(languages) != 2 {
+		t.Fatalf("Two languages must have been returned, but was: %v", languages)
+	}
+
+	for _, l := range languages {
+		if l.Language.String == "en" && (l.Visitors != 1 || l.PageViews != 2) || l.Language.String == "de" && (l.Visitors != 1 || l.PageViews != 1) {
+			t.Fatalf("Languages not as expected: %v", languages)
+		}
+	}
+
+	browser, err := store.CountVisitorsByBrowser(nil, NullTenant, pastDay(5))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(browser) != 2 || browser[0].Browser.String != BrowserChrome && browser[1].Browser.String != BrowserChrome {
+		t.Fatalf("Browser not as expected: %v", browser)
+	}
+
+	if err := store.SaveScreenStats(nil, &ScreenStats{Stats: Stats{Day: pastDay(5), Visitors: 3, PageViews: 4}, Width: 1920, Height: 1080}); err != nil {
+		t.Fatal(err)
+	}
+
+	screen, err := store.VisitorScreenSize(NullTenant, pastDay(6), pastDay(4))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(screen) != 1 || screen[0].Width != 1920 || screen[0].Height != 1080 || screen[0].Visitors != 3 || screen[0].PageViews != 4 {
+		t.Fatalf("Screen sizes not as expected: %v", screen)
+	}
+}
+
 func TestPostgresStore_ActiveVisitors(t *testing.T) {
 	cleanupDB(t)
 	store := NewPostgresStore(postgresDB, nil)
@@ -519,6 +1245,9 @@ func TestPostgresStore_ActiveVisitors(t *testing.T) {
 	if total != 1 {
 		t.Fatalf("One active visitor must have been returned, but was: %v", total)
 	}
+	if total := store.ActiveVisitorsFilter(&Filter{Language: "de"}, time.Now().Add(-time.Second*10)); total != 0 {
+		t.Fatalf("No active visitor must have been returned for the language filter, but was: %v", total)
+	}
 }
 
 func TestPostgresStore_ActivePageVisitors(t *testing.T) {
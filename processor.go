@@ -2,48 +2,147 @@ package pirsch
 
 import (
 	"database/sql"
+	"fmt"
 	"github.com/jmoiron/sqlx"
 	"time"
 )
 
 // Processor processes hits to reduce them into meaningful statistics.
 type Processor struct {
-	store Store
+	store            Store
+	customDimensions []Dimension
+	archiveRetention time.Duration
 }
 
-// NewProcessor creates a new Processor for given Store.
+// ProcessorConfig is the (optional) configuration for the Processor.
+type ProcessorConfig struct {
+	// Dimensions are the custom dimensions processed in addition to the built-in dimensions.
+	// Pass the same dimensions to the Analyzer to break down the visitors by them.
+	// Only days processed afterwards include a dimension.
+	Dimensions []Dimension
+
+	// ArchiveRetention enables the archive and sets how long processed hits and events are kept in it.
+	// The Analyzer requires the archive to filter past days by visitor attributes, path patterns, or content groups,
+	// so the retention must be passed to the AnalyzerConfig as well.
+	// As archived hits contain the fingerprints of visitors, the archive is disabled by default (0)
+	// and processed hits and events are deleted right away. Use Processor.PurgeArchive to delete archived hits and events.
+	ArchiveRetention time.Duration
+}
+
+// NewProcessor creates a new Processor for given Store using the default configuration.
 func NewProcessor(store Store) *Processor {
+	return NewProcessorWithConfig(store, nil)
+}
+
+// NewProcessorWithConfig creates a new Processor for given Store and config.
+// Pass nil for the config to use the defaults.
+// NewProcessorWithConfig panics if one of the dimensions is invalid (see Dimension.Validate).
+func NewProcessorWithConfig(store Store, config *ProcessorConfig) *Processor {
+	if config == nil {
+		config = new(ProcessorConfig)
+	}
+
+	dimensions, err := newDimensions(config.Dimensions)
+
+	if err != nil {
+		panic(fmt.Sprintf("pirsch: invalid dimension: %s", err))
+	}
+
+	if config.ArchiveRetention < 0 {
+		config.ArchiveRetention = 0
+	}
+
 	return &Processor{
-		store: store,
+		store:            store,
+		customDimensions: customDimensions(dimensions),
+		archiveRetention: config.ArchiveRetention,
 	}
 }
 
-// Process processes all hits in database and deletes them afterwards.
+// Process processes all hits and events in database and deletes them afterwards.
+// If the archive is enabled, they are moved to the archive and archived hits and events older than the retention are deleted.
 func (processor *Processor) Process() error {
 	return processor.ProcessTenant(NullTenant)
 }
 
-// ProcessTenant processes all hits in database for given tenant and deletes them afterwards.
+// ProcessTenant processes all hits and events in database for given tenant and deletes them afterwards.
+// If the archive is enabled, they are moved to the archive and archived hits and events older than the retention are deleted.
 // The tenant can be set to nil if you don't split your data (which is usually the case).
 func (processor *Processor) ProcessTenant(tenantID sql.NullInt64) error {
 	// this explicitly excludes "today", because we might not have collected all visitors
 	// and the hits will be deleted after the processor has finished reducing the data
-	days, err := processor.store.HitDays(tenantID)
+	hitDays, err := processor.store.HitDays(tenantID)
+
+	if err != nil {
+		return err
+	}
+
+	// there might be days with events but without hits
+	eventDays, err := processor.store.EventDays(tenantID)
+
+	if err != nil {
+		return err
+	}
+
+	goals, err := processor.store.Goals(tenantID)
+
+	if err != nil {
+		return err
+	}
+
+	funnels, err := processor.store.Funnels(tenantID)
 
 	if err != nil {
 		return err
 	}
 
-	for _, day := range days {
-		if err := processor.processDay(tenantID, day); err != nil {
+	for _, day := range mergeDays(hitDays, eventDays) {
+		if err := processor.processDay(tenantID, day, goals, funnels); err != nil {
 			return err
 		}
 	}
 
+	if processor.archiveRetention > 0 {
+		return processor.PurgeArchiveTenant(tenantID)
+	}
+
+	return nil
+}
+
+// PurgeArchive deletes all archived hits and events older than the archive retention.
+// All archived hits and events are deleted if the archive is disabled.
+func (processor *Processor) PurgeArchive() error {
+	return processor.PurgeArchiveTenant(NullTenant)
+}
+
+// PurgeArchiveTenant deletes all archived hits and events for given tenant older than the archive retention.
+// All archived hits and events of the tenant are deleted if the archive is disabled.
+// The tenant can be set to nil if you don't split your data (which is usually the case).
+func (processor *Processor) PurgeArchiveTenant(tenantID sql.NullInt64) error {
+	// the archive only contains days before today, so everything before tomorrow is deleted
+	before := today().AddDate(0, 0, 1)
+
+	if processor.archiveRetention > 0 {
+		before = today().Add(-processor.archiveRetention)
+	}
+
+	tx := processor.store.NewTx()
+
+	if err := processor.store.DeleteArchivedHitsBefore(tx, tenantID, before); err != nil {
+		processor.store.Rollback(tx)
+		return err
+	}
+
+	if err := processor.store.DeleteArchivedEventsBefore(tx, tenantID, before); err != nil {
+		processor.store.Rollback(tx)
+		return err
+	}
+
+	processor.store.Commit(tx)
 	return nil
 }
 
-func (processor *Processor) processDay(tenantID sql.NullInt64, day time.Time) error {
+func (processor *Processor) processDay(tenantID sql.NullInt64, day time.Time, goals []Goal, funnels []Funnel) error {
 	paths, err := processor.store.HitPaths(tenantID, day)
 
 	if err != nil {
@@ -52,8 +151,19 @@ func (processor *Processor) processDay(tenantID sql.NullInt64, day time.Time) er
 
 	tx := processor.store.NewTx()
 
+	// bounces, durations, entry and exit pages, funnels, transitions, and the statistics to filter by are calculated per session
+	hits, err := processor.store.SessionHits(tx, tenantID, day)
+
+	if err != nil {
+		processor.store.Rollback(tx)
+		return err
+	}
+
+	sessions := groupSessions(hits)
+	sessionStats := countSessionStats(sessions)
+
 	for _, path := range paths {
-		if err := processor.processPath(tx, tenantID, day, path); err != nil {
+		if err := processor.processPath(tx, tenantID, day, path, sessionStats); err != nil {
 			processor.store.Rollback(tx)
 			return err
 		}
@@ -69,17 +179,81 @@ func (processor *Processor) processDay(tenantID sql.NullInt64, day time.Time) er
 		return err
 	}
 
+	if err := processor.referrerSource(tx, tenantID, day); err != nil {
+		processor.store.Rollback(tx)
+		return err
+	}
+
+	if err := processor.utm(tx, tenantID, day); err != nil {
+		processor.store.Rollback(tx)
+		return err
+	}
+
+	if err := processor.dimensions(tx, tenantID, day); err != nil {
+		processor.store.Rollback(tx)
+		return err
+	}
+
+	if err := processor.events(tx, tenantID, day); err != nil {
+		processor.store.Rollback(tx)
+		return err
+	}
+
+	if err := processor.eventMetadata(tx, tenantID, day); err != nil {
+		processor.store.Rollback(tx)
+		return err
+	}
+
+	// goals require both, hits and events, so they must be processed before deleting either of them
+	if err := processor.goals(tx, tenantID, day, goals); err != nil {
+		processor.store.Rollback(tx)
+		return err
+	}
+
+	if err := processor.entryExitPages(tx, day, sessions); err != nil {
+		processor.store.Rollback(tx)
+		return err
+	}
+
+	if err := processor.funnels(tx, day, sessions, funnels); err != nil {
+		processor.store.Rollback(tx)
+		return err
+	}
+
+	if err := processor.transitions(tx, day, sessions); err != nil {
+		processor.store.Rollback(tx)
+		return err
+	}
+
+	// the hits and events are kept in the archive to filter the statistics by visitor attributes, if enabled
+	if processor.archiveRetention > 0 {
+		if err := processor.store.ArchiveHitsByDay(tx, tenantID, day); err != nil {
+			processor.store.Rollback(tx)
+			return err
+		}
+
+		if err := processor.store.ArchiveEventsByDay(tx, tenantID, day); err != nil {
+			processor.store.Rollback(tx)
+			return err
+		}
+	}
+
 	if err := processor.store.DeleteHitsByDay(tx, tenantID, day); err != nil {
 		processor.store.Rollback(tx)
 		return err
 	}
 
+	if err := processor.store.DeleteEventsByDay(tx, tenantID, day); err != nil {
+		processor.store.Rollback(tx)
+		return err
+	}
+
 	processor.store.Commit(tx)
 	return nil
 }
 
-func (processor *Processor) processPath(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time, path string) error {
-	if err := processor.visitors(tx, tenantID, day, path); err != nil {
+func (processor *Processor) processPath(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time, path string, sessionStats map[pathKey]Stats) error {
+	if err := processor.visitors(tx, tenantID, day, path, sessionStats); err != nil {
 		return err
 	}
 
@@ -106,17 +280,16 @@ func (processor *Processor) processPath(tx *sqlx.Tx, tenantID sql.NullInt64, day
 	return nil
 }
 
-func (processor *Processor) visitors(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time, path string) error {
+func (processor *Processor) visitors(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time, path string, sessionStats map[pathKey]Stats) error {
 	visitors, err := processor.store.CountVisitorsByPath(tx, tenantID, day, path, true)
 
 	if err != nil {
 		return err
 	}
 
-	bounces := processor.store.CountVisitorsByPathAndMaxOneHit(tx, tenantID, day, path)
-
 	for _, v := range visitors {
-		v.Bounces = bounces
+		pathStats := sessionStats[pathKey{v.TenantID, path}]
+		v.addSessionStats(&pathStats)
 
 		if err := processor.store.SaveVisitorStats(tx, &v); err != nil {
 			return err
@@ -237,3 +410,169 @@ func (processor *Processor) country(tx *sqlx.Tx, tenantID sql.NullInt64, day tim
 
 	return nil
 }
+
+func (processor *Processor) referrerSource(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) error {
+	visitors, err := processor.store.CountVisitorsByReferrerSource(tx, tenantID, day)
+
+	if err != nil {
+		return err
+	}
+
+	for _, v := range visitors {
+		if err := processor.store.SaveReferrerSourceStats(tx, &v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (processor *Processor) utm(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) error {
+	visitors, err := processor.store.CountVisitorsByUTM(tx, tenantID, day)
+
+	if err != nil {
+		return err
+	}
+
+	for _, v := range visitors {
+		if err := processor.store.SaveUTMStats(tx, &v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (processor *Processor) dimensions(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) error {
+	for _, dimension := range processor.customDimensions {
+		visitors, err := processor.store.CountVisitorsByDimension(tx, tenantID, day, dimension)
+
+		if err != nil {
+			return err
+		}
+
+		for _, v := range visitors {
+			if err := processor.store.SaveDimensionStats(tx, &v); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (processor *Processor) events(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) error {
+	events, err := processor.store.CountEvents(tx, tenantID, day)
+
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		if err := processor.store.SaveEventStats(tx, &e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (processor *Processor) eventMetadata(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time) error {
+	metadata, err := processor.store.CountEventMetadata(tx, tenantID, day)
+
+	if err != nil {
+		return err
+	}
+
+	for _, m := range metadata {
+		if err := processor.store.SaveEventMetadataStats(tx, &m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (processor *Processor) goals(tx *sqlx.Tx, tenantID sql.NullInt64, day time.Time, goals []Goal) error {
+	for i := range goals {
+		conversions, err := processor.store.CountGoalConversions(tx, tenantID, day, &goals[i])
+
+		if err != nil {
+			return err
+		}
+
+		for _, c := range conversions {
+			if err := processor.store.SaveGoalStats(tx, &c); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (processor *Processor) entryExitPages(tx *sqlx.Tx, day time.Time, sessions [][]Hit) error {
+	entries, exits := countEntryExitPages(sessions)
+
+	for _, entry := range entries {
+		entry.Day = day
+
+		if err := processor.store.SaveEntryStats(tx, &entry); err != nil {
+			return err
+		}
+	}
+
+	for _, exit := range exits {
+		exit.Day = day
+
+		if err := processor.store.SaveExitStats(tx, &exit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (processor *Processor) funnels(tx *sqlx.Tx, day time.Time, sessions [][]Hit, funnels []Funnel) error {
+	for _, funnel := range funnels {
+		steps, err := funnel.countSteps(sessions)
+
+		if err != nil {
+			return err
+		}
+
+		for i, visitors := range steps {
+			if visitors == 0 {
+				break
+			}
+
+			stats := &FunnelStats{
+				Stats: Stats{
+					BaseEntity: BaseEntity{TenantID: funnel.TenantID},
+					Day:        day,
+					Visitors:   visitors,
+				},
+				FunnelID: funnel.ID,
+				Step:     i,
+			}
+
+			if err := processor.store.SaveFunnelStats(tx, stats); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (processor *Processor) transitions(tx *sqlx.Tx, day time.Time, sessions [][]Hit) error {
+	for _, transition := range countTransitions(sessions) {
+		transition.Day = day
+
+		if err := processor.store.SaveTransitionStats(tx, &transition); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
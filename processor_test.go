@@ -57,13 +57,431 @@ func TestProcessor_ProcessSessions(t *testing.T) {
 			t.Fatalf("Visitor stats must have two visitors and three sessions, but was: %v %v", visitorStats[0].Visitors, visitorStats[0].Sessions)
 		}
 
-		if timeStats[4].Visitors != 1 || timeStats[4].Sessions != 1 ||
-			timeStats[5].Visitors != 1 || timeStats[5].Sessions != 2 {
+		if visitorStats[0].PageViews != 4 {
+			t.Fatalf("Visitor stats must have four page views, but was: %v", visitorStats[0].PageViews)
+		}
+
+		if timeStats[4].Visitors != 1 || timeStats[4].Sessions != 1 || timeStats[4].PageViews != 2 ||
+			timeStats[5].Visitors != 1 || timeStats[5].Sessions != 2 || timeStats[5].PageViews != 2 {
 			t.Fatalf("Visitor time stats must have two visitors and three sessions, but was: %v", timeStats)
 		}
 	}
 }
 
+func TestProcessor_ProcessDurations(t *testing.T) {
+	for _, store := range testStorageBackends() {
+		cleanupDB(t)
+		session := day(2020, 9, 7, 4)
+		createHit(t, store, 0, "fp1", "/", "en", "ua1", "", session, session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp1", "/pricing", "en", "ua1", "", session.Add(time.Minute), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp1", "/", "en", "ua1", "", session.Add(time.Minute*3), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp2", "/", "en", "ua2", "", day(2020, 9, 7, 5), day(2020, 9, 7, 5), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		processor := NewProcessor(store)
+
+		if err := processor.Process(); err != nil {
+			t.Fatalf("Data must have been processed, but was: %v", err)
+		}
+
+		db := sqlx.NewDb(postgresDB, "postgres")
+		var stats []VisitorStats
+
+		if err := db.Select(&stats, `SELECT * FROM "visitor_stats" ORDER BY "path"`); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(stats) != 2 ||
+			stats[0].Path != "/" || stats[0].SessionsStarted != 2 || stats[0].SessionDuration != 180 || stats[0].SessionDurationCount != 2 || stats[0].TimeOnPage != 60 || stats[0].TimeOnPageCount != 1 ||
+			stats[1].Path != "/pricing" || stats[1].SessionsStarted != 0 || stats[1].SessionDuration != 0 || stats[1].SessionDurationCount != 0 || stats[1].TimeOnPage != 120 || stats[1].TimeOnPageCount != 1 {
+			t.Fatalf("Visitor stats not as expected: %v", stats)
+		}
+	}
+}
+
+func TestProcessor_ProcessBounces(t *testing.T) {
+	for _, store := range testStorageBackends() {
+		cleanupDB(t)
+		session := day(2020, 9, 7, 4)
+		createHit(t, store, 0, "fp1", "/", "en", "ua1", "", session, session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp1", "/", "en", "ua1", "", session.Add(time.Hour*3), session.Add(time.Hour*3), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp1", "/pricing", "en", "ua1", "", session.Add(time.Hour*3+time.Minute), session.Add(time.Hour*3), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp1", "/pricing", "en", "ua1", "", day(2020, 9, 8, 4), day(2020, 9, 8, 4), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 1, "fp1", "/pricing", "en", "ua1", "", session, session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		processor := NewProcessor(store)
+
+		if err := processor.Process(); err != nil {
+			t.Fatalf("Data must have been processed, but was: %v", err)
+		}
+
+		db := sqlx.NewDb(postgresDB, "postgres")
+		var stats []VisitorStats
+
+		if err := db.Select(&stats, `SELECT * FROM "visitor_stats" ORDER BY "day", "path", "tenant_id" NULLS FIRST`); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(stats) != 4 ||
+			stats[0].Path != "/" || stats[0].Sessions != 2 || stats[0].Bounces != 1 ||
+			stats[1].Path != "/pricing" || stats[1].TenantID.Valid || stats[1].Sessions != 1 || stats[1].Bounces != 0 ||
+			stats[2].Path != "/pricing" || !stats[2].TenantID.Valid || stats[2].Sessions != 1 || stats[2].Bounces != 1 ||
+			stats[3].Path != "/pricing" || stats[3].Sessions != 1 || stats[3].Bounces != 1 {
+			t.Fatalf("Visitor stats not as expected: %v", stats)
+		}
+	}
+}
+
+func TestProcessor_ProcessEvents(t *testing.T) {
+	for _, store := range testStorageBackends() {
+		cleanupDB(t)
+		createEvent(t, store, 0, "fp1", "/", "signup", nil, 0, day(2020, 9, 7, 4))
+		createEvent(t, store, 0, "fp1", "/download", "download", map[string]string{"file": "a.pdf"}, 2, day(2020, 9, 7, 4))
+		createEvent(t, store, 0, "fp1", "/download", "download", map[string]string{"file": "a.pdf"}, 3, day(2020, 9, 7, 5))
+		createEvent(t, store, 0, "fp2", "/download", "download", map[string]string{"file": "b.pdf"}, 5, day(2020, 9, 7, 5))
+		createEvent(t, store, 0, "fp2", "/download", "download", nil, 0, today())
+		processor := NewProcessor(store)
+
+		if err := processor.Process(); err != nil {
+			t.Fatalf("Data must have been processed, but was: %v", err)
+		}
+
+		db := sqlx.NewDb(postgresDB, "postgres")
+		var count int
+
+		if err := db.Get(&count, `SELECT COUNT(1) FROM "event"`); err != nil {
+			t.Fatal(err)
+		}
+
+		if count != 1 {
+			t.Fatalf("Events of today must not have been processed, but was: %v", count)
+		}
+
+		var eventStats []EventStats
+		var metadataStats []EventMetadataStats
+
+		if err := db.Select(&eventStats, `SELECT * FROM "event_stats" ORDER BY "day", "path", "name"`); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := db.Select(&metadataStats, `SELECT * FROM "event_metadata_stats" ORDER BY "day", "path", "name", "meta_value"`); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(eventStats) != 2 ||
+			eventStats[0].Path != "/" || eventStats[0].Name != "signup" || eventStats[0].Visitors != 1 || eventStats[0].Events != 1 ||
+			eventStats[1].Path != "/download" || eventStats[1].Name != "download" || eventStats[1].Visitors != 2 || eventStats[1].Events != 3 || eventStats[1].Value != 10 {
+			t.Fatalf("Event stats not as expected: %v", eventStats)
+		}
+
+		if len(metadataStats) != 2 ||
+			metadataStats[0].MetaKey != "file" || metadataStats[0].MetaValue != "a.pdf" || metadataStats[0].Visitors != 1 || metadataStats[0].Events != 2 ||
+			metadataStats[1].MetaKey != "file" || metadataStats[1].MetaValue != "b.pdf" || metadataStats[1].Visitors != 1 || metadataStats[1].Events != 1 {
+			t.Fatalf("Event metadata stats not as expected: %v", metadataStats)
+		}
+	}
+}
+
+func TestProcessor_ProcessGoals(t *testing.T) {
+	for _, store := range testStorageBackends() {
+		cleanupDB(t)
+		signup := NewPathGoal(NullTenant, "Signup", "/signup/*")
+		download := NewEventGoal(NullTenant, "Download", "download")
+
+		if err := store.SaveGoal(signup); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := store.SaveGoal(download); err != nil {
+			t.Fatal(err)
+		}
+
+		createHit(t, store, 0, "fp1", "/", "en", "ua1", "ref1", day(2020, 9, 7, 4), time.Time{}, OSWindows, "10", BrowserChrome, "84.0", "de", true, false, 0, 0)
+		createHit(t, store, 0, "fp1", "/signup/thanks", "en", "ua1", "ref2", day(2020, 9, 7, 5), time.Time{}, OSWindows, "10", BrowserChrome, "84.0", "de", true, false, 0, 0)
+		createHit(t, store, 0, "fp2", "/SIGNUP/Thanks", "en", "ua2", "", day(2020, 9, 7, 6), time.Time{}, OSMac, "10.15.3", BrowserFirefox, "53.0", "gb", true, false, 0, 0)
+		createHit(t, store, 0, "fp3", "/signup/a/b", "en", "ua3", "", day(2020, 9, 7, 6), time.Time{}, OSMac, "10.15.3", BrowserFirefox, "53.0", "gb", true, false, 0, 0)
+		createEvent(t, store, 0, "fp1", "/", "download", nil, 0, day(2020, 9, 7, 4))
+		createEvent(t, store, 0, "fp4", "/", "download", nil, 0, day(2020, 9, 8, 4))
+		processor := NewProcessor(store)
+
+		if err := processor.Process(); err != nil {
+			t.Fatalf("Data must have been processed, but was: %v", err)
+		}
+
+		db := sqlx.NewDb(postgresDB, "postgres")
+		var stats []GoalStats
+
+		if err := db.Select(&stats, `SELECT * FROM "goal_stats" ORDER BY "day", "goal_id", "country_code"`); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(stats) != 4 {
+			t.Fatalf("Four goal stats must have been created, but was: %v", len(stats))
+		}
+
+		if stats[0].GoalID != signup.ID || stats[0].Visitors != 1 || stats[0].Referrer.String != "ref1" || stats[0].CountryCode.String != "de" || stats[0].Browser.String != BrowserChrome ||
+			stats[1].GoalID != signup.ID || stats[1].Visitors != 1 || stats[1].CountryCode.String != "gb" || stats[1].Browser.String != BrowserFirefox ||
+			stats[2].GoalID != download.ID || stats[2].Visitors != 1 || stats[2].Referrer.String != "ref1" ||
+			stats[3].GoalID != download.ID || stats[3].Visitors != 1 || !stats[3].Day.Equal(day(2020, 9, 8, 0)) || stats[3].Referrer.Valid || stats[3].CountryCode.Valid {
+			t.Fatalf("Goal stats not as expected: %v", stats)
+		}
+	}
+}
+
+func TestProcessor_ProcessEntryExitPages(t *testing.T) {
+	for _, store := range testStorageBackends() {
+		cleanupDB(t)
+		session := day(2020, 9, 7, 4)
+		createHit(t, store, 0, "fp1", "/", "en", "ua1", "", day(2020, 9, 7, 4), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp1", "/pricing", "en", "ua1", "", day(2020, 9, 7, 5), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp2", "/", "en", "ua2", "", day(2020, 9, 7, 4), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		processor := NewProcessor(store)
+
+		if err := processor.Process(); err != nil {
+			t.Fatalf("Data must have been processed, but was: %v", err)
+		}
+
+		db := sqlx.NewDb(postgresDB, "postgres")
+		var entries []EntryStats
+
+		if err := db.Select(&entries, `SELECT * FROM "entry_stats" ORDER BY "path"`); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(entries) != 1 ||
+			entries[0].Path != "/" || entries[0].Visitors != 2 || entries[0].Entries != 2 {
+			t.Fatalf("Entry stats not as expected: %v", entries)
+		}
+
+		var exits []ExitStats
+
+		if err := db.Select(&exits, `SELECT * FROM "exit_stats" ORDER BY "path"`); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(exits) != 2 ||
+			exits[0].Path != "/" || exits[0].Visitors != 1 || exits[0].Sessions != 2 || exits[0].Exits != 1 ||
+			exits[1].Path != "/pricing" || exits[1].Visitors != 1 || exits[1].Sessions != 1 || exits[1].Exits != 1 {
+			t.Fatalf("Exit stats not as expected: %v", exits)
+		}
+	}
+}
+
+func TestProcessor_ProcessEntryExitPagesTenant(t *testing.T) {
+	for _, store := range testStorageBackends() {
+		cleanupDB(t)
+		session := day(2020, 9, 7, 4)
+		createHit(t, store, 1, "fp1", "/", "en", "ua1", "", day(2020, 9, 7, 4), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 1, "fp1", "/pricing", "en", "ua1", "", day(2020, 9, 7, 5), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 2, "fp1", "/", "en", "ua1", "", day(2020, 9, 7, 4), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 2, "fp2", "/", "en", "ua2", "", day(2020, 9, 7, 4), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		processor := NewProcessor(store)
+
+		if err := processor.Process(); err != nil {
+			t.Fatalf("Data must have been processed, but was: %v", err)
+		}
+
+		db := sqlx.NewDb(postgresDB, "postgres")
+		var entries []EntryStats
+
+		if err := db.Select(&entries, `SELECT * FROM "entry_stats" ORDER BY "path", tenant_id`); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(entries) != 2 ||
+			entries[0].Path != "/" || entries[0].TenantID != NewTenantID(1) || entries[0].Visitors != 1 || entries[0].Entries != 1 ||
+			entries[1].Path != "/" || entries[1].TenantID != NewTenantID(2) || entries[1].Visitors != 2 || entries[1].Entries != 2 {
+			t.Fatalf("Entry stats not as expected: %v", entries)
+		}
+
+		var exits []ExitStats
+
+		if err := db.Select(&exits, `SELECT * FROM "exit_stats" ORDER BY "path", tenant_id`); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(exits) != 3 ||
+			exits[0].Path != "/" || exits[0].TenantID != NewTenantID(1) || exits[0].Visitors != 0 || exits[0].Sessions != 1 || exits[0].Exits != 0 ||
+			exits[1].Path != "/" || exits[1].TenantID != NewTenantID(2) || exits[1].Visitors != 2 || exits[1].Sessions != 2 || exits[1].Exits != 2 ||
+			exits[2].Path != "/pricing" || exits[2].TenantID != NewTenantID(1) || exits[2].Visitors != 1 || exits[2].Sessions != 1 || exits[2].Exits != 1 {
+			t.Fatalf("Exit stats not as expected: %v", exits)
+		}
+
+		analyzer := NewAnalyzer(store, nil)
+		stats, err := analyzer.EntryPages(&Filter{TenantID: NewTenantID(2), From: day(2020, 9, 7, 0), To: day(2020, 9, 7, 0)})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(stats) != 1 || stats[0].Path != "/" || stats[0].Visitors != 2 || stats[0].Entries != 2 {
+			t.Fatalf("Entry pages for tenant not as expected: %v", stats)
+		}
+	}
+}
+
+func TestProcessor_ProcessTransitions(t *testing.T) {
+	for _, store := range testStorageBackends() {
+		cleanupDB(t)
+		session := day(2020, 9, 7, 4)
+		createHit(t, store, 0, "fp1", "/", "en", "ua1", "", day(2020, 9, 7, 4), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp1", "/pricing", "en", "ua1", "", day(2020, 9, 7, 5), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp1", "/", "en", "ua1", "", day(2020, 9, 7, 6), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp2", "/", "en", "ua2", "", day(2020, 9, 7, 4), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp2", "/pricing", "en", "ua2", "", day(2020, 9, 7, 5), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		processor := NewProcessor(store)
+
+		if err := processor.Process(); err != nil {
+			t.Fatalf("Data must have been processed, but was: %v", err)
+		}
+
+		db := sqlx.NewDb(postgresDB, "postgres")
+		var transitions []TransitionStats
+
+		if err := db.Select(&transitions, `SELECT * FROM "transition_stats" ORDER BY "path", "next_path"`); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(transitions) != 2 ||
+			transitions[0].Path != "/" || transitions[0].NextPath != "/pricing" || transitions[0].Visitors != 2 || transitions[0].Transitions != 2 ||
+			transitions[1].Path != "/pricing" || transitions[1].NextPath != "/" || transitions[1].Visitors != 1 || transitions[1].Transitions != 1 {
+			t.Fatalf("Transition stats not as expected: %v", transitions)
+		}
+	}
+}
+
+func TestProcessor_ProcessFunnels(t *testing.T) {
+	for _, store := range testStorageBackends() {
+		cleanupDB(t)
+		funnel := NewFunnel(NullTenant, "Signup", "/pricing", "/signup", "/welcome")
+
+		if err := store.SaveFunnel(funnel); err != nil {
+			t.Fatal(err)
+		}
+
+		session := day(2020, 9, 7, 4)
+		createHit(t, store, 0, "fp1", "/pricing", "en", "ua1", "", day(2020, 9, 7, 4), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp1", "/signup", "en", "ua1", "", day(2020, 9, 7, 5), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp1", "/welcome", "en", "ua1", "", day(2020, 9, 7, 6), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp2", "/pricing", "en", "ua2", "", day(2020, 9, 7, 4), session, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp2", "/signup", "en", "ua2", "", day(2020, 9, 7, 5), day(2020, 9, 7, 5), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp3", "/pricing", "en", "ua3", "", day(2020, 9, 8, 4), day(2020, 9, 8, 4), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		processor := NewProcessor(store)
+
+		if err := processor.Process(); err != nil {
+			t.Fatalf("Data must have been processed, but was: %v", err)
+		}
+
+		db := sqlx.NewDb(postgresDB, "postgres")
+		var stats []FunnelStats
+
+		if err := db.Select(&stats, `SELECT * FROM "funnel_stats" ORDER BY "day", "step"`); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(stats) != 4 ||
+			stats[0].Step != 0 || stats[0].Visitors != 2 ||
+			stats[1].Step != 1 || stats[1].Visitors != 1 ||
+			stats[2].Step != 2 || stats[2].Visitors != 1 ||
+			stats[3].Step != 0 || stats[3].Visitors != 1 || !stats[3].Day.Equal(day(2020, 9, 8, 0)) ||
+			stats[0].FunnelID != funnel.ID {
+			t.Fatalf("Funnel stats not as expected: %v", stats)
+		}
+	}
+}
+
+func TestProcessor_ProcessReferrerSource(t *testing.T) {
+	for _, store := range testStorageBackends() {
+		cleanupDB(t)
+		createReferrerSourceHit(t, store, 0, "fp1", "Google", ChannelSearch, day(2020, 9, 7, 4))
+		createReferrerSourceHit(t, store, 0, "fp1", "Google", ChannelSearch, day(2020, 9, 7, 5))
+		createReferrerSourceHit(t, store, 0, "fp2", "Google", ChannelSearch, day(2020, 9, 7, 5))
+		createReferrerSourceHit(t, store, 0, "fp3", "", ChannelDirect, day(2020, 9, 7, 6))
+		processor := NewProcessor(store)
+
+		if err := processor.Process(); err != nil {
+			t.Fatalf("Data must have been processed, but was: %v", err)
+		}
+
+		db := sqlx.NewDb(postgresDB, "postgres")
+		var stats []ReferrerSourceStats
+
+		if err := db.Select(&stats, `SELECT * FROM "referrer_source_stats" ORDER BY "channel"`); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(stats) != 2 ||
+			stats[0].ReferrerName.Valid || stats[0].Channel.String != ChannelDirect || stats[0].Visitors != 1 ||
+			stats[1].ReferrerName.String != "Google" || stats[1].Channel.String != ChannelSearch || stats[1].Visitors != 2 {
+			t.Fatalf("Referrer source stats not as expected: %v", stats)
+		}
+	}
+}
+
+func TestProcessor_ProcessUTM(t *testing.T) {
+	for _, store := range testStorageBackends() {
+		cleanupDB(t)
+		createUTMHit(t, store, 0, "fp1", "google", "cpc", "launch", day(2020, 9, 7, 4))
+		createUTMHit(t, store, 0, "fp1", "google", "cpc", "launch", day(2020, 9, 7, 5))
+		createUTMHit(t, store, 0, "fp2", "google", "cpc", "launch", day(2020, 9, 7, 5))
+		createUTMHit(t, store, 0, "fp3", "newsletter", "email", "", day(2020, 9, 7, 6))
+		createHit(t, store, 0, "fp4", "/", "en", "ua4", "", day(2020, 9, 7, 6), time.Time{}, OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		processor := NewProcessor(store)
+
+		if err := processor.Process(); err != nil {
+			t.Fatalf("Data must have been processed, but was: %v", err)
+		}
+
+		db := sqlx.NewDb(postgresDB, "postgres")
+		var stats []UTMStats
+
+		if err := db.Select(&stats, `SELECT * FROM "utm_stats" ORDER BY "utm_source"`); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(stats) != 2 ||
+			stats[0].UTMSource.String != "google" || stats[0].UTMMedium.String != "cpc" || stats[0].UTMCampaign.String != "launch" || stats[0].Visitors != 2 ||
+			stats[1].UTMSource.String != "newsletter" || stats[1].UTMCampaign.Valid || stats[1].Visitors != 1 {
+			t.Fatalf("UTM stats not as expected: %v", stats)
+		}
+	}
+}
+
+func TestProcessor_ProcessArchive(t *testing.T) {
+	for _, store := range testStorageBackends() {
+		cleanupDB(t)
+		createHit(t, store, 0, "fp1", "/", "en", "ua1", "", pastDay(40), pastDay(40), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp1", "/", "en", "ua1", "", pastDay(2), pastDay(2), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createEvent(t, store, 0, "fp1", "/", "signup", nil, 0, pastDay(2))
+
+		if err := NewProcessor(store).Process(); err != nil {
+			t.Fatalf("Data must have been processed, but was: %v", err)
+		}
+
+		if hits, events := countArchive(t); hits != 0 || events != 0 {
+			t.Fatalf("Hits and events must not have been archived by default, but was: %v %v", hits, events)
+		}
+
+		cleanupDB(t)
+		createHit(t, store, 0, "fp1", "/", "en", "ua1", "", pastDay(40), pastDay(40), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createHit(t, store, 0, "fp1", "/", "en", "ua1", "", pastDay(2), pastDay(2), OSWindows, "10", BrowserChrome, "84.0", "", true, false, 0, 0)
+		createEvent(t, store, 0, "fp1", "/", "signup", nil, 0, pastDay(2))
+
+		if err := NewProcessorWithConfig(store, &ProcessorConfig{ArchiveRetention: time.Hour * 24 * 30}).Process(); err != nil {
+			t.Fatalf("Data must have been processed, but was: %v", err)
+		}
+
+		if hits, events := countArchive(t); hits != 1 || events != 1 {
+			t.Fatalf("Hits and events within the retention must have been archived, but was: %v %v", hits, events)
+		}
+
+		if err := NewProcessor(store).PurgeArchive(); err != nil {
+			t.Fatalf("Archive must have been purged, but was: %v", err)
+		}
+
+		if hits, events := countArchive(t); hits != 0 || events != 0 {
+			t.Fatalf("Archive must be empty, but was: %v %v", hits, events)
+		}
+	}
+}
+
 func testProcess(t *testing.T, tenantID int64) {
 	for _, store := range testStorageBackends() {
 		createTestdata(t, store, tenantID)
@@ -332,6 +750,21 @@ func createTestdata(t *testing.T, store Store, tenantID int64) {
 	createHit(t, store, tenantID, "fp6", "/different-page", "jp", "ua6", "ref3", day(2020, 6, 22, 10), time.Time{}, OSAndroid, "8.0", BrowserChrome, "84.0", "jp", false, true, 0, 0)
 }
 
+func countArchive(t *testing.T) (int, int) {
+	db := sqlx.NewDb(postgresDB, "postgres")
+	var hits, events int
+
+	if err := db.Get(&hits, `SELECT COUNT(1) FROM "hit_archive"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Get(&events, `SELECT COUNT(1) FROM "event_archive"`); err != nil {
+		t.Fatal(err)
+	}
+
+	return hits, events
+}
+
 func createHit(t *testing.T, store Store, tenantID int64, fingerprint, path, lang, userAgent, ref string, time, session time.Time, os, osVersion, browser, browserVersion, countryCode string, desktop, mobile bool, w, h int) {
 	hit := Hit{
 		BaseEntity:     BaseEntity{TenantID: NewTenantID(tenantID)},
@@ -358,6 +791,54 @@ func createHit(t *testing.T, store Store, tenantID int64, fingerprint, path, lan
 	}
 }
 
+func createEvent(t *testing.T, store Store, tenantID int64, fingerprint, path, name string, meta map[string]string, value float64, time time.Time) {
+	event, _ := newEvent(Hit{
+		BaseEntity:  BaseEntity{TenantID: NewTenantID(tenantID)},
+		Fingerprint: fingerprint,
+		Path:        sql.NullString{String: path, Valid: path != ""},
+		Time:        time,
+	}, &EventOptions{
+		Name:  name,
+		Meta:  meta,
+		Value: value,
+	})
+
+	if err := store.SaveEvents([]Event{event}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func createReferrerSourceHit(t *testing.T, store Store, tenantID int64, fingerprint, name, channel string, time time.Time) {
+	hit := Hit{
+		BaseEntity:   BaseEntity{TenantID: NewTenantID(tenantID)},
+		Fingerprint:  fingerprint,
+		Path:         sql.NullString{String: "/", Valid: true},
+		ReferrerName: sql.NullString{String: name, Valid: name != ""},
+		Channel:      sql.NullString{String: channel, Valid: channel != ""},
+		Time:         time,
+	}
+
+	if err := store.SaveHits([]Hit{hit}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func createUTMHit(t *testing.T, store Store, tenantID int64, fingerprint, source, medium, campaign string, time time.Time) {
+	hit := Hit{
+		BaseEntity:  BaseEntity{TenantID: NewTenantID(tenantID)},
+		Fingerprint: fingerprint,
+		Path:        sql.NullString{String: "/", Valid: true},
+		UTMSource:   sql.NullString{String: source, Valid: source != ""},
+		UTMMedium:   sql.NullString{String: medium, Valid: medium != ""},
+		UTMCampaign: sql.NullString{String: campaign, Valid: campaign != ""},
+		Time:        time,
+	}
+
+	if err := store.SaveHits([]Hit{hit}); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func day(year, month, day, hour int) time.Time {
 	return time.Date(year, time.Month(month), day, hour, 0, 0, 0, time.UTC)
 }
@@ -0,0 +1,180 @@
+package pirsch
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Channels a referrer source belongs to.
+const (
+	// ChannelDirect is used for visitors without referrer.
+	ChannelDirect = "direct"
+
+	// ChannelSearch is used for search engines.
+	ChannelSearch = "search"
+
+	// ChannelSocial is used for social networks and communities.
+	ChannelSocial = "social"
+
+	// ChannelEmail is used for webmail and email clients, and campaigns with utm_medium set to email.
+	ChannelEmail = "email"
+
+	// ChannelReferral is used for all other referrers.
+	ChannelReferral = "referral"
+)
+
+// referrerSource is a named source and the channel it belongs to.
+type referrerSource struct {
+	name    string
+	channel string
+}
+
+// referrerSources maps referrer hosts (without www.) and Android app package names to sources.
+var referrerSources = map[string]referrerSource{
+	// search
+	"duckduckgo.com":   {"DuckDuckGo", ChannelSearch},
+	"ecosia.org":       {"Ecosia", ChannelSearch},
+	"search.brave.com": {"Brave Search", ChannelSearch},
+	"qwant.com":        {"Qwant", ChannelSearch},
+	"startpage.com":    {"Startpage", ChannelSearch},
+	"baidu.com":        {"Baidu", ChannelSearch},
+	"yandex.ru":        {"Yandex", ChannelSearch},
+	"yandex.com":       {"Yandex", ChannelSearch},
+	"naver.com":        {"Naver", ChannelSearch},
+	"seznam.cz":        {"Seznam", ChannelSearch},
+	"search.aol.com":   {"AOL", ChannelSearch},
+	"ask.com":          {"Ask", ChannelSearch},
+	"com.google.android.googlequicksearchbox": {"Google", ChannelSearch},
+
+	// social
+	"facebook.com":               {"Facebook", ChannelSocial},
+	"l.facebook.com":             {"Facebook", ChannelSocial},
+	"lm.facebook.com":            {"Facebook", ChannelSocial},
+	"m.facebook.com":             {"Facebook", ChannelSocial},
+	"instagram.com":              {"Instagram", ChannelSocial},
+	"l.instagram.com":            {"Instagram", ChannelSocial},
+	"twitter.com":                {"Twitter", ChannelSocial},
+	"mobile.twitter.com":         {"Twitter", ChannelSocial},
+	"t.co":                       {"Twitter", ChannelSocial},
+	"com.twitter.android":        {"Twitter", ChannelSocial},
+	"linkedin.com":               {"LinkedIn", ChannelSocial},
+	"lnkd.in":                    {"LinkedIn", ChannelSocial},
+	"com.linkedin.android":       {"LinkedIn", ChannelSocial},
+	"reddit.com":                 {"Reddit", ChannelSocial},
+	"old.reddit.com":             {"Reddit", ChannelSocial},
+	"out.reddit.com":             {"Reddit", ChannelSocial},
+	"com.reddit.frontpage":       {"Reddit", ChannelSocial},
+	"news.ycombinator.com":       {"Hacker News", ChannelSocial},
+	"youtube.com":                {"YouTube", ChannelSocial},
+	"m.youtube.com":              {"YouTube", ChannelSocial},
+	"pinterest.com":              {"Pinterest", ChannelSocial},
+	"tiktok.com":                 {"TikTok", ChannelSocial},
+	"xing.com":                   {"XING", ChannelSocial},
+	"vk.com":                     {"VK", ChannelSocial},
+	"producthunt.com":            {"Product Hunt", ChannelSocial},
+	"lobste.rs":                  {"Lobsters", ChannelSocial},
+	"dev.to":                     {"DEV", ChannelSocial},
+	"medium.com":                 {"Medium", ChannelSocial},
+	"stackoverflow.com":          {"Stack Overflow", ChannelSocial},
+	"github.com":                 {"GitHub", ChannelSocial},
+	"discord.com":                {"Discord", ChannelSocial},
+	"t.me":                       {"Telegram", ChannelSocial},
+	"org.telegram.messenger":     {"Telegram", ChannelSocial},
+	"com.slack":                  {"Slack", ChannelSocial},
+	"app.slack.com":              {"Slack", ChannelSocial},
+	"mastodon.social":            {"Mastodon", ChannelSocial},
+	"com.facebook.katana":        {"Facebook", ChannelSocial},
+	"com.instagram.android":      {"Instagram", ChannelSocial},
+	"com.google.android.youtube": {"YouTube", ChannelSocial},
+	"com.pinterest":              {"Pinterest", ChannelSocial},
+	"com.zhiliaoapp.musically":   {"TikTok", ChannelSocial},
+	"com.discord":                {"Discord", ChannelSocial},
+	"com.stackexchange.marvin":   {"Stack Overflow", ChannelSocial},
+
+	// referral sources that would otherwise be matched as search engines
+	"docs.google.com":              {"Google Docs", ChannelReferral},
+	"news.google.com":              {"Google News", ChannelReferral},
+	"com.google.android.apps.docs": {"Google Docs", ChannelReferral},
+
+	// email
+	"mail.google.com":              {"Gmail", ChannelEmail},
+	"com.google.android.gm":        {"Gmail", ChannelEmail},
+	"outlook.live.com":             {"Outlook", ChannelEmail},
+	"outlook.office.com":           {"Outlook", ChannelEmail},
+	"outlook.office365.com":        {"Outlook", ChannelEmail},
+	"com.microsoft.office.outlook": {"Outlook", ChannelEmail},
+	"mail.yahoo.com":               {"Yahoo Mail", ChannelEmail},
+	"mail.proton.me":               {"Proton Mail", ChannelEmail},
+	"mail.protonmail.com":          {"Proton Mail", ChannelEmail},
+	"mail.yandex.ru":               {"Yandex Mail", ChannelEmail},
+	"mail.zoho.com":                {"Zoho Mail", ChannelEmail},
+	"webmail.gmx.net":              {"GMX", ChannelEmail},
+	"navigator.gmx.net":            {"GMX", ChannelEmail},
+	"web.de":                       {"WEB.DE", ChannelEmail},
+	"email.t-online.de":            {"T-Online", ChannelEmail},
+}
+
+// referrerSourceBrands maps the domain name (without top-level domain) of search engines available under
+// many country specific domains to sources, like google.de and google.co.uk.
+var referrerSourceBrands = map[string]referrerSource{
+	"google": {"Google", ChannelSearch},
+	"bing":   {"Bing", ChannelSearch},
+	"yahoo":  {"Yahoo", ChannelSearch},
+	"yandex": {"Yandex", ChannelSearch},
+}
+
+// getReferrerSource returns the source name and channel for given (cleaned) referrer and utm_medium.
+// Visitors without referrer are direct, unless the utm_medium is email. Unknown hosts are returned as the
+// name of the source (without www.) for the referral channel.
+func getReferrerSource(referrer, utmMedium string) (string, string) {
+	isEmailCampaign := strings.EqualFold(utmMedium, "email")
+
+	if referrer == "" {
+		if isEmailCampaign {
+			return "", ChannelEmail
+		}
+
+		return "", ChannelDirect
+	}
+
+	u, err := url.ParseRequestURI(referrer)
+
+	if err != nil || u.Hostname() == "" {
+		return "", ChannelReferral
+	}
+
+	hostname := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	source, found := referrerSources[hostname]
+
+	if !found {
+		source, found = referrerSources[stripSubdomain(hostname)]
+	}
+
+	if !found {
+		source, found = findReferrerSourceBrand(hostname)
+	}
+
+	if !found {
+		source = referrerSource{hostname, ChannelReferral}
+	}
+
+	if isEmailCampaign {
+		source.channel = ChannelEmail
+	}
+
+	return source.name, source.channel
+}
+
+// findReferrerSourceBrand returns the source for hostnames like google.de, www.google.co.uk, or de.search.yahoo.com.
+// The last label is the top-level domain and therefore never checked.
+func findReferrerSourceBrand(hostname string) (referrerSource, bool) {
+	labels := strings.Split(hostname, ".")
+
+	for i := len(labels) - 2; i >= 0; i-- {
+		if source, found := referrerSourceBrands[labels[i]]; found {
+			return source, true
+		}
+	}
+
+	return referrerSource{}, false
+}
@@ -0,0 +1,40 @@
+package pirsch
+
+import (
+	"testing"
+)
+
+func TestGetReferrerSource(t *testing.T) {
+	input := []struct {
+		referrer  string
+		utmMedium string
+		name      string
+		channel   string
+	}{
+		{"", "", "", ChannelDirect},
+		{"", "email", "", ChannelEmail},
+		{"https://www.google.com/", "", "Google", ChannelSearch},
+		{"https://google.de/", "", "Google", ChannelSearch},
+		{"https://www.google.co.uk/search", "", "Google", ChannelSearch},
+		{"https://de.search.yahoo.com/", "", "Yahoo", ChannelSearch},
+		{"https://duckduckgo.com/", "", "DuckDuckGo", ChannelSearch},
+		{"android-app://com.google.android.gm", "", "Gmail", ChannelEmail},
+		{"https://mail.google.com/", "", "Gmail", ChannelEmail},
+		{"https://docs.google.com/document", "", "Google Docs", ChannelReferral},
+		{"https://t.co/", "", "Twitter", ChannelSocial},
+		{"https://news.ycombinator.com/item", "", "Hacker News", ChannelSocial},
+		{"https://www.reddit.com/r/golang/", "", "Reddit", ChannelSocial},
+		{"https://de.linkedin.com/", "", "LinkedIn", ChannelSocial},
+		{"https://www.example.com/page", "", "example.com", ChannelReferral},
+		{"https://blog.example.com/", "", "blog.example.com", ChannelReferral},
+		{"https://blog.example.com/", "Email", "blog.example.com", ChannelEmail},
+	}
+
+	for _, in := range input {
+		name, channel := getReferrerSource(in.referrer, in.utmMedium)
+
+		if name != in.name || channel != in.channel {
+			t.Fatalf("Source for '%v' must be '%v' (%v), but was: '%v' (%v)", in.referrer, in.name, in.channel, name, channel)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+CREATE TABLE "event" (
+    id bigint NOT NULL UNIQUE,
+    tenant_id bigint,
+    fingerprint varchar(32) NOT NULL,
+    session timestamp without time zone,
+    path varchar(2000),
+    name varchar(200) NOT NULL,
+    meta_keys varchar(200)[],
+    meta_values varchar(200)[],
+    value double precision NOT NULL DEFAULT 0,
+    time timestamp without time zone NOT NULL
+);
+
+CREATE SEQUENCE event_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE event_id_seq OWNED BY "event".id;
+ALTER TABLE ONLY "event" ALTER COLUMN id SET DEFAULT nextval('event_id_seq'::regclass);
+ALTER TABLE ONLY "event" ADD CONSTRAINT event_pkey PRIMARY KEY (id);
+CREATE INDEX event_tenant_id_index ON event(tenant_id);
+CREATE INDEX event_time_index ON event(time);
+
+CREATE TABLE "event_stats" (
+    id bigint NOT NULL UNIQUE,
+    tenant_id bigint,
+    day date NOT NULL,
+    path varchar(2000) NOT NULL,
+    name varchar(200) NOT NULL,
+    visitors integer NOT NULL,
+    events integer NOT NULL,
+    value double precision NOT NULL DEFAULT 0
+);
+
+CREATE SEQUENCE event_stats_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE event_stats_id_seq OWNED BY "event_stats".id;
+ALTER TABLE ONLY "event_stats" ALTER COLUMN id SET DEFAULT nextval('event_stats_id_seq'::regclass);
+ALTER TABLE ONLY "event_stats" ADD CONSTRAINT event_stats_pkey PRIMARY KEY (id);
+CREATE INDEX event_stats_day_index ON event_stats(day);
+CREATE INDEX event_stats_name_index ON event_stats(name);
+
+CREATE TABLE "event_metadata_stats" (
+    id bigint NOT NULL UNIQUE,
+    tenant_id bigint,
+    day date NOT NULL,
+    path varchar(2000) NOT NULL,
+    name varchar(200) NOT NULL,
+    meta_key varchar(200) NOT NULL,
+    meta_value varchar(200) NOT NULL,
+    visitors integer NOT NULL,
+    events integer NOT NULL
+);
+
+CREATE SEQUENCE event_metadata_stats_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE event_metadata_stats_id_seq OWNED BY "event_metadata_stats".id;
+ALTER TABLE ONLY "event_metadata_stats" ALTER COLUMN id SET DEFAULT nextval('event_metadata_stats_id_seq'::regclass);
+ALTER TABLE ONLY "event_metadata_stats" ADD CONSTRAINT event_metadata_stats_pkey PRIMARY KEY (id);
+CREATE INDEX event_metadata_stats_day_index ON event_metadata_stats(day);
+CREATE INDEX event_metadata_stats_name_index ON event_metadata_stats(name);
+
+CREATE TABLE "goal" (
+    id bigint NOT NULL UNIQUE,
+    tenant_id bigint,
+    name varchar(200) NOT NULL,
+    path_pattern varchar(2000),
+    event_name varchar(200)
+);
+
+CREATE SEQUENCE goal_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE goal_id_seq OWNED BY "goal".id;
+ALTER TABLE ONLY "goal" ALTER COLUMN id SET DEFAULT nextval('goal_id_seq'::regclass);
+ALTER TABLE ONLY "goal" ADD CONSTRAINT goal_pkey PRIMARY KEY (id);
+CREATE INDEX goal_tenant_id_index ON goal(tenant_id);
+
+CREATE TABLE "goal_stats" (
+    id bigint NOT NULL UNIQUE,
+    tenant_id bigint,
+    day date NOT NULL,
+    goal_id bigint NOT NULL,
+    referrer varchar(2000),
+    country_code varchar(2),
+    browser varchar(20),
+    visitors integer NOT NULL
+);
+
+CREATE SEQUENCE goal_stats_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE goal_stats_id_seq OWNED BY "goal_stats".id;
+ALTER TABLE ONLY "goal_stats" ALTER COLUMN id SET DEFAULT nextval('goal_stats_id_seq'::regclass);
+ALTER TABLE ONLY "goal_stats" ADD CONSTRAINT goal_stats_pkey PRIMARY KEY (id);
+CREATE INDEX goal_stats_day_index ON goal_stats(day);
+CREATE INDEX goal_stats_goal_id_index ON goal_stats(goal_id);
+
+CREATE TABLE "funnel" (
+    id bigint NOT NULL UNIQUE,
+    tenant_id bigint,
+    name varchar(200) NOT NULL,
+    steps varchar(2000)[] NOT NULL
+);
+
+CREATE SEQUENCE funnel_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE funnel_id_seq OWNED BY "funnel".id;
+ALTER TABLE ONLY "funnel" ALTER COLUMN id SET DEFAULT nextval('funnel_id_seq'::regclass);
+ALTER TABLE ONLY "funnel" ADD CONSTRAINT funnel_pkey PRIMARY KEY (id);
+CREATE INDEX funnel_tenant_id_index ON funnel(tenant_id);
+
+CREATE TABLE "funnel_stats" (
+    id bigint NOT NULL UNIQUE,
+    tenant_id bigint,
+    day date NOT NULL,
+    funnel_id bigint NOT NULL,
+    step integer NOT NULL,
+    visitors integer NOT NULL
+);
+
+CREATE SEQUENCE funnel_stats_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE funnel_stats_id_seq OWNED BY "funnel_stats".id;
+ALTER TABLE ONLY "funnel_stats" ALTER COLUMN id SET DEFAULT nextval('funnel_stats_id_seq'::regclass);
+ALTER TABLE ONLY "funnel_stats" ADD CONSTRAINT funnel_stats_pkey PRIMARY KEY (id);
+CREATE INDEX funnel_stats_day_index ON funnel_stats(day);
+CREATE INDEX funnel_stats_funnel_id_index ON funnel_stats(funnel_id);
+
+ALTER TABLE "hit" ADD COLUMN "utm_source" character varying(200);
+ALTER TABLE "hit" ADD COLUMN "utm_medium" character varying(200);
+ALTER TABLE "hit" ADD COLUMN "utm_campaign" character varying(200);
+ALTER TABLE "hit" ADD COLUMN "utm_term" character varying(200);
+ALTER TABLE "hit" ADD COLUMN "utm_content" character varying(200);
+ALTER TABLE "hit" ADD COLUMN "status" integer DEFAULT 0;
+
+CREATE TABLE "utm_stats" (
+    id bigint NOT NULL UNIQUE,
+    tenant_id bigint,
+    day date NOT NULL,
+    utm_source varchar(200),
+    utm_medium varchar(200),
+    utm_campaign varchar(200),
+    utm_term varchar(200),
+    utm_content varchar(200),
+    visitors integer NOT NULL
+);
+
+CREATE SEQUENCE utm_stats_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE utm_stats_id_seq OWNED BY "utm_stats".id;
+ALTER TABLE ONLY "utm_stats" ALTER COLUMN id SET DEFAULT nextval('utm_stats_id_seq'::regclass);
+ALTER TABLE ONLY "utm_stats" ADD CONSTRAINT utm_stats_pkey PRIMARY KEY (id);
+CREATE INDEX utm_stats_day_index ON utm_stats(day);
+
+ALTER TABLE "hit" ADD COLUMN "referrer_name" character varying(200);
+ALTER TABLE "hit" ADD COLUMN "channel" character varying(20);
+
+CREATE TABLE "referrer_source_stats" (
+    id bigint NOT NULL UNIQUE,
+    tenant_id bigint,
+    day date NOT NULL,
+    referrer_name varchar(200),
+    channel varchar(20),
+    visitors integer NOT NULL
+);
+
+CREATE SEQUENCE referrer_source_stats_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE referrer_source_stats_id_seq OWNED BY "referrer_source_stats".id;
+ALTER TABLE ONLY "referrer_source_stats" ALTER COLUMN id SET DEFAULT nextval('referrer_source_stats_id_seq'::regclass);
+ALTER TABLE ONLY "referrer_source_stats" ADD CONSTRAINT referrer_source_stats_pkey PRIMARY KEY (id);
+CREATE INDEX referrer_source_stats_day_index ON referrer_source_stats(day);
+
+CREATE TABLE "entry_stats" (
+    id bigint NOT NULL UNIQUE,
+    tenant_id bigint,
+    day date NOT NULL,
+    path varchar(2000) NOT NULL,
+    visitors integer NOT NULL,
+    entries integer NOT NULL
+);
+
+CREATE SEQUENCE entry_stats_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE entry_stats_id_seq OWNED BY "entry_stats".id;
+ALTER TABLE ONLY "entry_stats" ALTER COLUMN id SET DEFAULT nextval('entry_stats_id_seq'::regclass);
+ALTER TABLE ONLY "entry_stats" ADD CONSTRAINT entry_stats_pkey PRIMARY KEY (id);
+CREATE INDEX entry_stats_day_index ON entry_stats(day);
+
+CREATE TABLE "exit_stats" (
+    id bigint NOT NULL UNIQUE,
+    tenant_id bigint,
+    day date NOT NULL,
+    path varchar(2000) NOT NULL,
+    visitors integer NOT NULL,
+    sessions integer NOT NULL,
+    exits integer NOT NULL
+);
+
+CREATE SEQUENCE exit_stats_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE exit_stats_id_seq OWNED BY "exit_stats".id;
+ALTER TABLE ONLY "exit_stats" ALTER COLUMN id SET DEFAULT nextval('exit_stats_id_seq'::regclass);
+ALTER TABLE ONLY "exit_stats" ADD CONSTRAINT exit_stats_pkey PRIMARY KEY (id);
+CREATE INDEX exit_stats_day_index ON exit_stats(day);
+
+ALTER TABLE "visitor_stats" ADD COLUMN "session_duration" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "visitor_stats" ADD COLUMN "session_duration_count" integer NOT NULL DEFAULT 0;
+ALTER TABLE "visitor_stats" ADD COLUMN "time_on_page" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "visitor_stats" ADD COLUMN "time_on_page_count" integer NOT NULL DEFAULT 0;
+ALTER TABLE "visitor_stats" ADD COLUMN "sessions_started" integer NOT NULL DEFAULT 0;
+UPDATE "visitor_stats" SET "sessions_started" = "visitors";
+
+ALTER TABLE "visitor_stats" ADD COLUMN "page_views" integer NOT NULL DEFAULT 0;
+ALTER TABLE "visitor_time_stats" ADD COLUMN "page_views" integer NOT NULL DEFAULT 0;
+ALTER TABLE "language_stats" ADD COLUMN "page_views" integer NOT NULL DEFAULT 0;
+ALTER TABLE "referrer_stats" ADD COLUMN "page_views" integer NOT NULL DEFAULT 0;
+ALTER TABLE "os_stats" ADD COLUMN "page_views" integer NOT NULL DEFAULT 0;
+ALTER TABLE "browser_stats" ADD COLUMN "page_views" integer NOT NULL DEFAULT 0;
+ALTER TABLE "screen_stats" ADD COLUMN "page_views" integer NOT NULL DEFAULT 0;
+ALTER TABLE "country_stats" ADD COLUMN "page_views" integer NOT NULL DEFAULT 0;
+ALTER TABLE "referrer_source_stats" ADD COLUMN "page_views" integer NOT NULL DEFAULT 0;
+ALTER TABLE "utm_stats" ADD COLUMN "page_views" integer NOT NULL DEFAULT 0;
+
+CREATE TABLE "transition_stats" (
+    id bigint NOT NULL UNIQUE,
+    tenant_id bigint,
+    day date NOT NULL,
+    path varchar(2000) NOT NULL,
+    next_path varchar(2000) NOT NULL,
+    visitors integer NOT NULL,
+    transitions integer NOT NULL
+);
+
+CREATE SEQUENCE transition_stats_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE transition_stats_id_seq OWNED BY "transition_stats".id;
+ALTER TABLE ONLY "transition_stats" ALTER COLUMN id SET DEFAULT nextval('transition_stats_id_seq'::regclass);
+ALTER TABLE ONLY "transition_stats" ADD CONSTRAINT transition_stats_pkey PRIMARY KEY (id);
+CREATE INDEX transition_stats_day_index ON transition_stats(day);
+CREATE INDEX transition_stats_path_index ON transition_stats(path);
+CREATE INDEX transition_stats_next_path_index ON transition_stats(next_path);
+
+CREATE TABLE "dimension_stats" (
+    id bigint NOT NULL UNIQUE,
+    tenant_id bigint,
+    day date NOT NULL,
+    dimension varchar(200) NOT NULL,
+    value varchar(2000),
+    visitors integer NOT NULL,
+    page_views integer NOT NULL DEFAULT 0
+);
+
+CREATE SEQUENCE dimension_stats_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE dimension_stats_id_seq OWNED BY "dimension_stats".id;
+ALTER TABLE ONLY "dimension_stats" ALTER COLUMN id SET DEFAULT nextval('dimension_stats_id_seq'::regclass);
+ALTER TABLE ONLY "dimension_stats" ADD CONSTRAINT dimension_stats_pkey PRIMARY KEY (id);
+CREATE INDEX dimension_stats_day_index ON dimension_stats(day);
+CREATE INDEX dimension_stats_dimension_index ON dimension_stats(dimension);
+
+CREATE TABLE "hit_archive" (LIKE "hit");
+ALTER TABLE ONLY "hit_archive" ADD CONSTRAINT hit_archive_pkey PRIMARY KEY (id);
+CREATE INDEX hit_archive_tenant_id_index ON hit_archive(tenant_id);
+CREATE INDEX hit_archive_time_index ON hit_archive(time);
+
+CREATE TABLE "event_archive" (LIKE "event");
+ALTER TABLE ONLY "event_archive" ADD CONSTRAINT event_archive_pkey PRIMARY KEY (id);
+CREATE INDEX event_archive_tenant_id_index ON event_archive(tenant_id);
+CREATE INDEX event_archive_time_index ON event_archive(time);
+
+CREATE TABLE "content_group" (
+    id bigint NOT NULL UNIQUE,
+    tenant_id bigint,
+    name varchar(200) NOT NULL,
+    patterns varchar(2000)[] NOT NULL
+);
+
+CREATE SEQUENCE content_group_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE content_group_id_seq OWNED BY "content_group".id;
+ALTER TABLE ONLY "content_group" ALTER COLUMN id SET DEFAULT nextval('content_group_id_seq'::regclass);
+ALTER TABLE ONLY "content_group" ADD CONSTRAINT content_group_pkey PRIMARY KEY (id);
+CREATE INDEX content_group_tenant_id_index ON content_group(tenant_id);
@@ -3,6 +3,8 @@ package pirsch
 import (
 	"context"
 	"database/sql"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -77,7 +79,7 @@ func (cache *sessionCache) cleanup(ctx context.Context, interval time.Duration)
 		case <-time.After(interval):
 			cache.swap()
 		case <-ctx.Done():
-			break
+			return
 		}
 	}
 }
@@ -90,23 +92,29 @@ func (cache *sessionCache) swap() {
 	cache.m.Unlock()
 }
 
-func (cache *sessionCache) find(tenantID sql.NullInt64, fingerprint string) time.Time {
+func (cache *sessionCache) find(tenantID sql.NullInt64, fingerprint string, now time.Time) time.Time {
 	// look up the active cache, the non-active cache and the database (in that order)
 	// to find an existing session, or add and return a new timestamp if we can't find one
-	now := time.Now().UTC()
+	// sessions that started after now are skipped, so that a backfilled hit doesn't belong to a later session
+	now = now.UTC()
 	cache.m.RLock()
 	session := cache.active[fingerprint]
 
-	if session.IsZero() {
+	if session.IsZero() || session.After(now) {
 		session = cache.inactive[fingerprint]
 
-		if session.IsZero() {
-			session = cache.store.Session(tenantID, fingerprint, now.Add(-cache.maxAge))
+		if session.IsZero() || session.After(now) {
+			session = cache.store.Session(tenantID, fingerprint, now.Add(-cache.maxAge), now)
 
 			if session.IsZero() {
 				cache.m.RUnlock()
 				cache.m.Lock()
-				cache.active[fingerprint] = now
+
+				// don't replace a later session by the one of a backfilled hit
+				if active := cache.active[fingerprint]; active.IsZero() || !active.After(now) {
+					cache.active[fingerprint] = now
+				}
+
 				cache.m.Unlock()
 				return now
 			}
@@ -116,3 +124,214 @@ func (cache *sessionCache) find(tenantID sql.NullInt64, fingerprint string) time
 	cache.m.RUnlock()
 	return session
 }
+
+// groupSessions splits hits into visits, which are the hits of one visitor (tenant and fingerprint) and session.
+// The hits must be ordered by tenant, fingerprint, session, and time, as returned by Store.SessionHits.
+// Hits without session belong to the same visit if they share the fingerprint.
+func groupSessions(hits []Hit) [][]Hit {
+	sessions := make([][]Hit, 0)
+	start := 0
+
+	for i := 1; i <= len(hits); i++ {
+		if i == len(hits) || !sameSession(&hits[start], &hits[i]) {
+			sessions = append(sessions, hits[start:i])
+			start = i
+		}
+	}
+
+	return sessions
+}
+
+func sameSession(a, b *Hit) bool {
+	return a.TenantID == b.TenantID &&
+		a.Fingerprint == b.Fingerprint &&
+		a.Session.Valid == b.Session.Valid &&
+		a.Session.Time.Equal(b.Session.Time)
+}
+
+// countEntryExitPages returns the entry and exit pages for given sessions, as returned by groupSessions, grouped by tenant
+// and ordered by path and tenant. Visitors are counted once per path and tenant, entries, exits, and sessions once per session.
+func countEntryExitPages(sessions [][]Hit) ([]EntryStats, []ExitStats) {
+	entries := make(map[pathKey]*EntryStats)
+	exits := make(map[pathKey]*ExitStats)
+	entryVisitors := make(map[pathKey]map[string]bool)
+	exitVisitors := make(map[pathKey]map[string]bool)
+
+	for _, session := range sessions {
+		if len(session) == 0 {
+			continue
+		}
+
+		tenantID := session[0].TenantID
+		fingerprint := session[0].Fingerprint
+		entryKey := pathKey{tenantID, session[0].Path.String}
+		exitKey := pathKey{tenantID, session[len(session)-1].Path.String}
+
+		if entries[entryKey] == nil {
+			entries[entryKey] = &EntryStats{Stats: Stats{BaseEntity: BaseEntity{TenantID: tenantID}, Path: entryKey.path}}
+			entryVisitors[entryKey] = make(map[string]bool)
+		}
+
+		entries[entryKey].Entries++
+		entryVisitors[entryKey][fingerprint] = true
+		visited := make(map[string]bool)
+
+		for _, hit := range session {
+			path := hit.Path.String
+
+			if visited[path] {
+				continue
+			}
+
+			visited[path] = true
+			key := pathKey{tenantID, path}
+
+			if exits[key] == nil {
+				exits[key] = &ExitStats{Stats: Stats{BaseEntity: BaseEntity{TenantID: tenantID}, Path: path}}
+				exitVisitors[key] = make(map[string]bool)
+			}
+
+			exits[key].Sessions++
+		}
+
+		exits[exitKey].Exits++
+		exitVisitors[exitKey][fingerprint] = true
+	}
+
+	entryStats := make([]EntryStats, 0, len(entries))
+	exitStats := make([]ExitStats, 0, len(exits))
+
+	for key, stats := range entries {
+		stats.Visitors = len(entryVisitors[key])
+		entryStats = append(entryStats, *stats)
+	}
+
+	for key, stats := range exits {
+		stats.Visitors = len(exitVisitors[key])
+		exitStats = append(exitStats, *stats)
+	}
+
+	sort.Slice(entryStats, func(i, j int) bool {
+		if entryStats[i].Path != entryStats[j].Path {
+			return entryStats[i].Path < entryStats[j].Path
+		}
+
+		return entryStats[i].TenantID.Int64 < entryStats[j].TenantID.Int64
+	})
+	sort.Slice(exitStats, func(i, j int) bool {
+		if exitStats[i].Path != exitStats[j].Path {
+			return exitStats[i].Path < exitStats[j].Path
+		}
+
+		return exitStats[i].TenantID.Int64 < exitStats[j].TenantID.Int64
+	})
+	return entryStats, exitStats
+}
+
+// pathKey is the tenant and path session statistics are grouped by.
+type pathKey struct {
+	tenantID sql.NullInt64
+	path     string
+}
+
+// countSessionStats returns the bounces, session durations, and times on page in seconds for given sessions, as returned by groupSessions, grouped by tenant and path.
+// A session with a single page view is a bounce. Bounces and the duration of a session are attributed to the path it started on,
+// so the duration is zero for sessions that bounced. The time on page is the time until the next page view of the session.
+func countSessionStats(sessions [][]Hit) map[pathKey]Stats {
+	stats := make(map[pathKey]Stats)
+
+	for _, session := range sessions {
+		if len(session) == 0 {
+			continue
+		}
+
+		entryKey := pathKey{session[0].TenantID, session[0].Path.String}
+		entry := stats[entryKey]
+		entry.SessionDuration += int(session[len(session)-1].Time.Sub(session[0].Time).Seconds())
+		entry.SessionsStarted++
+		entry.SessionDurationCount++
+
+		if len(session) == 1 {
+			entry.Bounces++
+		}
+
+		stats[entryKey] = entry
+
+		for i := 0; i < len(session)-1; i++ {
+			pageKey := pathKey{session[i].TenantID, session[i].Path.String}
+			page := stats[pageKey]
+			page.TimeOnPage += int(session[i+1].Time.Sub(session[i].Time).Seconds())
+			page.TimeOnPageCount++
+			stats[pageKey] = page
+		}
+	}
+
+	return stats
+}
+
+// sumSessionStats returns the sum of given bounces and durations for all tenants and the optional path.
+func sumSessionStats(stats map[pathKey]Stats, path string) Stats {
+	var sum Stats
+
+	for key, s := range stats {
+		if path == "" || strings.EqualFold(key.path, path) {
+			sum.addSessionStats(&s)
+		}
+	}
+
+	return sum
+}
+
+// countTransitions returns the transitions from one path to the next for given sessions, as returned by groupSessions, grouped by tenant
+// and ordered by path, next path, and tenant. Page views repeating the previous path (like reloads) are not counted as a transition.
+// Visitors are counted once per transition, transitions once per page view.
+func countTransitions(sessions [][]Hit) []TransitionStats {
+	type transition struct {
+		tenantID sql.NullInt64
+		path     string
+		nextPath string
+	}
+	transitions := make(map[transition]*TransitionStats)
+	visitors := make(map[transition]map[string]bool)
+
+	for _, session := range sessions {
+		for i := 1; i < len(session); i++ {
+			t := transition{session[i].TenantID, session[i-1].Path.String, session[i].Path.String}
+
+			if t.path == t.nextPath {
+				continue
+			}
+
+			if transitions[t] == nil {
+				transitions[t] = &TransitionStats{
+					Stats:    Stats{BaseEntity: BaseEntity{TenantID: t.tenantID}, Path: t.path},
+					NextPath: t.nextPath,
+				}
+				visitors[t] = make(map[string]bool)
+			}
+
+			transitions[t].Transitions++
+			visitors[t][session[i].Fingerprint] = true
+		}
+	}
+
+	stats := make([]TransitionStats, 0, len(transitions))
+
+	for t, s := range transitions {
+		s.Visitors = len(visitors[t])
+		stats = append(stats, *s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Path != stats[j].Path {
+			return stats[i].Path < stats[j].Path
+		}
+
+		if stats[i].NextPath != stats[j].NextPath {
+			return stats[i].NextPath < stats[j].NextPath
+		}
+
+		return stats[i].TenantID.Int64 < stats[j].TenantID.Int64
+	})
+	return stats
+}
@@ -1,6 +1,7 @@
 package pirsch
 
 import (
+	"database/sql"
 	"testing"
 	"time"
 )
@@ -40,14 +41,14 @@ func TestSessionCache(t *testing.T) {
 	defer cache.stop()
 
 	// cache miss -> create in active
-	session := cache.find(NullTenant, "fp")
+	session := cache.find(NullTenant, "fp", time.Now())
 
 	if session.IsZero() {
 		t.Fatal("New session must have been returned")
 	}
 
 	// find in active
-	existing := cache.find(NullTenant, "fp")
+	existing := cache.find(NullTenant, "fp", time.Now())
 
 	if !existing.Equal(session) {
 		t.Fatal("Existing session must have been found in active map")
@@ -60,7 +61,7 @@ func TestSessionCache(t *testing.T) {
 		t.Fatalf("Maps not as expected: %v %v", len(cache.active), len(cache.inactive))
 	}
 
-	existing = cache.find(NullTenant, "fp")
+	existing = cache.find(NullTenant, "fp", time.Now())
 
 	if !existing.Equal(session) {
 		t.Fatal("Existing session must have been found in inactive map")
@@ -74,13 +75,32 @@ func TestSessionCache(t *testing.T) {
 	}
 
 	createHit(t, store, 0, "fp", "/", "en", "ua1", "", today(), session, "", "", "", "", "", false, false, 0, 0)
-	existing = cache.find(NullTenant, "fp")
+	existing = cache.find(NullTenant, "fp", time.Now())
 
 	if existing.IsZero() {
 		t.Fatal("Existing session must have been found in database")
 	}
 }
 
+func TestSessionCacheBackfill(t *testing.T) {
+	cleanupDB(t)
+	store := NewPostgresStore(postgresDB, nil)
+	cache := newSessionCache(store, nil)
+	defer cache.stop()
+	session := cache.find(NullTenant, "fp", time.Now())
+	createHit(t, store, 0, "fp", "/", "en", "ua1", "", session, session, "", "", "", "", "", false, false, 0, 0)
+	past := time.Now().Add(-time.Minute * 10)
+	backfill := cache.find(NullTenant, "fp", past)
+
+	if !backfill.Equal(past.UTC()) {
+		t.Fatalf("A new session must have been returned for the backfilled hit, but was: %v", backfill)
+	}
+
+	if existing := cache.find(NullTenant, "fp", time.Now()); !existing.Equal(session) {
+		t.Fatalf("Later session must have been kept, but was: %v", existing)
+	}
+}
+
 func TestSessionCacheRenewal(t *testing.T) {
 	store := NewPostgresStore(postgresDB, nil)
 	session := time.Now().UTC()
@@ -101,7 +121,7 @@ func TestSessionCacheRenewal(t *testing.T) {
 		cache := newSessionCache(store, &sessionCacheConfig{
 			maxAge: time.Hour,
 		})
-		s := cache.find(NullTenant, "fp")
+		s := cache.find(NullTenant, "fp", time.Now())
 
 		if found[i] && (s.Year() != session.Year() || s.Month() != session.Month() || s.Day() != session.Day() || s.Hour() != session.Hour() || s.Minute() != session.Minute() || s.Second() != session.Second()) {
 			t.Fatalf("Session  must have been found, but was: %v", s)
@@ -112,3 +132,137 @@ func TestSessionCacheRenewal(t *testing.T) {
 		cache.stop()
 	}
 }
+
+func TestGroupSessions(t *testing.T) {
+	session := time.Date(2020, 9, 7, 4, 0, 0, 0, time.UTC)
+	hits := []Hit{
+		{Fingerprint: "fp1", Session: sql.NullTime{Time: session, Valid: true}},
+		{Fingerprint: "fp1", Session: sql.NullTime{Time: session, Valid: true}},
+		{Fingerprint: "fp1", Session: sql.NullTime{Time: session.Add(time.Hour), Valid: true}},
+		{Fingerprint: "fp1"},
+		{Fingerprint: "fp1"},
+		{BaseEntity: BaseEntity{TenantID: NewTenantID(1)}, Fingerprint: "fp1"},
+		{Fingerprint: "fp2", Session: sql.NullTime{Time: session, Valid: true}},
+	}
+	sessions := groupSessions(hits)
+
+	if len(sessions) != 5 ||
+		len(sessions[0]) != 2 ||
+		len(sessions[1]) != 1 ||
+		len(sessions[2]) != 2 ||
+		len(sessions[3]) != 1 ||
+		len(sessions[4]) != 1 {
+		t.Fatalf("Sessions not as expected: %v", sessions)
+	}
+
+	if len(groupSessions(nil)) != 0 {
+		t.Fatal("No sessions must be returned for no hits")
+	}
+}
+
+func TestCountEntryExitPages(t *testing.T) {
+	session := time.Date(2020, 9, 7, 4, 0, 0, 0, time.UTC)
+	sessions := groupSessions([]Hit{
+		funnelHit(0, "fp1", session, "/"),
+		funnelHit(0, "fp1", session, "/pricing"),
+		funnelHit(0, "fp1", session, "/signup"),
+		funnelHit(0, "fp1", session.Add(time.Hour*3), "/pricing"),
+		funnelHit(0, "fp2", session, "/"),
+		funnelHit(0, "fp2", session, "/pricing"),
+		funnelHit(0, "fp2", session, "/"),
+		funnelHit(1, "fp1", session, "/"),
+	})
+	entries, exits := countEntryExitPages(sessions)
+
+	if len(entries) != 3 ||
+		entries[0].Path != "/" || entries[0].TenantID.Valid || entries[0].Visitors != 2 || entries[0].Entries != 2 ||
+		entries[1].Path != "/" || entries[1].TenantID != NewTenantID(1) || entries[1].Visitors != 1 || entries[1].Entries != 1 ||
+		entries[2].Path != "/pricing" || entries[2].TenantID.Valid || entries[2].Visitors != 1 || entries[2].Entries != 1 {
+		t.Fatalf("Entry pages not as expected: %v", entries)
+	}
+
+	if len(exits) != 4 ||
+		exits[0].Path != "/" || exits[0].TenantID.Valid || exits[0].Visitors != 1 || exits[0].Exits != 1 || exits[0].Sessions != 2 ||
+		exits[1].Path != "/" || exits[1].TenantID != NewTenantID(1) || exits[1].Visitors != 1 || exits[1].Exits != 1 || exits[1].Sessions != 1 ||
+		exits[2].Path != "/pricing" || exits[2].TenantID.Valid || exits[2].Visitors != 1 || exits[2].Exits != 1 || exits[2].Sessions != 3 ||
+		exits[3].Path != "/signup" || exits[3].TenantID.Valid || exits[3].Visitors != 1 || exits[3].Exits != 1 || exits[3].Sessions != 1 {
+		t.Fatalf("Exit pages not as expected: %v", exits)
+	}
+
+	entries, exits = countEntryExitPages(nil)
+
+	if len(entries) != 0 || len(exits) != 0 {
+		t.Fatal("No entry and exit pages must be returned for no sessions")
+	}
+}
+
+func TestCountTransitions(t *testing.T) {
+	session := time.Date(2020, 9, 7, 4, 0, 0, 0, time.UTC)
+	transitions := countTransitions(groupSessions([]Hit{
+		funnelHit(0, "fp1", session, "/"),
+		funnelHit(0, "fp1", session, "/pricing"),
+		funnelHit(0, "fp1", session, "/pricing"),
+		funnelHit(0, "fp1", session, "/"),
+		funnelHit(0, "fp1", session, "/pricing"),
+		funnelHit(0, "fp2", session, "/"),
+		funnelHit(0, "fp2", session, "/signup"),
+		funnelHit(1, "fp1", session, "/"),
+		funnelHit(1, "fp1", session, "/pricing"),
+	}))
+
+	if len(transitions) != 4 ||
+		transitions[0].Path != "/" || transitions[0].NextPath != "/pricing" || transitions[0].TenantID.Valid || transitions[0].Visitors != 1 || transitions[0].Transitions != 2 ||
+		transitions[1].Path != "/" || transitions[1].NextPath != "/pricing" || transitions[1].TenantID != NewTenantID(1) || transitions[1].Visitors != 1 || transitions[1].Transitions != 1 ||
+		transitions[2].Path != "/" || transitions[2].NextPath != "/signup" || transitions[2].Visitors != 1 || transitions[2].Transitions != 1 ||
+		transitions[3].Path != "/pricing" || transitions[3].NextPath != "/" || transitions[3].Visitors != 1 || transitions[3].Transitions != 1 {
+		t.Fatalf("Transitions not as expected: %v", transitions)
+	}
+
+	if len(countTransitions(nil)) != 0 {
+		t.Fatal("No transitions must be returned for no sessions")
+	}
+}
+
+func TestCountSessionStats(t *testing.T) {
+	session := time.Date(2020, 9, 7, 4, 0, 0, 0, time.UTC)
+	hits := []Hit{
+		funnelHit(0, "fp1", session, "/"),
+		funnelHit(0, "fp1", session, "/pricing"),
+		funnelHit(0, "fp1", session, "/signup"),
+		funnelHit(0, "fp2", session, "/"),
+		funnelHit(1, "fp1", session, "/pricing"),
+		funnelHit(1, "fp1", session, "/"),
+	}
+	hits[0].Time = session
+	hits[1].Time = session.Add(time.Second * 30)
+	hits[2].Time = session.Add(time.Second * 90)
+	hits[3].Time = session
+	hits[4].Time = session
+	hits[5].Time = session.Add(time.Second * 10)
+	durations := countSessionStats(groupSessions(hits))
+	home := durations[pathKey{NullTenant, "/"}]
+	pricing := durations[pathKey{NullTenant, "/pricing"}]
+	pricingTenant := durations[pathKey{NewTenantID(1), "/pricing"}]
+
+	if len(durations) != 3 ||
+		home.Bounces != 1 || pricing.Bounces != 0 || pricingTenant.Bounces != 0 ||
+		home.SessionsStarted != 2 || pricing.SessionsStarted != 0 || pricingTenant.SessionsStarted != 1 ||
+		home.SessionDuration != 90 || home.SessionDurationCount != 2 || home.TimeOnPage != 30 || home.TimeOnPageCount != 1 ||
+		pricing.SessionDuration != 0 || pricing.SessionDurationCount != 0 || pricing.TimeOnPage != 60 || pricing.TimeOnPageCount != 1 ||
+		pricingTenant.SessionDuration != 10 || pricingTenant.SessionDurationCount != 1 || pricingTenant.TimeOnPage != 10 || pricingTenant.TimeOnPageCount != 1 {
+		t.Fatalf("Durations not as expected: %v", durations)
+	}
+
+	sum := sumSessionStats(durations, "")
+
+	if sum.Bounces != 1 || sum.SessionsStarted != 3 || sum.SessionDuration != 100 || sum.SessionDurationCount != 3 || sum.TimeOnPage != 100 || sum.TimeOnPageCount != 3 {
+		t.Fatalf("Sum not as expected: %v", sum)
+	}
+
+	sum = sumSessionStats(durations, "/Pricing")
+	sum.calculateAverageDurations()
+
+	if sum.SessionDuration != 10 || sum.TimeOnPage != 70 || !inRange(sum.AverageSessionDuration, 10) || !inRange(sum.AverageTimeOnPage, 35) {
+		t.Fatalf("Sum for path not as expected: %v", sum)
+	}
+}
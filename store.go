@@ -29,6 +29,51 @@ type Store interface {
 	// DeleteHitsByDay deletes all hits on given day.
 	DeleteHitsByDay(*sqlx.Tx, sql.NullInt64, time.Time) error
 
+	// ArchiveHitsByDay copies all hits on given day to the archive.
+	ArchiveHitsByDay(*sqlx.Tx, sql.NullInt64, time.Time) error
+
+	// DeleteArchivedHitsBefore deletes all archived hits before given day.
+	DeleteArchivedHitsBefore(*sqlx.Tx, sql.NullInt64, time.Time) error
+
+	// SaveEvents persists a list of events.
+	SaveEvents([]Event) error
+
+	// DeleteEventsByDay deletes all events on given day.
+	DeleteEventsByDay(*sqlx.Tx, sql.NullInt64, time.Time) error
+
+	// ArchiveEventsByDay copies all events on given day to the archive.
+	ArchiveEventsByDay(*sqlx.Tx, sql.NullInt64, time.Time) error
+
+	// DeleteArchivedEventsBefore deletes all archived events before given day.
+	DeleteArchivedEventsBefore(*sqlx.Tx, sql.NullInt64, time.Time) error
+
+	// SaveGoal creates or updates a goal.
+	SaveGoal(*Goal) error
+
+	// DeleteGoal deletes the goal for given ID and its statistics.
+	DeleteGoal(sql.NullInt64, int64) error
+
+	// Goals returns all goals.
+	Goals(sql.NullInt64) ([]Goal, error)
+
+	// SaveFunnel creates or updates a funnel.
+	SaveFunnel(*Funnel) error
+
+	// DeleteFunnel deletes the funnel for given ID and its statistics.
+	DeleteFunnel(sql.NullInt64, int64) error
+
+	// Funnels returns all funnels.
+	Funnels(sql.NullInt64) ([]Funnel, error)
+
+	// SaveContentGroup creates or updates a content group.
+	SaveContentGroup(*ContentGroup) error
+
+	// DeleteContentGroup deletes the content group for given ID.
+	DeleteContentGroup(sql.NullInt64, int64) error
+
+	// ContentGroups returns all content groups.
+	ContentGroups(sql.NullInt64) ([]ContentGroup, error)
+
 	// SaveVisitorStats saves VisitorStats.
 	SaveVisitorStats(*sqlx.Tx, *VisitorStats) error
 
@@ -41,6 +86,9 @@ type Store interface {
 	// SaveReferrerStats saves ReferrerStats.
 	SaveReferrerStats(*sqlx.Tx, *ReferrerStats) error
 
+	// SaveReferrerSourceStats saves ReferrerSourceStats.
+	SaveReferrerSourceStats(*sqlx.Tx, *ReferrerSourceStats) error
+
 	// SaveOSStats saves OSStats.
 	SaveOSStats(*sqlx.Tx, *OSStats) error
 
@@ -53,12 +101,46 @@ type Store interface {
 	// SaveCountryStats saves CountryStats.
 	SaveCountryStats(*sqlx.Tx, *CountryStats) error
 
-	// Session returns the hits session timestamp for given fingerprint and max age.
-	Session(sql.NullInt64, string, time.Time) time.Time
+	// SaveEventStats saves EventStats.
+	SaveEventStats(*sqlx.Tx, *EventStats) error
+
+	// SaveEventMetadataStats saves EventMetadataStats.
+	SaveEventMetadataStats(*sqlx.Tx, *EventMetadataStats) error
+
+	// SaveUTMStats saves UTMStats.
+	SaveUTMStats(*sqlx.Tx, *UTMStats) error
+
+	// SaveGoalStats saves GoalStats.
+	SaveGoalStats(*sqlx.Tx, *GoalStats) error
+
+	// SaveFunnelStats saves FunnelStats.
+	SaveFunnelStats(*sqlx.Tx, *FunnelStats) error
+
+	// SaveEntryStats saves EntryStats.
+	SaveEntryStats(*sqlx.Tx, *EntryStats) error
+
+	// SaveExitStats saves ExitStats.
+	SaveExitStats(*sqlx.Tx, *ExitStats) error
+
+	// SaveTransitionStats saves TransitionStats.
+	SaveTransitionStats(*sqlx.Tx, *TransitionStats) error
+
+	// SaveDimensionStats saves DimensionStats.
+	SaveDimensionStats(*sqlx.Tx, *DimensionStats) error
+
+	// Session returns the hits session timestamp for given fingerprint.
+	// Only sessions with a hit between the max age and the upper bound (exclusive and inclusive) are returned.
+	Session(sql.NullInt64, string, time.Time, time.Time) time.Time
 
 	// HitDays returns the distinct days with at least one hit.
 	HitDays(sql.NullInt64) ([]time.Time, error)
 
+	// EventDays returns the distinct days with at least one event.
+	EventDays(sql.NullInt64) ([]time.Time, error)
+
+	// SessionHits returns the hits for given day ordered by tenant, fingerprint, session, and time.
+	SessionHits(*sqlx.Tx, sql.NullInt64, time.Time) ([]Hit, error)
+
 	// HitPaths returns the distinct paths for given day.
 	HitPaths(sql.NullInt64, time.Time) ([]string, error)
 
@@ -87,15 +169,26 @@ type Store interface {
 	CountVisitorsByPathAndBrowser(*sqlx.Tx, sql.NullInt64, time.Time, string) ([]BrowserStats, error)
 
 	// CountVisitorsByLanguage returns the visitor count for given day grouped by language.
+	//
+	// Deprecated: use CountVisitorsByDimension with DimensionLanguage instead.
 	CountVisitorsByLanguage(*sqlx.Tx, sql.NullInt64, time.Time) ([]LanguageStats, error)
 
 	// CountVisitorsByReferrer returns the visitor count for given day grouped by referrer.
+	//
+	// Deprecated: use CountVisitorsByDimension with DimensionReferrer instead.
 	CountVisitorsByReferrer(*sqlx.Tx, sql.NullInt64, time.Time) ([]ReferrerStats, error)
 
+	// CountVisitorsByReferrerSource returns the visitor count for given day grouped by referrer source and channel.
+	CountVisitorsByReferrerSource(*sqlx.Tx, sql.NullInt64, time.Time) ([]ReferrerSourceStats, error)
+
 	// CountVisitorsByOS returns the visitor count for given day grouped by operating system.
+	//
+	// Deprecated: use CountVisitorsByDimension with DimensionOS instead.
 	CountVisitorsByOS(*sqlx.Tx, sql.NullInt64, time.Time) ([]OSStats, error)
 
 	// CountVisitorsByBrowser returns the visitor count for given day grouped by browser.
+	//
+	// Deprecated: use CountVisitorsByDimension with DimensionBrowser instead.
 	CountVisitorsByBrowser(*sqlx.Tx, sql.NullInt64, time.Time) ([]BrowserStats, error)
 
 	// CountVisitorsByScreenSize returns the visitor count for given day grouped by screen size (width and height).
@@ -104,19 +197,52 @@ type Store interface {
 	// CountVisitorsByCountryCode returns the visitor count for given day grouped by country code.
 	CountVisitorsByCountryCode(*sqlx.Tx, sql.NullInt64, time.Time) ([]CountryStats, error)
 
+	// CountVisitorsByDimension returns the visitor count for given day grouped by the value of given dimension.
+	CountVisitorsByDimension(*sqlx.Tx, sql.NullInt64, time.Time, Dimension) ([]DimensionStats, error)
+
+	// CountVisitorsByUTM returns the visitor count for given day grouped by campaign parameters.
+	// Visitors without campaign parameters are not included.
+	CountVisitorsByUTM(*sqlx.Tx, sql.NullInt64, time.Time) ([]UTMStats, error)
+
 	// CountVisitorsByPlatform returns the visitor count for given day grouped by platform.
 	CountVisitorsByPlatform(*sqlx.Tx, sql.NullInt64, time.Time) *VisitorStats
 
 	// CountVisitorsByPathAndMaxOneHit returns the visitor count for given day and optional path with a maximum of one hit.
 	// This returns the absolut number of hits without further page calls and is used to calculate the bounce rate.
+	//
+	// Deprecated: bounces are counted per session by the Processor now, see Stats.Bounces.
 	CountVisitorsByPathAndMaxOneHit(*sqlx.Tx, sql.NullInt64, time.Time, string) int
 
+	// CountEvents returns the visitor and event count and the sum of the values for given day grouped by path and event name.
+	CountEvents(*sqlx.Tx, sql.NullInt64, time.Time) ([]EventStats, error)
+
+	// CountEventMetadata returns the visitor and event count for given day grouped by path, event name, and metadata key and value.
+	CountEventMetadata(*sqlx.Tx, sql.NullInt64, time.Time) ([]EventMetadataStats, error)
+
+	// CountEventsByName returns the visitor and event count and the sum of the values for given day and optional path grouped by event name.
+	// Visitors are counted once per event, even if they triggered it on multiple pages.
+	CountEventsByName(*sqlx.Tx, sql.NullInt64, time.Time, string) ([]EventStats, error)
+
+	// CountEventMetadataByName returns the visitor and event count for given day, event name, and optional path grouped by metadata key and value.
+	// Visitors are counted once per key and value, even if they triggered the event on multiple pages.
+	CountEventMetadataByName(*sqlx.Tx, sql.NullInt64, time.Time, string, string) ([]EventMetadataStats, error)
+
+	// CountGoalConversions returns the converted visitor count for given day and goal grouped by referrer, country code, and browser.
+	// The dimensions are taken from the first hit of each visitor on that day.
+	CountGoalConversions(*sqlx.Tx, sql.NullInt64, time.Time, *Goal) ([]GoalStats, error)
+
 	// ActiveVisitors returns the active visitor count for given duration.
 	ActiveVisitors(sql.NullInt64, time.Time) int
 
+	// ActiveVisitorsFilter returns the active visitor count for given duration, tenant, and visitor attributes of the filter.
+	ActiveVisitorsFilter(*Filter, time.Time) int
+
 	// ActivePageVisitors returns the active visitors grouped by path for given duration.
 	ActivePageVisitors(sql.NullInt64, time.Time) ([]Stats, error)
 
+	// ActivePageVisitorsFilter returns the active visitors grouped by path for given duration, tenant, and visitor attributes of the filter.
+	ActivePageVisitorsFilter(*Filter, time.Time) ([]Stats, error)
+
 	// Visitors returns the visitors for given time frame grouped by days.
 	Visitors(sql.NullInt64, time.Time, time.Time) ([]Stats, error)
 
@@ -124,26 +250,94 @@ type Store interface {
 	VisitorHours(sql.NullInt64, time.Time, time.Time) ([]VisitorTimeStats, error)
 
 	// VisitorLanguages returns the visitors for given time frame grouped by language.
+	//
+	// Deprecated: use VisitorDimension with DimensionLanguage instead.
 	VisitorLanguages(sql.NullInt64, time.Time, time.Time) ([]LanguageStats, error)
 
 	// VisitorReferrer returns the visitor count for given time frame grouped by referrer.
+	//
+	// Deprecated: use VisitorDimension with DimensionReferrer instead.
 	VisitorReferrer(sql.NullInt64, time.Time, time.Time) ([]ReferrerStats, error)
 
+	// VisitorReferrerSource returns the visitor count for given time frame grouped by referrer source and channel.
+	VisitorReferrerSource(sql.NullInt64, time.Time, time.Time) ([]ReferrerSourceStats, error)
+
+	// VisitorChannel returns the visitor count for given time frame grouped by channel.
+	VisitorChannel(sql.NullInt64, time.Time, time.Time) ([]ReferrerSourceStats, error)
+
 	// VisitorOS returns the visitor count for given time frame grouped by operating system.
+	//
+	// Deprecated: use VisitorDimension with DimensionOS instead.
 	VisitorOS(sql.NullInt64, time.Time, time.Time) ([]OSStats, error)
 
 	// VisitorBrowser returns the visitor count for given time frame grouped by browser.
+	//
+	// Deprecated: use VisitorDimension with DimensionBrowser instead.
 	VisitorBrowser(sql.NullInt64, time.Time, time.Time) ([]BrowserStats, error)
 
 	// VisitorPlatform returns the visitor count for given time frame grouped by platform.
 	VisitorPlatform(sql.NullInt64, time.Time, time.Time) *VisitorStats
 
 	// VisitorScreenSize returns the visitor count for given time frame grouped by screen size (width and height).
+	//
+	// Deprecated: use VisitorDimension with DimensionScreen instead.
 	VisitorScreenSize(sql.NullInt64, time.Time, time.Time) ([]ScreenStats, error)
 
 	// VisitorCountry returns the visitor count for given time frame grouped by country code.
+	//
+	// Deprecated: use VisitorDimension with DimensionCountry instead.
 	VisitorCountry(sql.NullInt64, time.Time, time.Time) ([]CountryStats, error)
 
+	// VisitorDimension returns the visitor count for given time frame grouped by the value of given dimension.
+	VisitorDimension(sql.NullInt64, time.Time, time.Time, Dimension) ([]DimensionStats, error)
+
+	// FilterStats returns the statistics for all hits and archived hits of sessions matching the time frame and visitor attributes of given filter,
+	// grouped by given columns (day, hour, path, or the visitor attributes of FilterStats). Only page views matching the path and path pattern of the filter are counted.
+	FilterStats(*Filter, []string) ([]FilterStats, error)
+
+	// FilterEvents returns all events and archived events of sessions matching the time frame and visitor attributes of given filter.
+	FilterEvents(*Filter) ([]Event, error)
+
+	// FilterDimension returns the visitor count and page views grouped by given Dimension for sessions matching given filter.
+	FilterDimension(*Filter, Dimension) ([]DimensionStats, error)
+
+	// FilterEntryPages returns the visitor and entry count grouped by the path sessions matching given filter started on.
+	// Only entry pages matching the path and path pattern of the filter are returned.
+	FilterEntryPages(*Filter) ([]EntryStats, error)
+
+	// FilterExitPages returns the visitor, session, and exit count grouped by the paths visited by sessions matching given filter.
+	// Only pages matching the path and path pattern of the filter are returned.
+	FilterExitPages(*Filter) ([]ExitStats, error)
+
+	// FilterNextPages returns the visitor and transition count for sessions matching given filter and its path grouped by the next path.
+	// Only next paths matching the path pattern of the filter are returned.
+	FilterNextPages(*Filter) ([]TransitionStats, error)
+
+	// FilterPreviousPages returns the visitor and transition count for sessions matching given filter and its path as the next path grouped by the previous path.
+	// Only previous paths matching the path pattern of the filter are returned.
+	FilterPreviousPages(*Filter) ([]TransitionStats, error)
+
+	// FilterGoalConversions returns the converted visitor count for sessions matching given filter and goal grouped by referrer, country code, and browser.
+	// Only page views and events on pages matching the path and path pattern of the filter convert.
+	FilterGoalConversions(*Filter, *Goal) ([]GoalStats, error)
+
+	// FilterFunnelSteps returns the visitor count for sessions matching given filter and funnel grouped by step.
+	// Page views not matching the path and path pattern of the filter are left out.
+	FilterFunnelSteps(*Filter, *Funnel) ([]FunnelStats, error)
+
+	// FilterContentGroups returns the visitor count and page views for sessions matching given filter grouped by given content groups.
+	// Only page views matching the path and path pattern of the filter are counted.
+	FilterContentGroups(*Filter, []ContentGroup) ([]DimensionStats, error)
+
+	// VisitorUTMSource returns the visitor count for given time frame grouped by utm_source.
+	VisitorUTMSource(sql.NullInt64, time.Time, time.Time) ([]UTMStats, error)
+
+	// VisitorUTMMedium returns the visitor count for given time frame grouped by utm_medium.
+	VisitorUTMMedium(sql.NullInt64, time.Time, time.Time) ([]UTMStats, error)
+
+	// VisitorUTMCampaign returns the visitor count for given time frame grouped by utm_campaign.
+	VisitorUTMCampaign(sql.NullInt64, time.Time, time.Time) ([]UTMStats, error)
+
 	// PageVisitors returns the visitors for given path and time frame grouped by days.
 	PageVisitors(sql.NullInt64, string, time.Time, time.Time) ([]Stats, error)
 
@@ -162,7 +356,40 @@ type Store interface {
 	// PagePlatform returns the visitors for given path and time frame grouped by platform.
 	PagePlatform(sql.NullInt64, string, time.Time, time.Time) *VisitorStats
 
-	// VisitorsSum returns the sum of the visitors, sessions, and bounces for given time frame and path.
+	// Events returns the visitor and event count and the sum of the values for given time frame and optional path grouped by event name.
+	Events(sql.NullInt64, time.Time, time.Time, string) ([]EventStats, error)
+
+	// EventMetadata returns the visitor and event count for given event, time frame, and optional path grouped by metadata key and value.
+	EventMetadata(sql.NullInt64, string, time.Time, time.Time, string) ([]EventMetadataStats, error)
+
+	// GoalConversions returns the converted visitor count for given time frame grouped by goal.
+	GoalConversions(sql.NullInt64, time.Time, time.Time) ([]GoalStats, error)
+
+	// GoalReferrer returns the converted visitor count for given goal and time frame grouped by referrer.
+	GoalReferrer(sql.NullInt64, int64, time.Time, time.Time) ([]GoalStats, error)
+
+	// GoalCountry returns the converted visitor count for given goal and time frame grouped by country code.
+	GoalCountry(sql.NullInt64, int64, time.Time, time.Time) ([]GoalStats, error)
+
+	// GoalBrowser returns the converted visitor count for given goal and time frame grouped by browser.
+	GoalBrowser(sql.NullInt64, int64, time.Time, time.Time) ([]GoalStats, error)
+
+	// FunnelSteps returns the visitor count for given funnel and time frame grouped by step.
+	FunnelSteps(sql.NullInt64, int64, time.Time, time.Time) ([]FunnelStats, error)
+
+	// EntryPages returns the visitor and entry count for given time frame and optional path grouped by path.
+	EntryPages(sql.NullInt64, time.Time, time.Time, string) ([]EntryStats, error)
+
+	// ExitPages returns the visitor, session, and exit count for given time frame and optional path grouped by path.
+	ExitPages(sql.NullInt64, time.Time, time.Time, string) ([]ExitStats, error)
+
+	// NextPages returns the visitor and transition count for given time frame and path grouped by the next path.
+	NextPages(sql.NullInt64, time.Time, time.Time, string) ([]TransitionStats, error)
+
+	// PreviousPages returns the visitor and transition count for given time frame and next path grouped by the previous path.
+	PreviousPages(sql.NullInt64, time.Time, time.Time, string) ([]TransitionStats, error)
+
+	// VisitorsSum returns the sum of the visitors, sessions, bounces, session durations, and times on page for given time frame and path.
 	// The path is optional.
 	VisitorsSum(sql.NullInt64, time.Time, time.Time, string) (*Stats, error)
 }
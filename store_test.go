@@ -19,11 +19,12 @@ func testStorageBackends() []Store {
 type storeMock struct {
 	PostgresStore
 
-	hits []Hit
+	hits   []Hit
+	events []Event
 }
 
 func newTestStore() *storeMock {
-	return &storeMock{hits: make([]Hit, 0)}
+	return &storeMock{hits: make([]Hit, 0), events: make([]Event, 0)}
 }
 
 func (store *storeMock) NewTx() *sqlx.Tx {
@@ -47,6 +48,11 @@ func (store *storeMock) SaveHits(hits []Hit) error {
 	return nil
 }
 
-func (store *storeMock) Session(tenantID sql.NullInt64, fingerprint string, maxAge time.Time) time.Time {
+func (store *storeMock) SaveEvents(events []Event) error {
+	store.events = append(store.events, events...)
+	return nil
+}
+
+func (store *storeMock) Session(tenantID sql.NullInt64, fingerprint string, maxAge, now time.Time) time.Time {
 	return time.Now()
 }
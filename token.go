@@ -0,0 +1,42 @@
+package pirsch
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+// NewSiteToken returns a token signed using HMAC-SHA256 for given secret, tenant ID, and hostname.
+// The token is meant to be embedded in the script tag of pirsch.js (data-token) and is verified by the CollectHandler
+// if CollectConfig.TokenSecret is set. This prevents sending hits for other tenants or hostnames than the token was issued for.
+// The secret should not be known outside your organization, treat it like a password.
+func NewSiteToken(secret []byte, tenantID sql.NullInt64, hostname string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(siteTokenPayload(tenantID, hostname)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySiteToken returns true if the token has been issued for given secret, tenant ID, and hostname.
+func VerifySiteToken(secret []byte, token string, tenantID sql.NullInt64, hostname string) bool {
+	if token == "" {
+		return false
+	}
+
+	expected := NewSiteToken(secret, tenantID, hostname)
+	return hmac.Equal([]byte(token), []byte(expected))
+}
+
+// siteTokenPayload returns the signed payload for given tenant ID and hostname.
+// The tenant ID is left empty if it's NULL, so that the token is different from the one for tenant 0.
+func siteTokenPayload(tenantID sql.NullInt64, hostname string) string {
+	id := ""
+
+	if tenantID.Valid {
+		id = strconv.FormatInt(tenantID.Int64, 10)
+	}
+
+	return id + ":" + strings.ToLower(hostname)
+}
@@ -0,0 +1,37 @@
+package pirsch
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestSiteToken(t *testing.T) {
+	secret := []byte("secret")
+	token := NewSiteToken(secret, NewTenantID(42), "example.com")
+
+	if token == "" {
+		t.Fatal("Token must have been created")
+	}
+
+	if !VerifySiteToken(secret, token, NewTenantID(42), "example.com") ||
+		!VerifySiteToken(secret, token, NewTenantID(42), "Example.com") {
+		t.Fatal("Token must be valid")
+	}
+
+	if VerifySiteToken(secret, token, NewTenantID(43), "example.com") ||
+		VerifySiteToken(secret, token, NullTenant, "example.com") ||
+		VerifySiteToken(secret, token, NewTenantID(42), "evil.com") ||
+		VerifySiteToken([]byte("other"), token, NewTenantID(42), "example.com") ||
+		VerifySiteToken(secret, "", NewTenantID(42), "example.com") {
+		t.Fatal("Token must be invalid")
+	}
+
+	if NewSiteToken(secret, NullTenant, "example.com") != NewSiteToken(secret, NewTenantID(0), "example.com") {
+		t.Fatal("Tokens without tenant must be equal")
+	}
+
+	if NewSiteToken(secret, NullTenant, "example.com") == NewSiteToken(secret, sql.NullInt64{Int64: 0, Valid: true}, "example.com") ||
+		VerifySiteToken(secret, NewSiteToken(secret, NullTenant, "example.com"), sql.NullInt64{Int64: 0, Valid: true}, "example.com") {
+		t.Fatal("Token without tenant must differ from the one for tenant 0")
+	}
+}
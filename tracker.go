@@ -2,7 +2,9 @@ package pirsch
 
 import (
 	"context"
+	"errors"
 	"log"
+	"math/rand"
 	"net/http"
 	"os"
 	"runtime"
@@ -12,9 +14,34 @@ import (
 )
 
 const (
-	defaultWorkerBufferSize = 100
-	defaultWorkerTimeout    = time.Second * 10
-	maxWorkerTimeout        = time.Second * 60
+	defaultWorkerBufferSize   = 100
+	defaultWorkerTimeout      = time.Second * 10
+	maxWorkerTimeout          = time.Second * 60
+	defaultOverflowSampleRate = 0.1
+)
+
+// ErrTrackerStopped is returned by Tracker.HitSync if the Tracker has been stopped.
+var ErrTrackerStopped = errors.New("tracker has been stopped")
+
+// OverflowPolicy defines what the Tracker does with a hit when its queue is full.
+// This happens if the store can't keep up with the incoming hits, for example because the database is slow.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the call to Tracker.Hit until there is space in the queue
+	// or TrackerConfig.OverflowTimeout has been reached. The hit is dropped on timeout.
+	// This is the default.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropNewest drops the hit that is about to be queued.
+	OverflowDropNewest
+
+	// OverflowDropOldest drops the oldest hit in the queue to make space for the new one.
+	OverflowDropOldest
+
+	// OverflowSample only accepts a random sample of hits (see TrackerConfig.OverflowSampleRate)
+	// once the queue is more than half full and drops the hit if the queue is full.
+	OverflowSample
 )
 
 // TrackerConfig is the optional configuration for the Tracker.
@@ -33,6 +60,18 @@ type TrackerConfig struct {
 	// If you leave it 0, the default timeout is used, else it is limted to 60 seconds.
 	WorkerTimeout time.Duration
 
+	// Overflow sets the OverflowPolicy used when the queue is full.
+	// By default, the Tracker blocks until there is space in the queue (OverflowBlock).
+	Overflow OverflowPolicy
+
+	// OverflowTimeout sets the maximum time Tracker.Hit blocks when using OverflowBlock.
+	// If you leave it 0, Tracker.Hit blocks until the hit has been queued.
+	OverflowTimeout time.Duration
+
+	// OverflowSampleRate sets the share of hits (between 0 and 1) that is accepted when using OverflowSample.
+	// If you leave it 0, 10% of the hits are accepted.
+	OverflowSampleRate float64
+
 	// ReferrerDomainBlacklist see HitOptions.ReferrerDomainBlacklist.
 	ReferrerDomainBlacklist []string
 
@@ -51,6 +90,17 @@ type TrackerConfig struct {
 	// If not passed, the default will be used.
 	SessionCleanupInterval time.Duration
 
+	// WALDir enables the write-ahead log if set. Hits are appended to a segmented log in this directory
+	// before they are queued and acknowledged once they have been saved by a worker.
+	// Unacknowledged hits (from a crash for example) are saved in the background after the Tracker has been created.
+	// The directory will be created if it doesn't exist. The write-ahead log is disabled by default.
+	WALDir string
+
+	// WALSegmentSize sets the size in bytes at which a new segment is started for the write-ahead log.
+	// Segments are removed as soon as all hits they contain have been saved.
+	// If you leave it 0, the default size of 4 MB is used.
+	WALSegmentSize int64
+
 	// GeoDB enables/disabled mapping IPs to country codes.
 	// Can be set/updated at runtime by calling Tracker.SetGeoDB.
 	GeoDB *GeoDB
@@ -77,6 +127,14 @@ func (config *TrackerConfig) validate() {
 		config.WorkerTimeout = maxWorkerTimeout
 	}
 
+	if config.OverflowTimeout < 0 {
+		config.OverflowTimeout = 0
+	}
+
+	if config.OverflowSampleRate <= 0 || config.OverflowSampleRate > 1 {
+		config.OverflowSampleRate = defaultOverflowSampleRate
+	}
+
 	if config.Logger == nil {
 		config.Logger = log.New(os.Stdout, logPrefix, log.LstdFlags)
 	}
@@ -84,25 +142,80 @@ func (config *TrackerConfig) validate() {
 
 // Tracker is the main component of Pirsch.
 // It provides methods to track requests and store them in a data store.
-// Make sure you call Stop to make sure the hits get stored before shutting down the server.
+// Make sure you call Stop or Shutdown to make sure the hits get stored before shutting down the server.
 type Tracker struct {
+	// counters are accessed atomically and must be 64-bit aligned
+	accepted  int64
+	queued    int64
+	saved     int64
+	failed    int64
+	dropped   int64
+	lastFlush int64 // unix nano
+
+	ignored                                   map[string]int64
+	ignoredMutex                              sync.Mutex
 	store                                     Store
 	salt                                      string
-	hits                                      chan Hit
+	hits                                      chan queuedHit
+	hitsMutex                                 sync.RWMutex
 	stopped                                   int32
 	worker                                    int
 	workerBufferSize                          int
 	workerTimeout                             time.Duration
-	workerCancel                              context.CancelFunc
-	workerDone                                chan bool
+	workerFlush                               []chan chan struct{}
+	workerWG                                  sync.WaitGroup
+	workerStopped                             chan struct{}
+	replayDone                                chan struct{}
+	overflow                                  OverflowPolicy
+	overflowTimeout                           time.Duration
+	overflowSampleRate                        float64
 	referrerDomainBlacklist                   []string
 	referrerDomainBlacklistIncludesSubdomains bool
 	geoDB                                     *GeoDB
 	geoDBMutex                                sync.RWMutex
 	sessionCache                              *sessionCache
+	wal                                       *hitLog
 	logger                                    *log.Logger
 }
 
+// queuedHit is a hit or event waiting to be saved by a worker.
+// The event is set if the entry is an event instead of a hit.
+// The position is set if the entry has been written to the write-ahead log.
+type queuedHit struct {
+	hit   Hit
+	event *Event
+	pos   *walPosition
+}
+
+// TrackerStats is a snapshot of the Tracker counters.
+// The counters start at zero when the Tracker is created.
+type TrackerStats struct {
+	// Queued is the number of hits waiting to be saved, either in the queue or buffered by a worker.
+	Queued int64 `json:"queued"`
+
+	// Accepted is the total number of hits accepted by the Tracker (not ignored).
+	Accepted int64 `json:"accepted"`
+
+	// Ignored is the number of ignored hits by reason (see IgnoreReasonUserAgent and the other reasons).
+	Ignored map[string]int64 `json:"ignored"`
+
+	// Saved is the total number of hits saved successfully.
+	Saved int64 `json:"saved"`
+
+	// Failed is the total number of hits that could not be saved.
+	Failed int64 `json:"failed"`
+
+	// Dropped is the total number of hits dropped because the queue was full.
+	Dropped int64 `json:"dropped"`
+
+	// Worker is the number of workers used to save hits.
+	Worker int `json:"worker"`
+
+	// LastFlush is the time hits have been saved successfully for the last time.
+	// It's zero if nothing has been saved yet.
+	LastFlush time.Time `json:"last_flush"`
+}
+
 // NewTracker creates a new tracker for given store, salt and config.
 // Pass nil for the config to use the defaults.
 // The salt is mandatory.
@@ -127,18 +240,38 @@ func NewTracker(store Store, salt string, config *TrackerConfig) *Tracker {
 	tracker := &Tracker{
 		store:                   store,
 		salt:                    salt,
-		hits:                    make(chan Hit, config.Worker*config.WorkerBufferSize),
+		ignored:                 make(map[string]int64),
+		hits:                    make(chan queuedHit, config.Worker*config.WorkerBufferSize),
 		worker:                  config.Worker,
 		workerBufferSize:        config.WorkerBufferSize,
 		workerTimeout:           config.WorkerTimeout,
-		workerDone:              make(chan bool),
+		workerStopped:           make(chan struct{}),
+		overflow:                config.Overflow,
+		overflowTimeout:         config.OverflowTimeout,
+		overflowSampleRate:      config.OverflowSampleRate,
 		referrerDomainBlacklist: config.ReferrerDomainBlacklist,
 		referrerDomainBlacklistIncludesSubdomains: config.ReferrerDomainBlacklistIncludesSubdomains,
 		sessionCache: sessionCache,
 		geoDB:        config.GeoDB,
 		logger:       config.Logger,
 	}
+
+	var unacknowledged []queuedHit
+
+	if config.WALDir != "" {
+		unacknowledged = tracker.openWAL(config.WALDir, config.WALSegmentSize)
+	}
+
 	tracker.startWorker()
+	tracker.replayDone = make(chan struct{})
+
+	if len(unacknowledged) > 0 {
+		tracker.workerWG.Add(1)
+		go tracker.replay(unacknowledged)
+	} else {
+		close(tracker.replayDone)
+	}
+
 	return tracker
 }
 
@@ -150,40 +283,164 @@ func (tracker *Tracker) Hit(r *http.Request, options *HitOptions) {
 		return
 	}
 
-	if !IgnoreHit(r) {
-		if options == nil {
-			options = &HitOptions{
-				ReferrerDomainBlacklist:                   tracker.referrerDomainBlacklist,
-				ReferrerDomainBlacklistIncludesSubdomains: tracker.referrerDomainBlacklistIncludesSubdomains,
-			}
-		}
+	if hit, ok := tracker.hitFromRequest(r, options); ok {
+		tracker.queue(queuedHit{hit: hit})
+	}
+}
 
-		if tracker.geoDB != nil {
-			tracker.geoDBMutex.RLock()
-			defer tracker.geoDBMutex.RUnlock()
-			options.geoDB = tracker.geoDB
-		}
+// Event stores the given event for the request.
+// The event is tracked for the page the request has been sent for (see HitOptions) and ignored under the same
+// conditions as a hit, or if it has no name. Events don't count as page visits.
+// It's save (and recommended!) to call this function in its own goroutine.
+func (tracker *Tracker) Event(r *http.Request, eventOptions EventOptions, options *HitOptions) {
+	if atomic.LoadInt32(&tracker.stopped) > 0 {
+		return
+	}
 
-		if tracker.sessionCache != nil {
-			options.sessionCache = tracker.sessionCache
-		}
+	if hit, ok := tracker.hitFromRequest(r, options); ok {
+		tracker.queueEvent(hit, &eventOptions)
+	}
+}
+
+// Track stores the given TrackInput for later analysis.
+// It works like Hit, but doesn't require a http.Request. This can be used to track visitors from gRPC services,
+// queue consumers, or backends forwarding visitor information. The same bot filtering, fingerprinting, User-Agent parsing,
+// GeoDB lookup, and sessions are applied. Requests pre-fetching data cannot be detected and must be filtered beforehand.
+func (tracker *Tracker) Track(input *TrackInput) {
+	if atomic.LoadInt32(&tracker.stopped) > 0 || input == nil {
+		return
+	}
+
+	if hit, ok := tracker.hitFromInput(input); ok {
+		tracker.queue(queuedHit{hit: hit})
+	}
+}
+
+// TrackEvent stores the given event for the TrackInput.
+// It works like Event, but doesn't require a http.Request (see Track).
+func (tracker *Tracker) TrackEvent(input *TrackInput, eventOptions EventOptions) {
+	if atomic.LoadInt32(&tracker.stopped) > 0 || input == nil {
+		return
+	}
 
-		tracker.hits <- HitFromRequest(r, tracker.salt, options)
+	if hit, ok := tracker.hitFromInput(input); ok {
+		tracker.queueEvent(hit, &eventOptions)
 	}
 }
 
-// Flush flushes all hits to store that are currently buffered by the workers.
-// Call Tracker.Stop to also save hits that are in the queue.
+// HitSync stores the given request like Hit, but saves it right away instead of queueing it.
+// It returns the error of the Store, or ErrTrackerStopped if the Tracker has been stopped.
+// Ignored requests don't return an error.
+func (tracker *Tracker) HitSync(r *http.Request, options *HitOptions) error {
+	if atomic.LoadInt32(&tracker.stopped) > 0 {
+		return ErrTrackerStopped
+	}
+
+	hit, ok := tracker.hitFromRequest(r, options)
+
+	if !ok {
+		return nil
+	}
+
+	atomic.AddInt64(&tracker.accepted, 1)
+
+	if err := tracker.store.SaveHits([]Hit{hit}); err != nil {
+		atomic.AddInt64(&tracker.failed, 1)
+		return err
+	}
+
+	atomic.AddInt64(&tracker.saved, 1)
+	atomic.StoreInt64(&tracker.lastFlush, time.Now().UnixNano())
+	return nil
+}
+
+// Flush saves all hits that are currently queued or buffered by the workers and waits until they have been saved.
+// This includes the hits replayed from the write-ahead log on startup.
+// The workers keep running, so it's safe to call Flush while hits are tracked.
 func (tracker *Tracker) Flush() {
-	tracker.stopWorker()
-	tracker.startWorker()
+	select {
+	case <-tracker.replayDone:
+	case <-tracker.workerStopped:
+		return
+	}
+
+	done := make([]chan struct{}, 0, len(tracker.workerFlush))
+
+	for _, flush := range tracker.workerFlush {
+		d := make(chan struct{})
+
+		select {
+		case flush <- d:
+			done = append(done, d)
+		case <-tracker.workerStopped:
+			return
+		}
+	}
+
+	for _, d := range done {
+		select {
+		case <-d:
+		case <-tracker.workerStopped:
+			return
+		}
+	}
 }
 
-// Stop flushes and stops all workers.
+// Stop stops accepting new hits and waits until all hits in the queue have been saved.
+// This is equal to calling Shutdown without a deadline.
 func (tracker *Tracker) Stop() {
-	atomic.StoreInt32(&tracker.stopped, 1)
-	tracker.stopWorker()
-	tracker.flushHits()
+	_, _ = tracker.Shutdown(context.Background())
+}
+
+// Shutdown stops accepting new hits and waits until all hits in the queue have been saved or the context is done.
+// If the context is done first, Shutdown returns the number of hits that haven't been saved yet and the context error.
+// These hits are lost if the process exits, unless the write-ahead log is enabled (see TrackerConfig.WALDir).
+// Calling Shutdown more than once waits for the first call to finish.
+func (tracker *Tracker) Shutdown(ctx context.Context) (int, error) {
+	if atomic.CompareAndSwapInt32(&tracker.stopped, 0, 1) {
+		go tracker.stopWorker()
+	}
+
+	select {
+	case <-tracker.workerStopped:
+		return 0, nil
+	case <-ctx.Done():
+		return int(atomic.LoadInt64(&tracker.queued)), ctx.Err()
+	}
+}
+
+// Stats returns a snapshot of the Tracker counters.
+func (tracker *Tracker) Stats() TrackerStats {
+	tracker.ignoredMutex.Lock()
+	ignored := make(map[string]int64, len(tracker.ignored))
+
+	for reason, n := range tracker.ignored {
+		ignored[reason] = n
+	}
+
+	tracker.ignoredMutex.Unlock()
+	var lastFlush time.Time
+
+	if t := atomic.LoadInt64(&tracker.lastFlush); t != 0 {
+		lastFlush = time.Unix(0, t).UTC()
+	}
+
+	return TrackerStats{
+		Queued:    atomic.LoadInt64(&tracker.queued),
+		Accepted:  atomic.LoadInt64(&tracker.accepted),
+		Ignored:   ignored,
+		Saved:     atomic.LoadInt64(&tracker.saved),
+		Failed:    atomic.LoadInt64(&tracker.failed),
+		Dropped:   atomic.LoadInt64(&tracker.dropped),
+		Worker:    tracker.worker,
+		LastFlush: lastFlush,
+	}
+}
+
+// DroppedHits returns the number of hits that have been dropped because the queue was full.
+// See OverflowPolicy for details.
+func (tracker *Tracker) DroppedHits() int64 {
+	return atomic.LoadInt64(&tracker.dropped)
 }
 
 // SetGeoDB sets the GeoDB for the Tracker.
@@ -194,90 +451,341 @@ func (tracker *Tracker) SetGeoDB(geoDB *GeoDB) {
 	tracker.geoDB = geoDB
 }
 
-func (tracker *Tracker) startWorker() {
-	ctx, cancelFunc := context.WithCancel(context.Background())
-	tracker.workerCancel = cancelFunc
+// openWAL opens the write-ahead log and returns the hits left over from the last run.
+func (tracker *Tracker) openWAL(dir string, segmentSize int64) []queuedHit {
+	wal, unacknowledged, err := newHitLog(dir, segmentSize)
 
-	for i := 0; i < tracker.worker; i++ {
-		go tracker.aggregate(ctx)
+	if err != nil {
+		tracker.logger.Printf("error opening write-ahead log, hits will only be buffered in memory: %s", err)
+		return nil
 	}
+
+	tracker.wal = wal
+	atomic.AddInt64(&tracker.queued, int64(len(unacknowledged)))
+	return unacknowledged
 }
 
-func (tracker *Tracker) stopWorker() {
-	tracker.workerCancel()
+// replay saves the hits left over from the last run in the background, so that creating the Tracker isn't blocked
+// by a large backlog or an unreachable Store. Flush and Stop wait for the replay to finish.
+func (tracker *Tracker) replay(unacknowledged []queuedHit) {
+	defer tracker.workerWG.Done()
+	defer close(tracker.replayDone)
 
-	for i := 0; i < tracker.worker; i++ {
-		<-tracker.workerDone
+	for len(unacknowledged) > 0 {
+		n := tracker.workerBufferSize
+
+		if n > len(unacknowledged) {
+			n = len(unacknowledged)
+		}
+
+		tracker.saveHits(unacknowledged[:n])
+		unacknowledged = unacknowledged[n:]
 	}
 }
 
-func (tracker *Tracker) flushHits() {
-	// this function will make sure all dangling hits will be saved in database before shutdown
-	// hits are buffered before saving
-	hits := make([]Hit, 0, tracker.workerBufferSize)
+// hitFromRequest returns the Hit for given request and options.
+// It returns false if the request is ignored.
+func (tracker *Tracker) hitFromRequest(r *http.Request, options *HitOptions) (Hit, bool) {
+	if reason := ignoreHitReason(r); reason != "" {
+		tracker.ignore(reason)
+		return Hit{}, false
+	}
 
-	for {
-		stop := false
+	if options == nil {
+		options = &HitOptions{
+			ReferrerDomainBlacklist:                   tracker.referrerDomainBlacklist,
+			ReferrerDomainBlacklistIncludesSubdomains: tracker.referrerDomainBlacklistIncludesSubdomains,
+		}
+	}
 
-		select {
-		case hit := <-tracker.hits:
-			hits = append(hits, hit)
+	if tracker.geoDB != nil {
+		tracker.geoDBMutex.RLock()
+		defer tracker.geoDBMutex.RUnlock()
+		options.geoDB = tracker.geoDB
+	}
 
-			if len(hits) == tracker.workerBufferSize {
-				if err := tracker.store.SaveHits(hits); err != nil {
-					tracker.logger.Printf("error saving hits: %s", err)
-				}
+	if tracker.sessionCache != nil {
+		options.sessionCache = tracker.sessionCache
+	}
 
-				hits = hits[:0]
+	return HitFromRequest(r, tracker.salt, options), true
+}
+
+func (tracker *Tracker) hitFromInput(input *TrackInput) (Hit, bool) {
+	if reason := ignoreReason(input.UserAgent, input.Referrer); reason != "" {
+		tracker.ignore(reason)
+		return Hit{}, false
+	}
+
+	options := &HitOptions{
+		ReferrerDomainBlacklist:                   tracker.referrerDomainBlacklist,
+		ReferrerDomainBlacklistIncludesSubdomains: tracker.referrerDomainBlacklistIncludesSubdomains,
+		sessionCache:                              tracker.sessionCache,
+	}
+
+	if tracker.geoDB != nil {
+		tracker.geoDBMutex.RLock()
+		defer tracker.geoDBMutex.RUnlock()
+		options.geoDB = tracker.geoDB
+	}
+
+	return hitFromInput(input, tracker.salt, options), true
+}
+
+func (tracker *Tracker) ignore(reason string) {
+	tracker.ignoredMutex.Lock()
+	defer tracker.ignoredMutex.Unlock()
+	tracker.ignored[reason]++
+}
+
+func (tracker *Tracker) queueEvent(hit Hit, options *EventOptions) {
+	event, ok := newEvent(hit, options)
+
+	if !ok {
+		tracker.ignore(IgnoreReasonEventName)
+		return
+	}
+
+	tracker.queue(queuedHit{event: &event})
+}
+
+func (tracker *Tracker) queue(entry queuedHit) {
+	// the queue is closed on shutdown, so we must make sure it's still open while sending the hit
+	tracker.hitsMutex.RLock()
+	defer tracker.hitsMutex.RUnlock()
+
+	if atomic.LoadInt32(&tracker.stopped) > 0 {
+		return
+	}
+
+	atomic.AddInt64(&tracker.accepted, 1)
+
+	if tracker.wal != nil {
+		var pos *walPosition
+		var err error
+
+		if entry.event != nil {
+			pos, err = tracker.wal.appendEvent(*entry.event)
+		} else {
+			pos, err = tracker.wal.append(entry.hit)
+		}
+
+		if err != nil {
+			tracker.logger.Printf("error writing hit to write-ahead log: %s", err)
+		}
+
+		entry.pos = pos
+	}
+
+	// count the hit as queued before it is queued, so that it cannot be saved before it has been counted
+	atomic.AddInt64(&tracker.queued, 1)
+
+	if !tracker.enqueue(entry) {
+		atomic.AddInt64(&tracker.queued, -1)
+		tracker.drop(entry)
+	}
+}
+
+// enqueue adds the hit to the queue according to the OverflowPolicy.
+// It returns false if the hit has not been queued and must be dropped.
+func (tracker *Tracker) enqueue(entry queuedHit) bool {
+	switch tracker.overflow {
+	case OverflowDropNewest:
+		select {
+		case tracker.hits <- entry:
+			return true
+		default:
+			return false
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case tracker.hits <- entry:
+				return true
+			default:
+				select {
+				case oldest := <-tracker.hits:
+					atomic.AddInt64(&tracker.queued, -1)
+					tracker.drop(oldest)
+				default:
+				}
 			}
+		}
+	case OverflowSample:
+		if len(tracker.hits) > cap(tracker.hits)/2 && rand.Float64() >= tracker.overflowSampleRate {
+			return false
+		}
+
+		select {
+		case tracker.hits <- entry:
+			return true
 		default:
-			stop = true
+			return false
+		}
+	default:
+		if tracker.overflowTimeout == 0 {
+			tracker.hits <- entry
+			return true
 		}
 
-		if stop {
-			break
+		timer := time.NewTimer(tracker.overflowTimeout)
+		defer timer.Stop()
+
+		select {
+		case tracker.hits <- entry:
+			return true
+		case <-timer.C:
+			return false
 		}
 	}
+}
 
-	if len(hits) > 0 {
-		if err := tracker.store.SaveHits(hits); err != nil {
-			tracker.logger.Printf("error saving hits: %s", err)
+// drop counts the dropped hit and removes it from the write-ahead log.
+func (tracker *Tracker) drop(entry queuedHit) {
+	atomic.AddInt64(&tracker.dropped, 1)
+
+	if tracker.wal != nil && entry.pos != nil {
+		if err := tracker.wal.ack([]*walPosition{entry.pos}); err != nil {
+			tracker.logger.Printf("error acknowledging dropped hit in write-ahead log: %s", err)
+		}
+	}
+}
+
+func (tracker *Tracker) startWorker() {
+	tracker.workerFlush = make([]chan chan struct{}, tracker.worker)
+	tracker.workerWG.Add(tracker.worker)
+
+	for i := 0; i < tracker.worker; i++ {
+		tracker.workerFlush[i] = make(chan chan struct{})
+		go tracker.aggregate(tracker.workerFlush[i])
+	}
+}
+
+func (tracker *Tracker) stopWorker() {
+	// wait for hits that are being queued right now, the workers save all remaining hits once the queue has been closed
+	tracker.hitsMutex.Lock()
+	close(tracker.hits)
+	tracker.hitsMutex.Unlock()
+	tracker.workerWG.Wait()
+
+	if tracker.wal != nil {
+		if err := tracker.wal.close(); err != nil {
+			tracker.logger.Printf("error closing write-ahead log: %s", err)
 		}
 	}
+
+	if tracker.sessionCache != nil {
+		tracker.sessionCache.stop()
+	}
+
+	close(tracker.workerStopped)
 }
 
-func (tracker *Tracker) aggregate(ctx context.Context) {
-	hits := make([]Hit, 0, tracker.workerBufferSize)
+func (tracker *Tracker) aggregate(flush chan chan struct{}) {
+	defer tracker.workerWG.Done()
+	hits := make([]queuedHit, 0, tracker.workerBufferSize)
 
 	for {
 		select {
-		case hit := <-tracker.hits:
+		case hit, ok := <-tracker.hits:
+			if !ok {
+				if len(hits) > 0 {
+					tracker.saveHits(hits)
+				}
+
+				return
+			}
+
 			hits = append(hits, hit)
 
 			if len(hits) == tracker.workerBufferSize {
-				if err := tracker.store.SaveHits(hits); err != nil {
-					tracker.logger.Printf("error saving hits: %s", err)
-				}
+				tracker.saveHits(hits)
+				hits = hits[:0]
+			}
+		case done := <-flush:
+			hits = tracker.drain(hits)
 
+			if len(hits) > 0 {
+				tracker.saveHits(hits)
 				hits = hits[:0]
 			}
+
+			close(done)
 		case <-time.After(tracker.workerTimeout):
 			if len(hits) > 0 {
-				if err := tracker.store.SaveHits(hits); err != nil {
-					tracker.logger.Printf("error saving hits: %s", err)
-				}
-
+				tracker.saveHits(hits)
 				hits = hits[:0]
 			}
-		case <-ctx.Done():
-			if len(hits) > 0 {
-				if err := tracker.store.SaveHits(hits); err != nil {
-					tracker.logger.Printf("error saving hits: %s", err)
-				}
+		}
+	}
+}
+
+// drain takes all hits from the queue without blocking and saves them whenever the buffer is full.
+// It returns the buffer, which might still contain hits.
+func (tracker *Tracker) drain(hits []queuedHit) []queuedHit {
+	for {
+		select {
+		case hit, ok := <-tracker.hits:
+			if !ok {
+				return hits
 			}
 
-			tracker.workerDone <- true
-			return
+			hits = append(hits, hit)
+
+			if len(hits) == tracker.workerBufferSize {
+				tracker.saveHits(hits)
+				hits = hits[:0]
+			}
+		default:
+			return hits
+		}
+	}
+}
+
+// saveHits saves given hits and events and acknowledges them in the write-ahead log if they have been saved successfully.
+// Entries that failed to save are kept in the write-ahead log and will be saved the next time the Tracker is created.
+func (tracker *Tracker) saveHits(entries []queuedHit) {
+	hits := make([]Hit, 0, len(entries))
+	hitPositions := make([]*walPosition, 0, len(entries))
+	var events []Event
+	var eventPositions []*walPosition
+
+	for _, entry := range entries {
+		if entry.event != nil {
+			events = append(events, *entry.event)
+			eventPositions = append(eventPositions, entry.pos)
+		} else {
+			hits = append(hits, entry.hit)
+			hitPositions = append(hitPositions, entry.pos)
+		}
+	}
+
+	atomic.AddInt64(&tracker.queued, -int64(len(entries)))
+
+	if len(hits) > 0 {
+		tracker.ack(hitPositions, tracker.store.SaveHits(hits), "hits")
+	}
+
+	if len(events) > 0 {
+		tracker.ack(eventPositions, tracker.store.SaveEvents(events), "events")
+	}
+}
+
+// ack counts the entries as saved or failed depending on the error
+// and acknowledges them in the write-ahead log if they have been saved successfully.
+func (tracker *Tracker) ack(positions []*walPosition, err error, entity string) {
+	if err != nil {
+		atomic.AddInt64(&tracker.failed, int64(len(positions)))
+		tracker.logger.Printf("error saving %s: %s", entity, err)
+		return
+	}
+
+	atomic.AddInt64(&tracker.saved, int64(len(positions)))
+	atomic.StoreInt64(&tracker.lastFlush, time.Now().UnixNano())
+
+	if tracker.wal != nil {
+		if err := tracker.wal.ack(positions); err != nil {
+			tracker.logger.Printf("error acknowledging %s in write-ahead log: %s", entity, err)
 		}
 	}
 }
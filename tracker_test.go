@@ -1,8 +1,12 @@
 package pirsch
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
@@ -89,6 +93,365 @@ func TestTrackerHitLimit(t *testing.T) {
 	}
 }
 
+func TestTrackerWAL(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	// leave an unacknowledged hit in the log, like after a crash
+	wal, _, err := newHitLog(dir, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wal.append(Hit{Fingerprint: "crashed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wal.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newTestStore()
+	tracker := NewTracker(store, "salt", &TrackerConfig{
+		Worker: 1,
+		WALDir: dir,
+	})
+
+	tracker.Flush()
+
+	if len(store.hits) != 1 || store.hits[0].Fingerprint != "crashed" {
+		t.Fatalf("Unacknowledged hit must have been saved after startup, but was: %v", store.hits)
+	}
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Add("User-Agent", "valid")
+		tracker.Hit(req, nil)
+	}
+
+	tracker.Stop()
+
+	if len(store.hits) != 4 {
+		t.Fatalf("All requests must have been tracked, but was: %v", len(store.hits))
+	}
+
+	checkWALFiles(t, dir, 0)
+}
+
+func TestTrackerOverflow(t *testing.T) {
+	policies := []OverflowPolicy{OverflowBlock, OverflowDropNewest, OverflowDropOldest}
+	expectedPath := []string{"/1", "/1", "/4"}
+
+	for i, policy := range policies {
+		store := newBlockingStore()
+		tracker := NewTracker(store, "salt", &TrackerConfig{
+			Worker:           1,
+			WorkerBufferSize: 1,
+			Overflow:         policy,
+			OverflowTimeout:  time.Millisecond * 10,
+		})
+
+		// the first hit blocks the worker, the second one fills the queue, and the others overflow
+		tracker.Hit(newTrackerTestRequest("/0"), nil)
+		<-store.entered
+
+		for j := 1; j < 5; j++ {
+			tracker.Hit(newTrackerTestRequest(fmt.Sprintf("/%d", j)), nil)
+		}
+
+		if tracker.DroppedHits() != 3 {
+			t.Fatalf("Three hits must have been dropped for policy %v, but was: %v", policy, tracker.DroppedHits())
+		}
+
+		close(store.release)
+		tracker.Stop()
+
+		if len(store.hits) != 2 ||
+			store.hits[0].Path.String != "/0" ||
+			store.hits[1].Path.String != expectedPath[i] {
+			t.Fatalf("Hits not as expected for policy %v: %v", policy, store.hits)
+		}
+	}
+}
+
+func TestTrackerOverflowSample(t *testing.T) {
+	store := newBlockingStore()
+	tracker := NewTracker(store, "salt", &TrackerConfig{
+		Worker:             1,
+		WorkerBufferSize:   10,
+		WorkerTimeout:      time.Millisecond * 10,
+		Overflow:           OverflowSample,
+		OverflowSampleRate: 0.5,
+	})
+	tracker.Hit(newTrackerTestRequest("/"), nil)
+	<-store.entered
+
+	for i := 0; i < 100; i++ {
+		tracker.Hit(newTrackerTestRequest("/"), nil)
+	}
+
+	// the queue holds 10 hits, everything else must have been dropped
+	if tracker.DroppedHits() != 90 {
+		t.Fatalf("90 hits must have been dropped, but was: %v", tracker.DroppedHits())
+	}
+
+	close(store.release)
+	tracker.Stop()
+}
+
+func TestTrackerStats(t *testing.T) {
+	store := newTestStore()
+	tracker := NewTracker(store, "salt", &TrackerConfig{Worker: 2})
+	stats := tracker.Stats()
+
+	if stats.Worker != 2 || stats.Accepted != 0 || !stats.LastFlush.IsZero() {
+		t.Fatalf("Stats not as expected: %v", stats)
+	}
+
+	tracker.Hit(newTrackerTestRequest("/"), nil)
+	tracker.Hit(newTrackerTestRequest("/foo"), nil)
+	tracker.Hit(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	bot := newTrackerTestRequest("/")
+	bot.Header.Set("User-Agent", "Googlebot")
+	tracker.Hit(bot, nil)
+	stats = tracker.Stats()
+
+	if stats.Accepted != 2 || stats.Queued != 2 || stats.Saved != 0 {
+		t.Fatalf("Stats not as expected: %v", stats)
+	}
+
+	tracker.Stop()
+	stats = tracker.Stats()
+
+	if stats.Accepted != 2 ||
+		stats.Queued != 0 ||
+		stats.Saved != 2 ||
+		stats.Failed != 0 ||
+		stats.Dropped != 0 ||
+		stats.Ignored[IgnoreReasonUserAgent] != 1 ||
+		stats.Ignored[IgnoreReasonBot] != 1 ||
+		stats.LastFlush.IsZero() {
+		t.Fatalf("Stats not as expected: %v", stats)
+	}
+}
+
+func TestTrackerFlush(t *testing.T) {
+	store := newTestStore()
+	tracker := NewTracker(store, "salt", &TrackerConfig{
+		Worker:           1,
+		WorkerBufferSize: 10,
+		WorkerTimeout:    time.Minute,
+	})
+
+	for i := 0; i < 3; i++ {
+		tracker.Hit(newTrackerTestRequest("/"), nil)
+	}
+
+	tracker.Flush()
+
+	if len(store.hits) != 3 {
+		t.Fatalf("All hits must have been flushed, but was: %v", len(store.hits))
+	}
+
+	// the workers must still be running
+	tracker.Hit(newTrackerTestRequest("/"), nil)
+	tracker.Flush()
+
+	if len(store.hits) != 4 {
+		t.Fatalf("All hits must have been flushed, but was: %v", len(store.hits))
+	}
+
+	tracker.Stop()
+	tracker.Flush()
+	tracker.Hit(newTrackerTestRequest("/"), nil)
+
+	if len(store.hits) != 4 {
+		t.Fatalf("No hits must be accepted after the Tracker has been stopped, but was: %v", len(store.hits))
+	}
+}
+
+func TestTrackerShutdown(t *testing.T) {
+	store := newTestStore()
+	tracker := NewTracker(store, "salt", &TrackerConfig{Worker: 2})
+
+	for i := 0; i < 5; i++ {
+		tracker.Hit(newTrackerTestRequest("/"), nil)
+	}
+
+	lost, err := tracker.Shutdown(context.Background())
+
+	if lost != 0 || err != nil {
+		t.Fatalf("All hits must have been saved, but was: %v %v", lost, err)
+	}
+
+	if len(store.hits) != 5 {
+		t.Fatalf("All hits must have been saved, but was: %v", len(store.hits))
+	}
+}
+
+func TestTrackerShutdownDeadline(t *testing.T) {
+	store := newBlockingStore()
+	tracker := NewTracker(store, "salt", &TrackerConfig{
+		Worker:           1,
+		WorkerBufferSize: 1,
+	})
+	tracker.Hit(newTrackerTestRequest("/"), nil)
+	<-store.entered
+	tracker.Hit(newTrackerTestRequest("/"), nil)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	lost, err := tracker.Shutdown(ctx)
+
+	if lost != 1 || err != context.DeadlineExceeded {
+		t.Fatalf("One hit must have been lost, but was: %v %v", lost, err)
+	}
+
+	close(store.release)
+	tracker.Stop()
+
+	if len(store.hits) != 2 {
+		t.Fatalf("All hits must have been saved in the background, but was: %v", len(store.hits))
+	}
+}
+
+func TestTrackerHitSync(t *testing.T) {
+	store := newTestStore()
+	tracker := NewTracker(store, "salt", nil)
+	hitTime := time.Now().UTC().Add(-time.Hour * 24 * 3)
+
+	if err := tracker.HitSync(newTrackerTestRequest("/"), &HitOptions{Time: hitTime}); err != nil {
+		t.Fatalf("Hit must have been saved, but was: %v", err)
+	}
+
+	if err := tracker.HitSync(httptest.NewRequest(http.MethodGet, "/", nil), nil); err != nil {
+		t.Fatalf("Ignored hit must not return an error, but was: %v", err)
+	}
+
+	if len(store.hits) != 1 || !store.hits[0].Time.Equal(hitTime) {
+		t.Fatalf("Hit must have been saved right away, but was: %v", store.hits)
+	}
+
+	tracker.Stop()
+
+	if err := tracker.HitSync(newTrackerTestRequest("/"), nil); err != ErrTrackerStopped {
+		t.Fatalf("Hit must not be accepted after the Tracker has been stopped, but was: %v", err)
+	}
+
+	failing := &failingStore{}
+	tracker = NewTracker(failing, "salt", nil)
+	defer tracker.Stop()
+
+	if err := tracker.HitSync(newTrackerTestRequest("/"), nil); err != errSaveHits {
+		t.Fatalf("Store error must have been returned, but was: %v", err)
+	}
+
+	if stats := tracker.Stats(); stats.Failed != 1 || stats.Saved != 0 {
+		t.Fatalf("Stats not as expected: %v", stats)
+	}
+}
+
+func TestTrackerTrack(t *testing.T) {
+	store := newTestStore()
+	tracker := NewTracker(store, "salt", &TrackerConfig{
+		Worker:                  1,
+		ReferrerDomainBlacklist: []string{"example.com"},
+	})
+	tracker.Track(&TrackInput{
+		TenantID:       NewTenantID(42),
+		IP:             "81.2.69.142",
+		UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/87.0.4280.88 Safari/537.36",
+		AcceptLanguage: "de-DE,de;q=0.9",
+		URL:            "https://example.com/foo?bar=baz",
+		Referrer:       "https://example.com/",
+		ScreenWidth:    1920,
+		ScreenHeight:   1080,
+	})
+	tracker.Track(&TrackInput{URL: "https://example.com/"})
+	tracker.Track(&TrackInput{UserAgent: "Googlebot", URL: "https://example.com/"})
+	tracker.Track(nil)
+	tracker.Stop()
+
+	if len(store.hits) != 1 {
+		t.Fatalf("One hit must have been tracked, but was: %v", len(store.hits))
+	}
+
+	hit := store.hits[0]
+
+	if hit.TenantID.Int64 != 42 ||
+		hit.Fingerprint != fingerprintVisitor(hit.UserAgent.String, "81.2.69.142", "salt", hit.Time) ||
+		hit.Path.String != "/foo" ||
+		hit.URL.String != "https://example.com/foo?bar=baz" ||
+		hit.Language.String != "de" ||
+		hit.Referrer.Valid ||
+		hit.OS.String != OSWindows ||
+		hit.Browser.String != BrowserChrome ||
+		!hit.Desktop ||
+		hit.ScreenWidth != 1920 || hit.ScreenHeight != 1080 {
+		t.Fatalf("Hit not as expected: %v", hit)
+	}
+
+	if stats := tracker.Stats(); stats.Ignored[IgnoreReasonUserAgent] != 1 || stats.Ignored[IgnoreReasonBot] != 1 {
+		t.Fatalf("Ignored hits must have been counted, but was: %v", stats.Ignored)
+	}
+}
+
+func TestTrackerEvent(t *testing.T) {
+	store := newTestStore()
+	tracker := NewTracker(store, "salt", &TrackerConfig{Worker: 1})
+	tracker.Hit(newTrackerTestRequest("/"), nil)
+	tracker.Event(newTrackerTestRequest("/download"), EventOptions{
+		Name:  "download",
+		Meta:  map[string]string{"file": "report.pdf", "format": "pdf"},
+		Value: 42.5,
+	}, nil)
+	tracker.Event(newTrackerTestRequest("/"), EventOptions{Name: " "}, nil)
+	tracker.Event(httptest.NewRequest(http.MethodGet, "/", nil), EventOptions{Name: "signup"}, nil)
+	tracker.TrackEvent(&TrackInput{
+		UserAgent: "valid",
+		URL:       "https://example.com/signup",
+	}, EventOptions{Name: "signup"})
+	tracker.Stop()
+
+	if len(store.hits) != 1 || len(store.events) != 2 {
+		t.Fatalf("One hit and two events must have been saved, but was: %v %v", len(store.hits), len(store.events))
+	}
+
+	event := store.events[0]
+
+	if event.Name != "download" ||
+		event.Path.String != "/download" ||
+		event.Fingerprint == "" ||
+		event.Value != 42.5 ||
+		len(event.MetaKeys) != 2 || event.MetaKeys[0] != "file" || event.MetaKeys[1] != "format" ||
+		len(event.MetaValues) != 2 || event.MetaValues[0] != "report.pdf" || event.MetaValues[1] != "pdf" {
+		t.Fatalf("Event not as expected: %v", event)
+	}
+
+	if store.events[1].Name != "signup" || store.events[1].Path.String != "/signup" {
+		t.Fatalf("Event not as expected: %v", store.events[1])
+	}
+
+	if stats := tracker.Stats(); stats.Saved != 3 || stats.Ignored[IgnoreReasonEventName] != 1 || stats.Ignored[IgnoreReasonUserAgent] != 1 {
+		t.Fatalf("Stats not as expected: %v", stats)
+	}
+}
+
+func TestTrackerEventWAL(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+	tracker := NewTracker(&failingStore{}, "salt", &TrackerConfig{WALDir: dir})
+	tracker.Event(newTrackerTestRequest("/"), EventOptions{Name: "signup"}, nil)
+	tracker.Stop()
+	store := newTestStore()
+	tracker = NewTracker(store, "salt", &TrackerConfig{WALDir: dir})
+	tracker.Stop()
+
+	if len(store.hits) != 0 || len(store.events) != 1 || store.events[0].Name != "signup" {
+		t.Fatalf("Event must have been replayed, but was: %v %v", store.hits, store.events)
+	}
+}
+
 func TestTrackerCountryCode(t *testing.T) {
 	geoDB, err := NewGeoDB(filepath.Join("geodb/GeoIP2-Country-Test.mmdb"))
 
@@ -183,3 +546,50 @@ func BenchmarkTracker(b *testing.B) {
 
 	tracker.Stop()
 }
+
+var errSaveHits = errors.New("error saving hits")
+
+// failingStore fails to save hits.
+type failingStore struct {
+	storeMock
+}
+
+func (store *failingStore) SaveHits(hits []Hit) error {
+	return errSaveHits
+}
+
+func (store *failingStore) SaveEvents(events []Event) error {
+	return errSaveHits
+}
+
+// blockingStore blocks saving hits until release is closed.
+type blockingStore struct {
+	storeMock
+
+	entered chan bool
+	release chan bool
+}
+
+func newBlockingStore() *blockingStore {
+	return &blockingStore{
+		storeMock: *newTestStore(),
+		entered:   make(chan bool, 1),
+		release:   make(chan bool),
+	}
+}
+
+func (store *blockingStore) SaveHits(hits []Hit) error {
+	select {
+	case store.entered <- true:
+	default:
+	}
+
+	<-store.release
+	return store.storeMock.SaveHits(hits)
+}
+
+func newTrackerTestRequest(path string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Add("User-Agent", "valid")
+	return req
+}
@@ -3,6 +3,7 @@ package pirsch
 import (
 	"context"
 	"database/sql"
+	"sort"
 	"time"
 )
 
@@ -45,6 +46,28 @@ func containsString(list []string, str string) bool {
 	return false
 }
 
+// mergeDays returns the distinct days of both lists in ascending order.
+func mergeDays(a, b []time.Time) []time.Time {
+	days := make([]time.Time, 0, len(a)+len(b))
+	seen := make(map[time.Time]struct{})
+
+	for _, list := range [][]time.Time{a, b} {
+		for _, day := range list {
+			day = day.UTC()
+
+			if _, found := seen[day]; !found {
+				seen[day] = struct{}{}
+				days = append(days, day)
+			}
+		}
+	}
+
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Before(days[j])
+	})
+	return days
+}
+
 func today() time.Time {
 	now := time.Now().UTC()
 	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
@@ -49,3 +49,17 @@ func TestHourInTimezone(t *testing.T) {
 		t.Fatalf("Time not as expected: %v", out)
 	}
 }
+
+func TestMergeDays(t *testing.T) {
+	a := []time.Time{day(2020, 9, 1, 0), day(2020, 9, 3, 0)}
+	b := []time.Time{day(2020, 9, 2, 0), day(2020, 9, 3, 0)}
+	days := mergeDays(a, b)
+
+	if len(days) != 3 {
+		t.Fatalf("Three days must have been returned, but was: %v", len(days))
+	}
+
+	if !days[0].Equal(day(2020, 9, 1, 0)) || !days[1].Equal(day(2020, 9, 2, 0)) || !days[2].Equal(day(2020, 9, 3, 0)) {
+		t.Fatalf("Days not as expected: %v", days)
+	}
+}
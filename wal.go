@@ -0,0 +1,350 @@
+package pirsch
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultWALSegmentSize = 1024 * 1024 * 4
+	walSegmentExt         = ".log"
+	walAckExt             = ".ack"
+	walMaxLineSize        = 1024 * 1024
+)
+
+// walPosition is the position of an entry within the hitLog.
+type walPosition struct {
+	segment uint64
+	entry   uint64
+}
+
+// walRecord is a single entry written to a hitLog segment.
+// The event is set if the entry is an event instead of a hit.
+type walRecord struct {
+	Entry uint64 `json:"entry"`
+	Hit   Hit    `json:"hit"`
+	Event *Event `json:"event,omitempty"`
+}
+
+// hitLog is a segmented write-ahead log for hits and events.
+// Each hit is appended to the current segment before it is queued and acknowledged once it has been saved.
+// Acknowledgements are written to a separate file per segment. Segments are removed as soon as they are
+// full (or have been replaced after a restart) and all of their entries have been acknowledged.
+// The log is not synced to disk on each write, so it protects against process crashes, but not against power loss.
+type hitLog struct {
+	dir          string
+	segmentSize  int64
+	current      *os.File
+	currentIndex uint64
+	currentSize  int64
+	currentEntry uint64
+	pending      map[uint64]int // segment -> number of unacknowledged entries
+	m            sync.Mutex
+}
+
+// newHitLog opens the write-ahead log in given directory and returns all entries that haven't been acknowledged yet.
+// The directory will be created if required. If segmentSize is 0 or less, the default will be used.
+func newHitLog(dir string, segmentSize int64) (*hitLog, []queuedHit, error) {
+	if segmentSize <= 0 {
+		segmentSize = defaultWALSegmentSize
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, err
+	}
+
+	wal := &hitLog{
+		dir:         dir,
+		segmentSize: segmentSize,
+		pending:     make(map[uint64]int),
+	}
+	segments, err := wal.segments()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	unacknowledged := make([]queuedHit, 0)
+
+	for _, segment := range segments {
+		entries, err := wal.readSegment(segment)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(entries) == 0 {
+			if err := wal.removeSegment(segment); err != nil {
+				return nil, nil, err
+			}
+		} else {
+			wal.pending[segment] = len(entries)
+			unacknowledged = append(unacknowledged, entries...)
+		}
+
+		wal.currentIndex = segment
+	}
+
+	if err := wal.openSegment(wal.currentIndex + 1); err != nil {
+		return nil, nil, err
+	}
+
+	return wal, unacknowledged, nil
+}
+
+// append writes the hit to the current segment and returns its position.
+func (wal *hitLog) append(hit Hit) (*walPosition, error) {
+	return wal.appendRecord(walRecord{Hit: hit})
+}
+
+// appendEvent writes the event to the current segment and returns its position.
+func (wal *hitLog) appendEvent(event Event) (*walPosition, error) {
+	return wal.appendRecord(walRecord{Event: &event})
+}
+
+func (wal *hitLog) appendRecord(record walRecord) (*walPosition, error) {
+	wal.m.Lock()
+	defer wal.m.Unlock()
+
+	if wal.currentSize >= wal.segmentSize {
+		if err := wal.rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	wal.currentEntry++
+	record.Entry = wal.currentEntry
+	out, err := json.Marshal(record)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out = append(out, '\n')
+	n, err := wal.current.Write(out)
+	wal.currentSize += int64(n)
+
+	if err != nil {
+		return nil, err
+	}
+
+	wal.pending[wal.currentIndex]++
+	return &walPosition{segment: wal.currentIndex, entry: wal.currentEntry}, nil
+}
+
+// ack acknowledges given entries, so that they won't be replayed.
+// Positions set to nil are ignored.
+func (wal *hitLog) ack(positions []*walPosition) error {
+	wal.m.Lock()
+	defer wal.m.Unlock()
+	bySegment := make(map[uint64][]uint64)
+
+	for _, pos := range positions {
+		if pos != nil {
+			bySegment[pos.segment] = append(bySegment[pos.segment], pos.entry)
+		}
+	}
+
+	for segment, entries := range bySegment {
+		wal.pending[segment] -= len(entries)
+
+		if wal.pending[segment] <= 0 && segment != wal.currentIndex {
+			if err := wal.removeSegment(segment); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := wal.writeAck(segment, entries); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// close closes the current segment.
+// The segment is removed if all of its entries have been acknowledged.
+func (wal *hitLog) close() error {
+	wal.m.Lock()
+	defer wal.m.Unlock()
+
+	if err := wal.current.Close(); err != nil {
+		return err
+	}
+
+	if wal.pending[wal.currentIndex] <= 0 {
+		return wal.removeSegment(wal.currentIndex)
+	}
+
+	return nil
+}
+
+func (wal *hitLog) rotate() error {
+	if err := wal.current.Close(); err != nil {
+		return err
+	}
+
+	if wal.pending[wal.currentIndex] <= 0 {
+		if err := wal.removeSegment(wal.currentIndex); err != nil {
+			return err
+		}
+	}
+
+	return wal.openSegment(wal.currentIndex + 1)
+}
+
+func (wal *hitLog) openSegment(segment uint64) error {
+	file, err := os.OpenFile(wal.segmentPath(segment, walSegmentExt), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	wal.current = file
+	wal.currentIndex = segment
+	wal.currentSize = 0
+	wal.currentEntry = 0
+	return nil
+}
+
+func (wal *hitLog) writeAck(segment uint64, entries []uint64) error {
+	file, err := os.OpenFile(wal.segmentPath(segment, walAckExt), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+
+	for _, entry := range entries {
+		sb.WriteString(strconv.FormatUint(entry, 10))
+		sb.WriteRune('\n')
+	}
+
+	if _, err := file.WriteString(sb.String()); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func (wal *hitLog) removeSegment(segment uint64) error {
+	delete(wal.pending, segment)
+
+	if err := os.Remove(wal.segmentPath(segment, walSegmentExt)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.Remove(wal.segmentPath(segment, walAckExt)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// segments returns the indices of all segments in the log directory in ascending order.
+func (wal *hitLog) segments() ([]uint64, error) {
+	files, err := ioutil.ReadDir(wal.dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]uint64, 0, len(files))
+
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != walSegmentExt {
+			continue
+		}
+
+		segment, err := strconv.ParseUint(strings.TrimSuffix(file.Name(), walSegmentExt), 10, 64)
+
+		if err == nil {
+			segments = append(segments, segment)
+		}
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i] < segments[j]
+	})
+	return segments, nil
+}
+
+// readSegment returns all unacknowledged entries of given segment.
+// Incomplete or malformed lines (like a partial write before a crash) are skipped.
+func (wal *hitLog) readSegment(segment uint64) ([]queuedHit, error) {
+	acknowledged, err := wal.readAcks(segment)
+
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(wal.segmentPath(segment, walSegmentExt))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 4096), walMaxLineSize)
+	entries := make([]queuedHit, 0)
+
+	for scanner.Scan() {
+		var record walRecord
+
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+
+		if _, found := acknowledged[record.Entry]; !found {
+			entries = append(entries, queuedHit{
+				hit:   record.Hit,
+				event: record.Event,
+				pos:   &walPosition{segment: segment, entry: record.Entry},
+			})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (wal *hitLog) readAcks(segment uint64) (map[uint64]struct{}, error) {
+	acknowledged := make(map[uint64]struct{})
+	content, err := ioutil.ReadFile(wal.segmentPath(segment, walAckExt))
+
+	if os.IsNotExist(err) {
+		return acknowledged, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		entry, err := strconv.ParseUint(strings.TrimSpace(line), 10, 64)
+
+		if err == nil {
+			acknowledged[entry] = struct{}{}
+		}
+	}
+
+	return acknowledged, nil
+}
+
+func (wal *hitLog) segmentPath(segment uint64, ext string) string {
+	return filepath.Join(wal.dir, fmt.Sprintf("%020d%s", segment, ext))
+}
@@ -0,0 +1,137 @@
+package pirsch
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHitLog(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+	wal, unacknowledged, err := newHitLog(dir, 0)
+
+	if err != nil {
+		t.Fatalf("Write-ahead log must have been opened, but was: %v", err)
+	}
+
+	if len(unacknowledged) != 0 {
+		t.Fatalf("No entries must have been returned, but was: %v", len(unacknowledged))
+	}
+
+	paths := []string{"/", "/foo", "/bar"}
+	positions := make([]*walPosition, 0, len(paths))
+
+	for _, path := range paths {
+		pos, err := wal.append(Hit{Fingerprint: "fp", Path: sql.NullString{String: path, Valid: true}})
+
+		if err != nil {
+			t.Fatalf("Hit must have been appended, but was: %v", err)
+		}
+
+		positions = append(positions, pos)
+	}
+
+	if err := wal.ack(positions[:1]); err != nil {
+		t.Fatalf("Hit must have been acknowledged, but was: %v", err)
+	}
+
+	if err := wal.close(); err != nil {
+		t.Fatalf("Write-ahead log must have been closed, but was: %v", err)
+	}
+
+	wal, unacknowledged, err = newHitLog(dir, 0)
+
+	if err != nil {
+		t.Fatalf("Write-ahead log must have been opened, but was: %v", err)
+	}
+
+	if len(unacknowledged) != 2 ||
+		unacknowledged[0].hit.Path.String != "/foo" ||
+		unacknowledged[1].hit.Path.String != "/bar" ||
+		unacknowledged[0].hit.Fingerprint != "fp" {
+		t.Fatalf("Unacknowledged hits not as expected: %v", unacknowledged)
+	}
+
+	if err := wal.ack([]*walPosition{unacknowledged[0].pos, unacknowledged[1].pos}); err != nil {
+		t.Fatalf("Hits must have been acknowledged, but was: %v", err)
+	}
+
+	if err := wal.close(); err != nil {
+		t.Fatalf("Write-ahead log must have been closed, but was: %v", err)
+	}
+
+	checkWALFiles(t, dir, 0)
+}
+
+func TestHitLogRotate(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+	wal, _, err := newHitLog(dir, 1)
+
+	if err != nil {
+		t.Fatalf("Write-ahead log must have been opened, but was: %v", err)
+	}
+
+	positions := make([]*walPosition, 0, 3)
+
+	for i := 0; i < 3; i++ {
+		pos, err := wal.append(Hit{Fingerprint: "fp"})
+
+		if err != nil {
+			t.Fatalf("Hit must have been appended, but was: %v", err)
+		}
+
+		positions = append(positions, pos)
+	}
+
+	if positions[0].segment == positions[1].segment || positions[1].segment == positions[2].segment {
+		t.Fatalf("Each hit must have been written to its own segment, but was: %v %v %v", positions[0], positions[1], positions[2])
+	}
+
+	checkWALFiles(t, dir, 3)
+
+	if err := wal.ack(positions[:2]); err != nil {
+		t.Fatalf("Hits must have been acknowledged, but was: %v", err)
+	}
+
+	checkWALFiles(t, dir, 1)
+
+	if err := wal.close(); err != nil {
+		t.Fatalf("Write-ahead log must have been closed, but was: %v", err)
+	}
+
+	checkWALFiles(t, dir, 1)
+}
+
+func createTempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "pirsch")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func checkWALFiles(t *testing.T, dir string, n int) {
+	files, err := ioutil.ReadDir(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	segments := 0
+
+	for _, file := range files {
+		if filepath.Ext(file.Name()) == walSegmentExt {
+			segments++
+		}
+	}
+
+	if segments != n {
+		t.Fatalf("%v segments must exist, but was: %v", n, segments)
+	}
+}